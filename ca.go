@@ -0,0 +1,282 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"time"
+)
+
+// LocalCA provides a simple local certificate authority suitable for issuing
+// server and client certificates in development and testing setups.
+type LocalCA struct {
+	certificate *tls.Certificate
+}
+
+// NewLocalCA loads the CA certificate stored under the given name from the given [KeyStore].
+//
+// If the [KeyStore] does not contain the named entry, a new self-signed CA certificate is
+// generated using the given algorithm and lifetime and put into the [KeyStore].
+func NewLocalCA(store KeyStore, name string, algorithm CertificateAlgorithm, lifetime time.Duration) (*LocalCA, error) {
+	certificate, err := store.Get(name)
+	if err == nil {
+		return NewLocalCAFromCertificate(certificate)
+	}
+	if !errors.Is(err, ErrKeyStoreEntryNotFound) {
+		return nil, err
+	}
+	slog.Info("generating local CA certificate", slog.String("name", name), slog.String("algorithm", string(algorithm)))
+	_, privateKey, err := algorithm.GenerateCertificateKey()
+	if err != nil {
+		return nil, err
+	}
+	certificate, err = GenerateLocalCACertificate(name, privateKey.(crypto.Signer), lifetime)
+	if err != nil {
+		return nil, err
+	}
+	err = store.Put(name, certificate)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalCA{certificate: certificate}, nil
+}
+
+// NewLocalCAFromCertificate creates a [LocalCA] using the given CA certificate.
+//
+// The certificate's private key must implement [crypto.Signer], hence opaque keys (HSM, TPM, KMS)
+// are supported.
+func NewLocalCAFromCertificate(certificate *tls.Certificate) (*LocalCA, error) {
+	if certificate.Leaf == nil || !certificate.Leaf.IsCA {
+		return nil, fmt.Errorf("not a CA certificate")
+	}
+	if _, ok := certificate.PrivateKey.(crypto.Signer); !ok {
+		return nil, fmt.Errorf("CA private key is not a signer: %T", certificate.PrivateKey)
+	}
+	return &LocalCA{certificate: certificate}, nil
+}
+
+// GenerateLocalCACertificate generates a self-signed CA certificate for the given signer.
+func GenerateLocalCACertificate(name string, signer crypto.Signer, lifetime time.Duration) (*tls.Certificate, error) {
+	err := checkPolicyPublicKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	now := certificateNow()
+	template := &x509.Certificate{
+		SerialNumber:          nextCertificateSerialNumber(),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             now,
+		NotAfter:              now.Add(lifetime),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	certificateBytes, err := x509.CreateCertificate(rand.Reader, template, template, signer.Public(), signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CA certificate (cause: %w)", err)
+	}
+	err = lintGeneratedCertificate(certificateBytes)
+	if err != nil {
+		return nil, err
+	}
+	return newTLSCertificate([][]byte{certificateBytes}, signer)
+}
+
+// Certificate gets the CA certificate.
+func (ca *LocalCA) Certificate() *x509.Certificate {
+	return ca.certificate.Leaf
+}
+
+// IssueServerCertificate issues a server certificate for the given address.
+//
+// The address may be a host name or IP address optionally followed by a port (which is ignored).
+// The certificate's lifetime is limited to the CA certificate's lifetime.
+func (ca *LocalCA) IssueServerCertificate(address string, algorithm CertificateAlgorithm, lifetime time.Duration) (*tls.Certificate, error) {
+	slog.Info("issuing server certificate", slog.String("address", address), slog.String("algorithm", string(algorithm)))
+	_, privateKey, err := algorithm.GenerateCertificateKey()
+	if err != nil {
+		return nil, err
+	}
+	return ca.IssueServerSignerCertificate(address, privateKey.(crypto.Signer), lifetime)
+}
+
+// IssueServerSignerCertificate issues a server certificate for the given address and signer.
+//
+// See [LocalCA.IssueServerCertificate] for details.
+func (ca *LocalCA) IssueServerSignerCertificate(address string, signer crypto.Signer, lifetime time.Duration) (*tls.Certificate, error) {
+	host, err := addressHost(address)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: host},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	setCertificateHost(template, host)
+	chain, err := ca.issueCertificate(template, signer.Public(), lifetime)
+	if err != nil {
+		return nil, err
+	}
+	return newTLSCertificate(chain, signer)
+}
+
+// IssueClientCertificate issues a client certificate for the given name.
+//
+// The certificate's lifetime is limited to the CA certificate's lifetime.
+func (ca *LocalCA) IssueClientCertificate(name string, algorithm CertificateAlgorithm, lifetime time.Duration) (*tls.Certificate, error) {
+	slog.Info("issuing client certificate", slog.String("name", name), slog.String("algorithm", string(algorithm)))
+	_, privateKey, err := algorithm.GenerateCertificateKey()
+	if err != nil {
+		return nil, err
+	}
+	return ca.IssueClientSignerCertificate(name, privateKey.(crypto.Signer), lifetime)
+}
+
+// IssueClientSignerCertificate issues a client certificate for the given name and signer.
+//
+// See [LocalCA.IssueClientCertificate] for details.
+func (ca *LocalCA) IssueClientSignerCertificate(name string, signer crypto.Signer, lifetime time.Duration) (*tls.Certificate, error) {
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: name},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	chain, err := ca.issueCertificate(template, signer.Public(), lifetime)
+	if err != nil {
+		return nil, err
+	}
+	return newTLSCertificate(chain, signer)
+}
+
+// SignCertificateRequest issues a certificate for the given PEM or DER encoded certificate signing request.
+//
+// Subject and subject alternative names are taken from the request. The certificate is issued for the
+// given extended key usages. The returned chain starts with the issued certificate.
+func (ca *LocalCA) SignCertificateRequest(csrData []byte, extKeyUsage []x509.ExtKeyUsage, lifetime time.Duration) ([]*x509.Certificate, error) {
+	csrBytes := csrData
+	if csrBlock, _ := pem.Decode(csrData); csrBlock != nil {
+		csrBytes = csrBlock.Bytes
+	}
+	csr, err := x509.ParseCertificateRequest(csrBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate request (cause: %w)", err)
+	}
+	err = csr.CheckSignature()
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate request signature (cause: %w)", err)
+	}
+	template := &x509.Certificate{
+		Subject:        csr.Subject,
+		DNSNames:       csr.DNSNames,
+		IPAddresses:    csr.IPAddresses,
+		EmailAddresses: csr.EmailAddresses,
+		URIs:           csr.URIs,
+		ExtKeyUsage:    extKeyUsage,
+	}
+	chain, err := ca.issueCertificate(template, csr.PublicKey, lifetime)
+	if err != nil {
+		return nil, err
+	}
+	certificates := make([]*x509.Certificate, 0, len(chain))
+	for _, certificateBytes := range chain {
+		certificate, err := x509.ParseCertificate(certificateBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate (cause: %w)", err)
+		}
+		certificates = append(certificates, certificate)
+	}
+	return certificates, nil
+}
+
+// CreateRevocationList creates a certificate revocation list (CRL) listing the given revoked
+// certificates.
+//
+// The CRL is identified by the given (monotonically increasing) number. Its next update time is
+// derived from the given lifetime and limited to the CA certificate's lifetime.
+func (ca *LocalCA) CreateRevocationList(revoked []x509.RevocationListEntry, number *big.Int, lifetime time.Duration) (*x509.RevocationList, error) {
+	now := certificateNow()
+	template := &x509.RevocationList{
+		RevokedCertificateEntries: revoked,
+		Number:                    number,
+		ThisUpdate:                now,
+		NextUpdate:                now.Add(lifetime),
+	}
+	if template.NextUpdate.After(ca.certificate.Leaf.NotAfter) {
+		template.NextUpdate = ca.certificate.Leaf.NotAfter
+	}
+	crlBytes, err := x509.CreateRevocationList(rand.Reader, template, ca.certificate.Leaf, ca.certificate.PrivateKey.(crypto.Signer))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create revocation list (cause: %w)", err)
+	}
+	crl, err := x509.ParseRevocationList(crlBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse revocation list (cause: %w)", err)
+	}
+	return crl, nil
+}
+
+// WriteRevocationList writes the given certificate revocation list (CRL) PEM encoded to the
+// given file.
+//
+// The file is replaced atomically using the certificate file mode (see [WriteOption] for the
+// available write options).
+func WriteRevocationList(crl *x509.RevocationList, crlFile string, options ...WriteOption) error {
+	writeOptions := newWriteOptions(options)
+	crlData := pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crl.Raw})
+	return writeFile(&fileWrite{path: crlFile, data: crlData, mode: writeOptions.certMode}, writeOptions)
+}
+
+func (ca *LocalCA) issueCertificate(template *x509.Certificate, publicKey crypto.PublicKey, lifetime time.Duration) ([][]byte, error) {
+	err := checkPolicyPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	now := certificateNow()
+	template.SerialNumber = nextCertificateSerialNumber()
+	template.NotBefore = now
+	template.NotAfter = now.Add(lifetime)
+	if template.NotAfter.After(ca.certificate.Leaf.NotAfter) {
+		template.NotAfter = ca.certificate.Leaf.NotAfter
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	if _, ok := publicKey.(*rsa.PublicKey); ok {
+		template.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+	template.BasicConstraintsValid = true
+	certificateBytes, err := x509.CreateCertificate(rand.Reader, template, ca.certificate.Leaf, publicKey, ca.certificate.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate (cause: %w)", err)
+	}
+	err = lintGeneratedCertificate(certificateBytes)
+	if err != nil {
+		return nil, err
+	}
+	// the CA certificate itself is not part of the chain, only intermediates (if any)
+	return append([][]byte{certificateBytes}, ca.certificate.Certificate[1:]...), nil
+}
+
+func newTLSCertificate(chain [][]byte, privateKey crypto.PrivateKey) (*tls.Certificate, error) {
+	leaf, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate (cause: %w)", err)
+	}
+	return &tls.Certificate{
+		Certificate: chain,
+		PrivateKey:  privateKey,
+		Leaf:        leaf,
+	}, nil
+}
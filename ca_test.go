@@ -0,0 +1,83 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf_test
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf"
+)
+
+func TestNewLocalCA(t *testing.T) {
+	store := tlsconf.NewMemoryKeyStore()
+	ca1, err := tlsconf.NewLocalCA(store, "ca", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	require.True(t, ca1.Certificate().IsCA)
+	ca2, err := tlsconf.NewLocalCA(store, "ca", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, ca1.Certificate(), ca2.Certificate())
+}
+
+func TestLocalCAIssueCertificates(t *testing.T) {
+	ca, err := tlsconf.NewLocalCA(tlsconf.NewMemoryKeyStore(), "ca", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Certificate())
+
+	serverCertificate, err := ca.IssueServerCertificate("127.0.0.1:443", tlsconf.CertificateAlgorithmRSA2048, 2*time.Hour)
+	require.NoError(t, err)
+	require.Len(t, serverCertificate.Certificate, 1)
+	require.Equal(t, ca.Certificate().NotAfter, serverCertificate.Leaf.NotAfter)
+	_, err = serverCertificate.Leaf.Verify(x509.VerifyOptions{
+		DNSName:   "127.0.0.1",
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	require.NoError(t, err)
+
+	clientCertificate, err := ca.IssueClientCertificate("client", tlsconf.CertificateAlgorithmED25519, time.Minute)
+	require.NoError(t, err)
+	_, err = clientCertificate.Leaf.Verify(x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	require.NoError(t, err)
+}
+
+func TestLocalCACreateRevocationList(t *testing.T) {
+	ca, err := tlsconf.NewLocalCA(tlsconf.NewMemoryKeyStore(), "ca", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	certificate, err := ca.IssueServerCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+
+	crl, err := ca.CreateRevocationList([]x509.RevocationListEntry{
+		{SerialNumber: certificate.Leaf.SerialNumber, RevocationTime: time.Now()},
+	}, big.NewInt(1), 2*time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, crl.CheckSignatureFrom(ca.Certificate()))
+	require.Equal(t, big.NewInt(1), crl.Number)
+	require.Equal(t, ca.Certificate().NotAfter, crl.NextUpdate)
+	require.Len(t, crl.RevokedCertificateEntries, 1)
+	require.Equal(t, certificate.Leaf.SerialNumber, crl.RevokedCertificateEntries[0].SerialNumber)
+
+	crlFile := filepath.Join(t.TempDir(), "ca.crl")
+	err = tlsconf.WriteRevocationList(crl, crlFile)
+	require.NoError(t, err)
+	crlData, err := os.ReadFile(crlFile)
+	require.NoError(t, err)
+	crlBlock, _ := pem.Decode(crlData)
+	require.NotNil(t, crlBlock)
+	require.Equal(t, "X509 CRL", crlBlock.Type)
+	require.Equal(t, crl.Raw, crlBlock.Bytes)
+}
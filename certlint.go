@@ -0,0 +1,206 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	LintValidityPeriod      LintID = "validity-period"       // Certificate validity period exceeds the baseline limit
+	LintSerialNumber        LintID = "serial-number"         // Serial number is not positive or exceeds 20 octets
+	LintSerialNumberEntropy LintID = "serial-number-entropy" // Serial number has less than 64 bits
+	LintSANMismatch         LintID = "san-mismatch"          // Subject common name not contained in subject alternative names
+	LintKeyUsage            LintID = "key-usage"             // Key usage inconsistent with key type or certificate type
+	LintBasicConstraints    LintID = "basic-constraints"     // Basic constraints missing or not critical
+	LintSignatureAlgorithm  LintID = "signature-algorithm"   // Signature algorithm not permitted
+)
+
+type validityPeriodLimit struct {
+	notBefore time.Time
+	limit     time.Duration
+}
+
+// validityPeriodLimits reflects the subscriber certificate validity limits of the
+// CA/Browser Forum Baseline Requirements (ballot SC-081).
+var validityPeriodLimits = []validityPeriodLimit{
+	{notBefore: time.Date(2029, time.March, 15, 0, 0, 0, 0, time.UTC), limit: 47 * 24 * time.Hour},
+	{notBefore: time.Date(2027, time.March, 15, 0, 0, 0, 0, time.UTC), limit: 100 * 24 * time.Hour},
+	{notBefore: time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC), limit: 200 * 24 * time.Hour},
+	{notBefore: time.Time{}, limit: 398 * 24 * time.Hour},
+}
+
+var oidExtensionBasicConstraints = asn1.ObjectIdentifier{2, 5, 29, 19}
+
+// LintCertificate checks the given certificate against a subset of the CA/Browser Forum
+// Baseline Requirements.
+//
+// The following rules are checked: validity period limits (depending on the certificate's
+// NotBefore date), serial number size and entropy, subject alternative name presence and
+// consistency with the common name (server certificates only), key usage consistency with
+// the key type, basic constraints, key strength and signature algorithm. Certificates with a
+// server or client authentication extended key usage are checked as end-entity certificates
+// (and reported, if they are marked as CA). All other certificates marked as CA (including the
+// ones without any extended key usage) are checked as CA certificates and the remaining ones
+// as end-entity certificates.
+func LintCertificate(certificate *x509.Certificate) LintFindings {
+	linter := &configLinter{now: time.Now()}
+	linter.lintCertificateSerialNumber(certificate)
+	linter.lintCertificateKey(certificate)
+	linter.lintCertificateSignatureAlgorithm(certificate)
+	if certificate.IsCA && !isEndEntityCertificate(certificate) {
+		linter.lintCACertificate(certificate)
+	} else {
+		linter.lintLeafCertificate(certificate)
+	}
+	return linter.findings
+}
+
+func (linter *configLinter) lintCertificateSerialNumber(certificate *x509.Certificate) {
+	serialNumber := certificate.SerialNumber
+	if serialNumber == nil || serialNumber.Sign() <= 0 || len(serialNumber.Bytes()) > 20 {
+		linter.report(LintSerialNumber, LintSeverityError, "certificate '%s' serial number must be positive and must not exceed 20 octets", certificate.Subject)
+		return
+	}
+	if serialNumber.BitLen() < 64 {
+		linter.report(LintSerialNumberEntropy, LintSeverityError, "certificate '%s' serial number has only %d bits (at least 64 bits required)", certificate.Subject, serialNumber.BitLen())
+	}
+}
+
+func (linter *configLinter) lintCertificateSignatureAlgorithm(certificate *x509.Certificate) {
+	switch certificate.SignatureAlgorithm {
+	case x509.SHA256WithRSA, x509.SHA384WithRSA, x509.SHA512WithRSA,
+		x509.SHA256WithRSAPSS, x509.SHA384WithRSAPSS, x509.SHA512WithRSAPSS,
+		x509.ECDSAWithSHA256, x509.ECDSAWithSHA384, x509.ECDSAWithSHA512:
+	case x509.PureEd25519:
+		linter.report(LintSignatureAlgorithm, LintSeverityWarning, "certificate '%s' signature algorithm %s is not permitted for publicly-trusted certificates", certificate.Subject, certificate.SignatureAlgorithm)
+	default:
+		linter.report(LintSignatureAlgorithm, LintSeverityError, "certificate '%s' signature algorithm %s is not permitted", certificate.Subject, certificate.SignatureAlgorithm)
+	}
+}
+
+func (linter *configLinter) lintCACertificate(certificate *x509.Certificate) {
+	if certificate.KeyUsage&x509.KeyUsageCertSign == 0 {
+		linter.report(LintKeyUsage, LintSeverityError, "CA certificate '%s' lacks certificate signing key usage", certificate.Subject)
+	}
+	if !slices.ContainsFunc(certificate.Extensions, func(extension pkix.Extension) bool {
+		return extension.Id.Equal(oidExtensionBasicConstraints) && extension.Critical
+	}) {
+		linter.report(LintBasicConstraints, LintSeverityError, "CA certificate '%s' basic constraints are not marked critical", certificate.Subject)
+	}
+}
+
+func (linter *configLinter) lintLeafCertificate(certificate *x509.Certificate) {
+	validityPeriod := certificate.NotAfter.Sub(certificate.NotBefore) + time.Second
+	for _, limit := range validityPeriodLimits {
+		if !certificate.NotBefore.Before(limit.notBefore) {
+			if validityPeriod > limit.limit {
+				linter.report(LintValidityPeriod, LintSeverityError, "certificate '%s' validity period of %d days exceeds %d days", certificate.Subject, validityPeriod/(24*time.Hour), limit.limit/(24*time.Hour))
+			}
+			break
+		}
+	}
+	if certificate.BasicConstraintsValid && certificate.IsCA {
+		linter.report(LintCACertificate, LintSeverityError, "end-entity certificate '%s' basic constraints are marked as CA", certificate.Subject)
+	}
+	if certificate.KeyUsage&(x509.KeyUsageCertSign|x509.KeyUsageCRLSign) != 0 {
+		linter.report(LintKeyUsage, LintSeverityError, "end-entity certificate '%s' has certificate or CRL signing key usage", certificate.Subject)
+	}
+	switch certificate.PublicKey.(type) {
+	case *ecdsa.PublicKey, ed25519.PublicKey:
+		if certificate.KeyUsage&(x509.KeyUsageKeyEncipherment|x509.KeyUsageDataEncipherment) != 0 {
+			linter.report(LintKeyUsage, LintSeverityError, "certificate '%s' has encipherment key usage for a %s key", certificate.Subject, certificate.PublicKeyAlgorithm)
+		}
+	}
+	if certificate.KeyUsage == 0 {
+		linter.report(LintKeyUsage, LintSeverityWarning, "certificate '%s' has no key usage", certificate.Subject)
+	} else if certificate.KeyUsage&(x509.KeyUsageDigitalSignature|x509.KeyUsageKeyEncipherment) == 0 {
+		linter.report(LintKeyUsage, LintSeverityError, "certificate '%s' lacks digital signature key usage", certificate.Subject)
+	}
+	if !isServerCertificate(certificate) {
+		return
+	}
+	if len(certificate.DNSNames) == 0 && len(certificate.IPAddresses) == 0 {
+		linter.report(LintMissingSAN, LintSeverityError, "server certificate '%s' has no DNS or IP subject alternative names", certificate.Subject)
+		return
+	}
+	commonName := certificate.Subject.CommonName
+	if commonName != "" && !slices.ContainsFunc(certificate.DNSNames, func(dnsName string) bool {
+		return strings.EqualFold(dnsName, commonName)
+	}) && !slices.ContainsFunc(certificate.IPAddresses, func(ipAddress net.IP) bool {
+		return ipAddress.Equal(net.ParseIP(commonName))
+	}) {
+		linter.report(LintSANMismatch, LintSeverityError, "server certificate common name '%s' is not contained in the subject alternative names", commonName)
+	}
+}
+
+func isServerCertificate(certificate *x509.Certificate) bool {
+	return slices.Contains(certificate.ExtKeyUsage, x509.ExtKeyUsageServerAuth)
+}
+
+func isEndEntityCertificate(certificate *x509.Certificate) bool {
+	return isServerCertificate(certificate) || slices.Contains(certificate.ExtKeyUsage, x509.ExtKeyUsageClientAuth)
+}
+
+var strictMode bool
+var strictModeLock sync.RWMutex = sync.RWMutex{}
+
+// SetStrictMode enables or disables strict certificate generation.
+//
+// In strict mode, serial numbers are generated randomly (instead of being derived from the
+// current time or the deterministic clock, see [EnableDeterministicMode]) and every generated
+// certificate is checked via [LintCertificate]. Certificate generation fails, if any error
+// finding is reported.
+func SetStrictMode(strict bool) {
+	strictModeLock.Lock()
+	defer strictModeLock.Unlock()
+	strictMode = strict
+}
+
+func isStrictMode() bool {
+	strictModeLock.RLock()
+	defer strictModeLock.RUnlock()
+	return strictMode
+}
+
+func nextStrictSerialNumber() *big.Int {
+	if !isStrictMode() {
+		return nil
+	}
+	serialNumberBytes := make([]byte, 16)
+	rand.Read(serialNumberBytes)
+	// ensure a positive serial number with maximum bit length
+	serialNumberBytes[0] = (serialNumberBytes[0] & 0x7f) | 0x40
+	return new(big.Int).SetBytes(serialNumberBytes)
+}
+
+func lintGeneratedCertificate(certificateBytes []byte) error {
+	if !isStrictMode() {
+		return nil
+	}
+	certificate, err := x509.ParseCertificate(certificateBytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse certificate (cause: %w)", err)
+	}
+	err = LintCertificate(certificate).Err(LintSeverityError)
+	if err != nil {
+		return fmt.Errorf("generated certificate '%s' violates strict mode (cause: %w)", certificate.Subject, err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf"
+	"github.com/tdrn-org/go-tlsconf/tlstest"
+)
+
+func TestLintCertificate(t *testing.T) {
+	certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	requireLintFindings(t, tlsconf.LintCertificate(certificate.Leaf), map[tlsconf.LintID]tlsconf.LintSeverity{
+		tlsconf.LintSerialNumberEntropy: tlsconf.LintSeverityError,
+	})
+
+	factory := tlstest.NewBrokenCertificateFactory(t, tlsconf.CertificateAlgorithmDefault)
+	sha1Signature := factory.Generate(tlstest.BrokenSHA1Signature, "localhost")
+	findings := tlsconf.LintCertificate(sha1Signature.Certificate.Leaf)
+	require.Contains(t, findings.Err(tlsconf.LintSeverityError).Error(), "[signature-algorithm]")
+	weakKey := factory.Generate(tlstest.BrokenWeakKey, "localhost")
+	findings = tlsconf.LintCertificate(weakKey.Certificate.Leaf)
+	require.Contains(t, findings.Err(tlsconf.LintSeverityError).Error(), "[weak-key]")
+}
+
+func TestLintCertificateTemplate(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	now := time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "www.example.org"},
+		NotBefore:             now,
+		NotAfter:              now.Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"example.org"},
+	}
+	certificateBytes, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	certificate, err := x509.ParseCertificate(certificateBytes)
+	require.NoError(t, err)
+	requireLintFindings(t, tlsconf.LintCertificate(certificate), map[tlsconf.LintID]tlsconf.LintSeverity{
+		tlsconf.LintSerialNumberEntropy: tlsconf.LintSeverityError,
+		tlsconf.LintValidityPeriod:      tlsconf.LintSeverityError,
+		tlsconf.LintKeyUsage:            tlsconf.LintSeverityError,
+		tlsconf.LintSANMismatch:         tlsconf.LintSeverityError,
+	})
+
+	// CA-marked certificates with server or client authentication usage are end-entity certificates
+	template.IsCA = true
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	certificateBytes, err = x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	certificate, err = x509.ParseCertificate(certificateBytes)
+	require.NoError(t, err)
+	requireLintFindings(t, tlsconf.LintCertificate(certificate), map[tlsconf.LintID]tlsconf.LintSeverity{
+		tlsconf.LintSerialNumberEntropy: tlsconf.LintSeverityError,
+		tlsconf.LintValidityPeriod:      tlsconf.LintSeverityError,
+		tlsconf.LintSANMismatch:         tlsconf.LintSeverityError,
+		tlsconf.LintCACertificate:       tlsconf.LintSeverityError,
+	})
+
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	certificateBytes, err = x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	certificate, err = x509.ParseCertificate(certificateBytes)
+	require.NoError(t, err)
+	requireLintFindings(t, tlsconf.LintCertificate(certificate), map[tlsconf.LintID]tlsconf.LintSeverity{
+		tlsconf.LintSerialNumberEntropy: tlsconf.LintSeverityError,
+		tlsconf.LintValidityPeriod:      tlsconf.LintSeverityError,
+		tlsconf.LintCACertificate:       tlsconf.LintSeverityError,
+	})
+
+	// CA-marked certificates without extended key usage are CA certificates
+	template.ExtKeyUsage = nil
+	certificateBytes, err = x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	certificate, err = x509.ParseCertificate(certificateBytes)
+	require.NoError(t, err)
+	requireLintFindings(t, tlsconf.LintCertificate(certificate), map[tlsconf.LintID]tlsconf.LintSeverity{
+		tlsconf.LintSerialNumberEntropy: tlsconf.LintSeverityError,
+		tlsconf.LintKeyUsage:            tlsconf.LintSeverityError,
+	})
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	certificateBytes, err = x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	certificate, err = x509.ParseCertificate(certificateBytes)
+	require.NoError(t, err)
+	requireLintFindings(t, tlsconf.LintCertificate(certificate), map[tlsconf.LintID]tlsconf.LintSeverity{
+		tlsconf.LintSerialNumberEntropy: tlsconf.LintSeverityError,
+	})
+}
+
+func TestStrictMode(t *testing.T) {
+	tlsconf.SetStrictMode(true)
+	defer tlsconf.SetStrictMode(false)
+
+	certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmRSA2048, time.Hour)
+	require.NoError(t, err)
+	require.Empty(t, tlsconf.LintCertificate(certificate.Leaf))
+	ca, err := tlsconf.NewLocalCA(tlsconf.NewMemoryKeyStore(), "ca", tlsconf.CertificateAlgorithmDefault, 10*365*24*time.Hour)
+	require.NoError(t, err)
+	require.Empty(t, tlsconf.LintCertificate(ca.Certificate()))
+	serverCertificate, err := ca.IssueServerCertificate("127.0.0.1", tlsconf.CertificateAlgorithmECDSA384, 90*24*time.Hour)
+	require.NoError(t, err)
+	require.Empty(t, tlsconf.LintCertificate(serverCertificate.Leaf))
+	clientCertificate, err := ca.IssueClientCertificate("client", tlsconf.CertificateAlgorithmDefault, 90*24*time.Hour)
+	require.NoError(t, err)
+	require.Empty(t, tlsconf.LintCertificate(clientCertificate.Leaf))
+
+	_, err = ca.IssueServerCertificate("localhost", tlsconf.CertificateAlgorithmDefault, 365*24*time.Hour)
+	require.ErrorContains(t, err, "violates strict mode")
+	require.ErrorContains(t, err, "[validity-period]")
+}
@@ -0,0 +1,181 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package main
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/tdrn-org/go-tlsconf"
+)
+
+const (
+	defaultCALifetime  = 10 * 365 * 24 * time.Hour
+	defaultCRLLifetime = 7 * 24 * time.Hour
+)
+
+var caCommands = []*command{
+	{name: "init", description: "create a new local CA", run: runCAInit},
+	{name: "issue", description: "issue a server or client certificate", run: runCAIssue},
+	{name: "revoke", description: "revoke certificates and update the CA's revocation list", run: runCARevoke},
+}
+
+func runCA(cmd *commandContext, args []string) error {
+	help := len(args) > 0 && (args[0] == "-h" || args[0] == "-help" || args[0] == "--help")
+	if len(args) > 0 && !help {
+		index := slices.IndexFunc(caCommands, func(c *command) bool { return c.name == args[0] })
+		if index >= 0 {
+			return caCommands[index].run(cmd.sub(args[0]), args[1:])
+		}
+		fmt.Fprintf(cmd.stderr, "%s: unknown command '%s'\n", cmd.name, args[0])
+	}
+	fmt.Fprintf(cmd.stderr, "Usage: %s <command> [flags] [args]\n\nCommands:\n", cmd.name)
+	for _, c := range caCommands {
+		fmt.Fprintf(cmd.stderr, "  %-8s %s\n", c.name, c.description)
+	}
+	if help {
+		return flag.ErrHelp
+	}
+	return errUsage
+}
+
+func runCAInit(cmd *commandContext, args []string) error {
+	flags := cmd.newFlagSet("", "Creates a new local CA with a self-signed CA certificate.")
+	ca := newCAFlags(flags, ".", "CA `directory`")
+	algorithm := algorithmFlag(flags, "algorithm")
+	lifetime := flags.Duration("lifetime", defaultCALifetime, "CA certificate `lifetime`")
+	err := cmd.parseFlags(flags, args, 0, 0)
+	if err != nil {
+		return err
+	}
+	store := ca.keyStore()
+	_, err = store.Get(ca.name)
+	if err == nil {
+		return fmt.Errorf("CA '%s' already exists in '%s'", ca.name, ca.dir)
+	} else if !errors.Is(err, tlsconf.ErrKeyStoreEntryNotFound) {
+		return err
+	}
+	err = os.MkdirAll(ca.dir, 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create CA directory '%s' (cause: %w)", ca.dir, err)
+	}
+	localCA, err := tlsconf.NewLocalCA(store, ca.name, *algorithm, *lifetime)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.stdout, "CA '%s' created in '%s' (valid until %s)\n", localCA.Certificate().Subject, ca.dir, localCA.Certificate().NotAfter.Format(time.RFC3339))
+	return nil
+}
+
+func runCAIssue(cmd *commandContext, args []string) error {
+	flags := cmd.newFlagSet("<address|name>", "Issues a server certificate for the given address or a client certificate for the given name.")
+	ca := newCAFlags(flags, ".", "CA `directory`")
+	certificate := newCertificateFlags(flags)
+	err := cmd.parseFlags(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	localCA, err := ca.load()
+	if err != nil {
+		return err
+	}
+	return certificate.issue(cmd, localCA, flags.Arg(0))
+}
+
+func runCARevoke(cmd *commandContext, args []string) error {
+	flags := cmd.newFlagSet("[certificate file ...]", "Revokes the given certificates and writes the updated revocation list to <ca directory>/<ca name>.crl.\nWithout arguments the revocation list is renewed only.")
+	ca := newCAFlags(flags, ".", "CA `directory`")
+	crlLifetime := flags.Duration("crl-lifetime", defaultCRLLifetime, "revocation list `lifetime` (time until next update)")
+	err := cmd.parseFlags(flags, args, 0, -1)
+	if err != nil {
+		return err
+	}
+	localCA, err := ca.load()
+	if err != nil {
+		return err
+	}
+	crlFile := filepath.Join(ca.dir, ca.name+".crl")
+	revoked, number, err := loadRevocationList(crlFile, localCA)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, certFile := range flags.Args() {
+		file, err := loadCertificateFile(certFile, &passphraseFlags{})
+		if err != nil {
+			return err
+		}
+		certificate := file.certificates[0]
+		err = certificate.CheckSignatureFrom(localCA.Certificate())
+		if err != nil {
+			return fmt.Errorf("certificate '%s' has not been issued by CA '%s' (cause: %w)", certificate.Subject, localCA.Certificate().Subject, err)
+		}
+		if slices.ContainsFunc(revoked, func(entry x509.RevocationListEntry) bool {
+			return entry.SerialNumber.Cmp(certificate.SerialNumber) == 0
+		}) {
+			fmt.Fprintf(cmd.stdout, "Certificate '%s' (serial %s) already revoked\n", certificate.Subject, certificate.SerialNumber.Text(16))
+			continue
+		}
+		revoked = append(revoked, x509.RevocationListEntry{SerialNumber: certificate.SerialNumber, RevocationTime: now})
+		fmt.Fprintf(cmd.stdout, "Certificate '%s' (serial %s) revoked\n", certificate.Subject, certificate.SerialNumber.Text(16))
+	}
+	crl, err := localCA.CreateRevocationList(revoked, number.Add(number, big.NewInt(1)), *crlLifetime)
+	if err != nil {
+		return err
+	}
+	err = tlsconf.WriteRevocationList(crl, crlFile)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.stdout, "Revocation list #%s with %d entries written to '%s'\n", crl.Number, len(crl.RevokedCertificateEntries), crlFile)
+	return nil
+}
+
+// loadRevocationList loads the revoked certificate entries and the number of the CA's current
+// revocation list. A non-existing revocation list results in an empty list with number 0.
+func loadRevocationList(crlFile string, ca *tlsconf.LocalCA) ([]x509.RevocationListEntry, *big.Int, error) {
+	crl, err := readRevocationList(crlFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, big.NewInt(0), nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+	err = crl.CheckSignatureFrom(ca.Certificate())
+	if err != nil {
+		return nil, nil, fmt.Errorf("revocation list '%s' has not been issued by CA '%s' (cause: %w)", crlFile, ca.Certificate().Subject, err)
+	}
+	number := crl.Number
+	if number == nil {
+		number = big.NewInt(0)
+	}
+	return crl.RevokedCertificateEntries, number, nil
+}
+
+// readRevocationList reads a PEM or DER encoded revocation list.
+func readRevocationList(crlFile string) (*x509.RevocationList, error) {
+	crlData, err := os.ReadFile(crlFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read revocation list '%s' (cause: %w)", crlFile, err)
+	}
+	if crlBlock, _ := pem.Decode(crlData); crlBlock != nil {
+		crlData = crlBlock.Bytes
+	}
+	crl, err := x509.ParseRevocationList(crlData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse revocation list '%s' (cause: %w)", crlFile, err)
+	}
+	return crl, nil
+}
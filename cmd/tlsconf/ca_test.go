@@ -0,0 +1,54 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCA(t *testing.T) {
+	caDir := filepath.Join(t.TempDir(), "ca")
+	outDir := t.TempDir()
+	t.Setenv("TLSCONF_TEST_CA_PASSPHRASE", "secret")
+
+	stdout, stderr, exitCode := runTest(t, "ca", "init", "-ca", caDir, "-ca-passphrase-env", "TLSCONF_TEST_CA_PASSPHRASE", "-algorithm", "ecdsa384")
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	require.Contains(t, stdout, "CA 'CN=ca' created")
+	_, stderr, exitCode = runTest(t, "ca", "init", "-ca", caDir, "-ca-passphrase-env", "TLSCONF_TEST_CA_PASSPHRASE")
+	require.Equal(t, exitFailure, exitCode)
+	require.Contains(t, stderr, "already exists")
+
+	_, stderr, exitCode = runTest(t, "ca", "issue", "-ca", caDir, "-out", outDir, "localhost")
+	require.Equal(t, exitFailure, exitCode)
+	require.Contains(t, stderr, "failed to load CA")
+	_, stderr, exitCode = runTest(t, "ca", "issue", "-ca", caDir, "-ca-passphrase-env", "TLSCONF_TEST_CA_PASSPHRASE", "-out", outDir, "localhost")
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	_, stderr, exitCode = runTest(t, "gen", "-ca", caDir, "-ca-passphrase-env", "TLSCONF_TEST_CA_PASSPHRASE", "-out", outDir, "-client", "client")
+	require.Equal(t, exitSuccess, exitCode, stderr)
+
+	stdout, stderr, exitCode = runTest(t, "ca", "revoke", "-ca", caDir, "-ca-passphrase-env", "TLSCONF_TEST_CA_PASSPHRASE", filepath.Join(outDir, "client.crt"))
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	require.Contains(t, stdout, "Certificate 'CN=client'")
+	require.Contains(t, stdout, "Revocation list #1 with 1 entries")
+	stdout, stderr, exitCode = runTest(t, "ca", "revoke", "-ca", caDir, "-ca-passphrase-env", "TLSCONF_TEST_CA_PASSPHRASE", filepath.Join(outDir, "client.crt"), filepath.Join(outDir, "localhost.crt"))
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	require.Contains(t, stdout, "already revoked")
+	require.Contains(t, stdout, "Revocation list #2 with 2 entries")
+
+	_, stderr, exitCode = runTest(t, "gen", "-out", outDir, "ephemeral")
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	_, stderr, exitCode = runTest(t, "ca", "revoke", "-ca", caDir, "-ca-passphrase-env", "TLSCONF_TEST_CA_PASSPHRASE", filepath.Join(outDir, "ephemeral.crt"))
+	require.Equal(t, exitFailure, exitCode)
+	require.Contains(t, stderr, "has not been issued by CA")
+
+	_, stderr, exitCode = runTest(t, "ca", "unknown")
+	require.Equal(t, exitUsage, exitCode)
+	require.Contains(t, stderr, "unknown command 'unknown'")
+}
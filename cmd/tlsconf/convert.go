@@ -0,0 +1,111 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/tdrn-org/go-tlsconf"
+)
+
+const formatPKCS12 = "pkcs12"
+
+func runConvert(cmd *commandContext, args []string) error {
+	flags := cmd.newFlagSet("<input file>", "Converts the certificates contained in the given PEM, DER, PKCS#7 or PKCS#12 (.p12, .pfx) file.")
+	to := flags.String("to", string(tlsconf.CertificateFormatPEM), "output `format` (pem, der, pkcs7, pkcs12)")
+	out := flags.String("out", "", "output `file` (default: standard output)")
+	keyFile := flags.String("key", "", "private key `file` to include in the PKCS#12 output")
+	keyOut := flags.String("key-out", "", "output `file` for the private key contained in a PKCS#12 input")
+	encryption := flags.String("pkcs12-encryption", string(tlsconf.PKCS12EncryptionModern), "PKCS#12 output `encryption` (modern, legacy)")
+	password := newPassphraseFlags(flags, "password", "PKCS#12 password (input and output)")
+	keyPassphrase := newPassphraseFlags(flags, "key-passphrase", "passphrase of the encrypted private key")
+	err := cmd.parseFlags(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	input := flags.Arg(0)
+	switch *to {
+	case formatPKCS12:
+		err = convertToPKCS12(cmd, input, *out, *keyFile, keyPassphrase, password, tlsconf.PKCS12Encryption(*encryption))
+	case string(tlsconf.CertificateFormatPEM), string(tlsconf.CertificateFormatDER), string(tlsconf.CertificateFormatPKCS7):
+		err = convertCertificates(cmd, input, *out, *keyOut, password, tlsconf.CertificateFormat(*to))
+	default:
+		fmt.Fprintf(cmd.stderr, "%s: unknown output format '%s'\n", cmd.name, *to)
+		flags.Usage()
+		return errUsage
+	}
+	if err != nil {
+		return err
+	}
+	if *out != "" {
+		fmt.Fprintf(cmd.stdout, "Certificates from '%s' written to '%s' (format: %s)\n", input, *out, *to)
+	}
+	return nil
+}
+
+func convertCertificates(cmd *commandContext, input, out, keyOut string, password *passphraseFlags, format tlsconf.CertificateFormat) error {
+	certificateFile, err := loadCertificateFile(input, password)
+	if err != nil {
+		return err
+	}
+	if keyOut != "" {
+		if certificateFile.privateKey == nil {
+			return fmt.Errorf("input file '%s' does not contain a private key", input)
+		}
+		err = tlsconf.WritePrivateKey(certificateFile.privateKey, keyOut)
+		if err != nil {
+			return err
+		}
+	}
+	if out != "" {
+		return tlsconf.WriteCertificates(certificateFile.certificates, out, format)
+	}
+	data, err := tlsconf.EncodeCertificates(certificateFile.certificates, format)
+	if err != nil {
+		return err
+	}
+	_, err = cmd.stdout.Write(data)
+	return err
+}
+
+func convertToPKCS12(cmd *commandContext, input, out, keyFile string, keyPassphrase, password *passphraseFlags, encryption tlsconf.PKCS12Encryption) error {
+	var certificate *tls.Certificate
+	var err error
+	if isPKCS12File(input) {
+		certificateFile, err := loadCertificateFile(input, password)
+		if err != nil {
+			return err
+		}
+		chain := make([][]byte, 0, len(certificateFile.certificates))
+		for _, x509Certificate := range certificateFile.certificates {
+			chain = append(chain, x509Certificate.Raw)
+		}
+		certificate = &tls.Certificate{Certificate: chain, PrivateKey: certificateFile.privateKey}
+	} else {
+		if keyFile == "" {
+			return fmt.Errorf("PKCS#12 output requires a private key (see flag -key)")
+		}
+		certificate, err = tlsconf.LoadCertificate(input, keyFile, keyPassphrase.passphrase())
+		if err != nil {
+			return err
+		}
+	}
+	pfxPassword, err := password.password()
+	if err != nil {
+		return err
+	}
+	if out != "" {
+		return tlsconf.WritePKCS12File(certificate, out, pfxPassword, encryption)
+	}
+	data, err := tlsconf.EncodePKCS12(certificate, pfxPassword, encryption)
+	if err != nil {
+		return err
+	}
+	_, err = cmd.stdout.Write(data)
+	return err
+}
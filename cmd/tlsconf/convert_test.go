@@ -0,0 +1,73 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf"
+)
+
+func TestRunConvert(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TLSCONF_TEST_PASSWORD", "secret")
+	_, stderr, exitCode := runTest(t, "gen", "-out", dir, "localhost")
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	certFile := filepath.Join(dir, "localhost.crt")
+	keyFile := filepath.Join(dir, "localhost.key")
+
+	for _, format := range []tlsconf.CertificateFormat{tlsconf.CertificateFormatDER, tlsconf.CertificateFormatPKCS7} {
+		outFile := filepath.Join(dir, "localhost."+string(format))
+		_, stderr, exitCode = runTest(t, "convert", "-to", string(format), "-out", outFile, certFile)
+		require.Equal(t, exitSuccess, exitCode, stderr)
+		data, err := os.ReadFile(outFile)
+		require.NoError(t, err)
+		require.Equal(t, format, tlsconf.DetectCertificateFormat(data))
+		stdout, stderr, exitCode := runTest(t, "convert", outFile)
+		require.Equal(t, exitSuccess, exitCode, stderr)
+		require.Equal(t, tlsconf.CertificateFormatPEM, tlsconf.DetectCertificateFormat([]byte(stdout)))
+	}
+
+	pfxFile := filepath.Join(dir, "localhost.p12")
+	_, stderr, exitCode = runTest(t, "convert", "-to", "pkcs12", "-out", pfxFile, certFile)
+	require.Equal(t, exitFailure, exitCode)
+	require.Contains(t, stderr, "PKCS#12 output requires a private key")
+	require.NoError(t, os.WriteFile(pfxFile, []byte{}, 0644))
+	_, stderr, exitCode = runTest(t, "convert", "-to", "pkcs12", "-key", keyFile, "-password-env", "TLSCONF_TEST_PASSWORD", "-out", pfxFile, certFile)
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	requireFileMode(t, pfxFile, 0600)
+	certificate, err := tlsconf.LoadPKCS12(pfxFile, "secret")
+	require.NoError(t, err)
+
+	stdout, stderr, exitCode := runTest(t, "inspect", "-password-env", "TLSCONF_TEST_PASSWORD", pfxFile)
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	require.Contains(t, stdout, "Subject:         CN=localhost")
+
+	keyOutFile := filepath.Join(dir, "converted.key")
+	certOutFile := filepath.Join(dir, "converted.crt")
+	require.NoError(t, os.WriteFile(keyOutFile, []byte{}, 0644))
+	_, stderr, exitCode = runTest(t, "convert", "-password-env", "TLSCONF_TEST_PASSWORD", "-key-out", keyOutFile, "-out", certOutFile, pfxFile)
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	requireFileMode(t, keyOutFile, 0600)
+	requireFileMode(t, certOutFile, 0644)
+	converted, err := tlsconf.LoadCertificate(certOutFile, keyOutFile, nil)
+	require.NoError(t, err)
+	require.Equal(t, certificate.Certificate, converted.Certificate)
+	require.Equal(t, certificate.PrivateKey, converted.PrivateKey)
+
+	_, _, exitCode = runTest(t, "convert", "-to", "jks", certFile)
+	require.Equal(t, exitUsage, exitCode)
+}
+
+func requireFileMode(t *testing.T, file string, mode os.FileMode) {
+	fileInfo, err := os.Stat(file)
+	require.NoError(t, err)
+	require.Equal(t, mode, fileInfo.Mode().Perm())
+}
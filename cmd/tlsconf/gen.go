@@ -0,0 +1,141 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/tdrn-org/go-tlsconf"
+)
+
+const defaultCertificateLifetime = 90 * 24 * time.Hour
+
+// caFlags defines the local CA to use.
+type caFlags struct {
+	dir        string
+	name       string
+	passphrase *passphraseFlags
+}
+
+func newCAFlags(flags *flag.FlagSet, defaultDir, usage string) *caFlags {
+	ca := &caFlags{}
+	flags.StringVar(&ca.dir, "ca", defaultDir, usage)
+	flags.StringVar(&ca.name, "ca-name", "ca", "`name` of the CA entry in the CA directory")
+	ca.passphrase = newPassphraseFlags(flags, "ca-passphrase", "passphrase protecting the CA private key")
+	return ca
+}
+
+func (ca *caFlags) keyStore() tlsconf.KeyStore {
+	passphrase := ca.passphrase.passphrase()
+	if passphrase != nil {
+		return tlsconf.NewEncryptedDirKeyStore(ca.dir, passphrase, tlsconf.KeyEncryptionDefault)
+	}
+	return tlsconf.NewDirKeyStore(ca.dir)
+}
+
+// load loads the existing CA.
+func (ca *caFlags) load() (*tlsconf.LocalCA, error) {
+	certificate, err := ca.keyStore().Get(ca.name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load CA '%s' from '%s' (cause: %w)", ca.name, ca.dir, err)
+	}
+	return tlsconf.NewLocalCAFromCertificate(certificate)
+}
+
+// certificateFlags defines the certificate to generate and where to write it.
+type certificateFlags struct {
+	algorithm  *tlsconf.CertificateAlgorithm
+	lifetime   time.Duration
+	client     bool
+	out        string
+	name       string
+	passphrase *passphraseFlags
+}
+
+func newCertificateFlags(flags *flag.FlagSet) *certificateFlags {
+	certificate := &certificateFlags{}
+	certificate.algorithm = algorithmFlag(flags, "algorithm")
+	flags.DurationVar(&certificate.lifetime, "lifetime", defaultCertificateLifetime, "certificate `lifetime`")
+	flags.BoolVar(&certificate.client, "client", false, "issue a client certificate instead of a server certificate (requires a CA)")
+	flags.StringVar(&certificate.out, "out", ".", "output `directory`")
+	flags.StringVar(&certificate.name, "name", "", "output file `name` without extension (default: the certificate's host or name)")
+	certificate.passphrase = newPassphraseFlags(flags, "passphrase", "passphrase used to encrypt the private key")
+	return certificate
+}
+
+// fileName determines the output file name for the given subject.
+func (certificate *certificateFlags) fileName(subject string) (string, error) {
+	name := certificate.name
+	if name == "" {
+		host, _, err := net.SplitHostPort(subject)
+		if err != nil {
+			host = subject
+		}
+		name = strings.Trim(host, "[]")
+	}
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid output file name: '%s'", name)
+	}
+	return name, nil
+}
+
+func (certificate *certificateFlags) issue(cmd *commandContext, ca *tlsconf.LocalCA, subject string) error {
+	name, err := certificate.fileName(subject)
+	if err != nil {
+		return err
+	}
+	var issued *tls.Certificate
+	switch {
+	case ca == nil && certificate.client:
+		return errors.New("client certificates require a CA (see flag -ca)")
+	case ca == nil:
+		issued, err = tlsconf.GenerateEphemeralCertificate(subject, *certificate.algorithm, certificate.lifetime)
+	case certificate.client:
+		issued, err = ca.IssueClientCertificate(subject, *certificate.algorithm, certificate.lifetime)
+	default:
+		issued, err = ca.IssueServerCertificate(subject, *certificate.algorithm, certificate.lifetime)
+	}
+	if err != nil {
+		return err
+	}
+	var certFile, keyFile string
+	passphrase := certificate.passphrase.passphrase()
+	if passphrase != nil {
+		certFile, keyFile, err = tlsconf.WriteEncryptedCertificate(issued, certificate.out, name, passphrase, tlsconf.KeyEncryptionDefault)
+	} else {
+		certFile, keyFile, err = tlsconf.WriteCertificate(issued, certificate.out, name)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.stdout, "Certificate '%s' written to '%s' (key: '%s')\n", issued.Leaf.Subject, certFile, keyFile)
+	return nil
+}
+
+func runGen(cmd *commandContext, args []string) error {
+	flags := cmd.newFlagSet("<address|name>", "Generates an ephemeral self-signed server certificate or, if a CA is given, a CA-issued server or client certificate.")
+	ca := newCAFlags(flags, "", "CA `directory` (see 'ca init') used to issue the certificate (default: generate an ephemeral certificate)")
+	certificate := newCertificateFlags(flags)
+	err := cmd.parseFlags(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	var localCA *tlsconf.LocalCA
+	if ca.dir != "" {
+		localCA, err = ca.load()
+		if err != nil {
+			return err
+		}
+	}
+	return certificate.issue(cmd, localCA, flags.Arg(0))
+}
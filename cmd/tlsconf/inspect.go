@@ -0,0 +1,79 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/tdrn-org/go-tlsconf/tlsinventory"
+)
+
+func runInspect(cmd *commandContext, args []string) error {
+	flags := cmd.newFlagSet("<file> ...", "Shows the details of all certificates contained in the given PEM, DER, PKCS#7 or PKCS#12 (.p12, .pfx) files.")
+	jsonOutput := flags.Bool("json", false, "output the certificate details in JSON format")
+	password := newPassphraseFlags(flags, "password", "PKCS#12 password")
+	err := cmd.parseFlags(flags, args, 1, -1)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	records := make([]*tlsinventory.CertificateRecord, 0)
+	for _, file := range flags.Args() {
+		certificateFile, err := loadCertificateFile(file, password)
+		if err != nil {
+			return err
+		}
+		for index, certificate := range certificateFile.certificates {
+			record := tlsinventory.NewCertificateRecord(file, certificate, now)
+			if *jsonOutput {
+				records = append(records, record)
+				continue
+			}
+			printCertificate(cmd.stdout, fmt.Sprintf("%s [%d]", file, index), record, certificate)
+		}
+	}
+	if *jsonOutput {
+		encoder := json.NewEncoder(cmd.stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(records)
+		if err != nil {
+			return fmt.Errorf("failed to encode certificate details (cause: %w)", err)
+		}
+	}
+	return nil
+}
+
+func printCertificate(out io.Writer, title string, record *tlsinventory.CertificateRecord, certificate *x509.Certificate) {
+	fingerprint := sha256.Sum256(certificate.Raw)
+	fmt.Fprintln(out, title)
+	fmt.Fprintf(out, "  Subject:         %s\n", record.Subject)
+	fmt.Fprintf(out, "  Issuer:          %s\n", record.Issuer)
+	fmt.Fprintf(out, "  Serial number:   %s\n", record.SerialNumber)
+	fmt.Fprintf(out, "  Not before:      %s\n", record.NotBefore.Format(time.RFC3339))
+	fmt.Fprintf(out, "  Not after:       %s (%d days remaining)\n", record.NotAfter.Format(time.RFC3339), record.DaysRemaining)
+	fmt.Fprintf(out, "  Key:             %s %d bit\n", record.KeyAlgorithm, record.KeySize)
+	fmt.Fprintf(out, "  Signature:       %s\n", certificate.SignatureAlgorithm)
+	fmt.Fprintf(out, "  CA:              %t\n", record.IsCA)
+	printList(out, "  DNS names:       ", record.DNSNames)
+	printList(out, "  IP addresses:    ", record.IPAddresses)
+	printList(out, "  Email addresses: ", record.EmailAddresses)
+	printList(out, "  URIs:            ", record.URIs)
+	fmt.Fprintf(out, "  SHA-256:         %s\n", strings.ToUpper(hex.EncodeToString(fingerprint[:])))
+}
+
+func printList(out io.Writer, label string, values []string) {
+	if len(values) > 0 {
+		fmt.Fprintf(out, "%s%s\n", label, strings.Join(values, ", "))
+	}
+}
@@ -0,0 +1,43 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf/tlsinventory"
+)
+
+func TestRunInspect(t *testing.T) {
+	dir := t.TempDir()
+	_, stderr, exitCode := runTest(t, "gen", "-out", dir, "-algorithm", "rsa2048", "127.0.0.1")
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	certFile := filepath.Join(dir, "127.0.0.1.crt")
+
+	stdout, stderr, exitCode := runTest(t, "inspect", certFile)
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	require.Contains(t, stdout, certFile+" [0]")
+	require.Contains(t, stdout, "Subject:         CN=127.0.0.1")
+	require.Contains(t, stdout, "Key:             RSA 2048 bit")
+	require.Contains(t, stdout, "IP addresses:    127.0.0.1")
+
+	stdout, stderr, exitCode = runTest(t, "inspect", "-json", certFile, certFile)
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	var records []*tlsinventory.CertificateRecord
+	require.NoError(t, json.Unmarshal([]byte(stdout), &records))
+	require.Len(t, records, 2)
+	require.Equal(t, certFile, records[0].Source)
+	require.Equal(t, []string{"127.0.0.1"}, records[0].IPAddresses)
+	require.Equal(t, 2048, records[0].KeySize)
+
+	_, stderr, exitCode = runTest(t, "inspect", filepath.Join(dir, "127.0.0.1.key"))
+	require.Equal(t, exitFailure, exitCode)
+	require.Contains(t, stderr, "failed to decode certificates")
+}
@@ -0,0 +1,271 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+// Command tlsconf generates, inspects, verifies and converts certificates, probes TLS endpoints
+// and serves throwaway HTTPS test servers.
+//
+// Usage:
+//
+//	tlsconf <command> [flags] [args]
+//
+// The commands are:
+//
+//	gen      generate an ephemeral or CA-issued certificate
+//	ca       manage a local CA (init, issue, revoke)
+//	inspect  show the details of the certificates contained in PEM, DER, PKCS#7 or PKCS#12 files
+//	verify   verify a certificate chain against a CA bundle
+//	convert  convert certificates between PEM, DER, PKCS#7 and PKCS#12
+//	probe    connect to a TLS endpoint and show its parameters and certificates
+//	serve    start an HTTPS static file server or reverse proxy for testing
+//
+// Run "tlsconf <command> -h" for the command specific flags.
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/tdrn-org/go-tlsconf"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	exitCode := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(exitCode)
+}
+
+const (
+	exitSuccess = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+type command struct {
+	name        string
+	description string
+	run         func(cmd *commandContext, args []string) error
+}
+
+var commands = []*command{
+	{name: "gen", description: "generate an ephemeral or CA-issued certificate", run: runGen},
+	{name: "ca", description: "manage a local CA (init, issue, revoke)", run: runCA},
+	{name: "inspect", description: "show the details of the certificates contained in PEM, DER, PKCS#7 or PKCS#12 files", run: runInspect},
+	{name: "verify", description: "verify a certificate chain against a CA bundle", run: runVerify},
+	{name: "convert", description: "convert certificates between PEM, DER, PKCS#7 and PKCS#12", run: runConvert},
+	{name: "probe", description: "connect to a TLS endpoint and show its parameters and certificates", run: runProbe},
+	{name: "serve", description: "start an HTTPS static file server or reverse proxy for testing", run: runServe},
+}
+
+// errUsage indicates invalid command line arguments (already reported to the user).
+var errUsage = errors.New("invalid usage")
+
+type commandContext struct {
+	ctx    context.Context
+	name   string
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	cmd := &commandContext{ctx: ctx, name: "tlsconf", stdout: stdout, stderr: stderr}
+	if len(args) == 0 {
+		cmd.usage()
+		return exitUsage
+	}
+	if args[0] == "-h" || args[0] == "-help" || args[0] == "--help" || args[0] == "help" {
+		cmd.usage()
+		return exitSuccess
+	}
+	index := slices.IndexFunc(commands, func(c *command) bool { return c.name == args[0] })
+	if index < 0 {
+		fmt.Fprintf(stderr, "tlsconf: unknown command '%s'\n", args[0])
+		cmd.usage()
+		return exitUsage
+	}
+	err := commands[index].run(cmd.sub(args[0]), args[1:])
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitSuccess
+	case errors.Is(err, errUsage):
+		return exitUsage
+	}
+	fmt.Fprintf(stderr, "tlsconf: %v\n", err)
+	return exitFailure
+}
+
+func (cmd *commandContext) usage() {
+	fmt.Fprintf(cmd.stderr, "Usage: %s <command> [flags] [args]\n\nCommands:\n", cmd.name)
+	for _, c := range commands {
+		fmt.Fprintf(cmd.stderr, "  %-8s %s\n", c.name, c.description)
+	}
+	fmt.Fprintf(cmd.stderr, "\nRun '%s <command> -h' for the command specific flags.\n", cmd.name)
+}
+
+func (cmd *commandContext) sub(name string) *commandContext {
+	return &commandContext{ctx: cmd.ctx, name: cmd.name + " " + name, stdout: cmd.stdout, stderr: cmd.stderr}
+}
+
+func (cmd *commandContext) newFlagSet(arguments, description string) *flag.FlagSet {
+	flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	flags.SetOutput(cmd.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(cmd.stderr, "Usage: %s [flags] %s\n\n%s\n\nFlags:\n", cmd.name, arguments, description)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseFlags parses the given arguments and checks the number of remaining arguments
+// (a negative maxArgs allows an arbitrary number of arguments).
+func (cmd *commandContext) parseFlags(flags *flag.FlagSet, args []string, minArgs, maxArgs int) error {
+	err := flags.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	if err != nil {
+		return errUsage
+	}
+	if flags.NArg() < minArgs || (maxArgs >= 0 && flags.NArg() > maxArgs) {
+		fmt.Fprintf(cmd.stderr, "%s: unexpected number of arguments\n", cmd.name)
+		flags.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+var certificateAlgorithms = []tlsconf.CertificateAlgorithm{
+	tlsconf.CertificateAlgorithmDefault,
+	tlsconf.CertificateAlgorithmRSA2048,
+	tlsconf.CertificateAlgorithmRSA3072,
+	tlsconf.CertificateAlgorithmRSA4096,
+	tlsconf.CertificateAlgorithmRSA8192,
+	tlsconf.CertificateAlgorithmECDSA224,
+	tlsconf.CertificateAlgorithmECDSA256,
+	tlsconf.CertificateAlgorithmECDSA384,
+	tlsconf.CertificateAlgorithmECDSA521,
+	tlsconf.CertificateAlgorithmED25519,
+}
+
+type algorithmValue tlsconf.CertificateAlgorithm
+
+func (value *algorithmValue) String() string {
+	return string(*value)
+}
+
+func (value *algorithmValue) Set(s string) error {
+	algorithm := tlsconf.CertificateAlgorithm(s)
+	if !slices.Contains(certificateAlgorithms, algorithm) {
+		return fmt.Errorf("unknown certificate algorithm: %s", s)
+	}
+	*value = algorithmValue(algorithm)
+	return nil
+}
+
+func algorithmFlag(flags *flag.FlagSet, name string) *tlsconf.CertificateAlgorithm {
+	algorithm := tlsconf.CertificateAlgorithmDefault
+	names := make([]string, 0, len(certificateAlgorithms))
+	for _, certificateAlgorithm := range certificateAlgorithms {
+		names = append(names, string(certificateAlgorithm))
+	}
+	flags.Var((*algorithmValue)(&algorithm), name, "key `algorithm` ("+strings.Join(names, ", ")+")")
+	return &algorithm
+}
+
+// passphraseFlags collects the alternative sources for a passphrase or password.
+type passphraseFlags struct {
+	env  string
+	file string
+}
+
+func newPassphraseFlags(flags *flag.FlagSet, prefix, usage string) *passphraseFlags {
+	passphrase := &passphraseFlags{}
+	flags.StringVar(&passphrase.env, prefix+"-env", "", "read the "+usage+" from the given environment `variable`")
+	flags.StringVar(&passphrase.file, prefix+"-file", "", "read the "+usage+" from the given `file`")
+	return passphrase
+}
+
+// passphrase gets the [tlsconf.PassphraseFunc] defined by the flags or nil, if none is set.
+func (passphrase *passphraseFlags) passphrase() tlsconf.PassphraseFunc {
+	switch {
+	case passphrase.env != "":
+		return tlsconf.PassphraseFromEnv(passphrase.env)
+	case passphrase.file != "":
+		return tlsconf.PassphraseFromFile(passphrase.file)
+	}
+	return nil
+}
+
+// password gets the password defined by the flags or an empty password, if none is set.
+func (passphrase *passphraseFlags) password() (string, error) {
+	passphraseFunc := passphrase.passphrase()
+	if passphraseFunc == nil {
+		return "", nil
+	}
+	password, err := passphraseFunc()
+	if err != nil {
+		return "", err
+	}
+	return string(password), nil
+}
+
+// certificateFile contains the certificates (and the private key for PKCS#12 files)
+// loaded from a file.
+type certificateFile struct {
+	certificates []*x509.Certificate
+	privateKey   crypto.PrivateKey
+}
+
+func isPKCS12File(file string) bool {
+	ext := strings.ToLower(filepath.Ext(file))
+	return ext == ".p12" || ext == ".pfx"
+}
+
+// loadCertificateFile loads the certificates from the given file.
+//
+// Files with a .p12 or .pfx extension are decoded as PKCS#12 archives using the given
+// password. All other files are decoded via [tlsconf.DecodeCertificates].
+func loadCertificateFile(file string, password *passphraseFlags) (*certificateFile, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file '%s' (cause: %w)", file, err)
+	}
+	if isPKCS12File(file) {
+		pfxPassword, err := password.password()
+		if err != nil {
+			return nil, err
+		}
+		certificate, err := tlsconf.DecodePKCS12(data, pfxPassword)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load PKCS#12 file '%s' (cause: %w)", file, err)
+		}
+		certificates := make([]*x509.Certificate, 0, len(certificate.Certificate))
+		for _, certificateBytes := range certificate.Certificate {
+			x509Certificate, err := x509.ParseCertificate(certificateBytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse certificate in '%s' (cause: %w)", file, err)
+			}
+			certificates = append(certificates, x509Certificate)
+		}
+		return &certificateFile{certificates: certificates, privateKey: certificate.PrivateKey}, nil
+	}
+	certificates, err := tlsconf.DecodeCertificates(data, tlsconf.DecodeStrict)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode certificates in '%s' (cause: %w)", file, err)
+	}
+	return &certificateFile{certificates: certificates}, nil
+}
@@ -0,0 +1,82 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunUsage(t *testing.T) {
+	stdout, stderr, exitCode := runTest(t)
+	require.Equal(t, exitUsage, exitCode)
+	require.Empty(t, stdout)
+	require.Contains(t, stderr, "Commands:")
+
+	_, stderr, exitCode = runTest(t, "unknown")
+	require.Equal(t, exitUsage, exitCode)
+	require.Contains(t, stderr, "unknown command 'unknown'")
+
+	_, _, exitCode = runTest(t, "help")
+	require.Equal(t, exitSuccess, exitCode)
+	_, stderr, exitCode = runTest(t, "gen", "-h")
+	require.Equal(t, exitSuccess, exitCode)
+	require.Contains(t, stderr, "Usage: tlsconf gen [flags] <address|name>")
+	_, stderr, exitCode = runTest(t, "ca", "-h")
+	require.Equal(t, exitSuccess, exitCode)
+	require.Contains(t, stderr, "Usage: tlsconf ca <command>")
+
+	_, stderr, exitCode = runTest(t, "gen", "-algorithm", "rsa1024", "localhost")
+	require.Equal(t, exitUsage, exitCode)
+	require.Contains(t, stderr, "unknown certificate algorithm: rsa1024")
+	_, stderr, exitCode = runTest(t, "gen")
+	require.Equal(t, exitUsage, exitCode)
+	require.Contains(t, stderr, "unexpected number of arguments")
+}
+
+func TestRunGen(t *testing.T) {
+	dir := t.TempDir()
+	for _, algorithm := range certificateAlgorithms {
+		if algorithm == "rsa8192" {
+			continue
+		}
+		stdout, stderr, exitCode := runTest(t, "gen", "-out", dir, "-name", string(algorithm), "-algorithm", string(algorithm), "localhost:443")
+		require.Equal(t, exitSuccess, exitCode, stderr)
+		require.Contains(t, stdout, filepath.Join(dir, string(algorithm)+".crt"))
+		require.FileExists(t, filepath.Join(dir, string(algorithm)+".key"))
+	}
+
+	stdout, stderr, exitCode := runTest(t, "gen", "-out", dir, "[::1]:443")
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	require.Contains(t, stdout, filepath.Join(dir, "::1.crt"))
+
+	t.Setenv("TLSCONF_TEST_PASSPHRASE", "secret")
+	_, stderr, exitCode = runTest(t, "gen", "-out", dir, "-passphrase-env", "TLSCONF_TEST_PASSPHRASE", "encrypted")
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	stdout, stderr, exitCode = runTest(t, "inspect", filepath.Join(dir, "encrypted.crt"))
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	require.Contains(t, stdout, "DNS names:       encrypted")
+
+	_, stderr, exitCode = runTest(t, "gen", "-out", dir, "-client", "client")
+	require.Equal(t, exitFailure, exitCode)
+	require.Contains(t, stderr, "client certificates require a CA")
+	_, stderr, exitCode = runTest(t, "gen", "-out", dir, "-name", "../escape", "localhost")
+	require.Equal(t, exitFailure, exitCode)
+	require.Contains(t, stderr, "invalid output file name")
+}
+
+func runTest(t *testing.T, args ...string) (string, string, int) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	exitCode := run(t.Context(), args, stdout, stderr)
+	t.Logf("tlsconf %s (exit code: %d)\n%s%s", strings.Join(args, " "), exitCode, stdout, stderr)
+	return stdout.String(), stderr.String(), exitCode
+}
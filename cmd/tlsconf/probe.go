@@ -0,0 +1,239 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tdrn-org/go-tlsconf"
+	"github.com/tdrn-org/go-tlsconf/tlsclient"
+	"golang.org/x/crypto/ocsp"
+)
+
+const defaultProbeTimeout = 10 * time.Second
+
+var probeVersions = []uint16{tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12, tls.VersionTLS13}
+
+func runProbe(cmd *commandContext, args []string) error {
+	flags := cmd.newFlagSet("<host:port>", "Connects to the given TLS endpoint and shows the negotiated parameters, the certificate chain, the OCSP staple status\nand the verification result as well as the supported versions and cipher suites.")
+	serverName := flags.String("servername", "", "server `name` sent via SNI and used for verification (default: the address' host)")
+	timeout := flags.Duration("timeout", defaultProbeTimeout, "connect and handshake `timeout`")
+	caFile := flags.String("ca", "", "CA bundle `file` to add to the trusted root certificates")
+	caDir := flags.String("ca-dir", "", "`directory` containing CA certificates to add to the trusted root certificates")
+	ignoreSystemCerts := flags.Bool("ignore-system-certs", false, "do not trust the system certificates")
+	certFile := flags.String("cert", "", "client certificate `file`")
+	keyFile := flags.String("key", "", "client certificate key `file`")
+	keyPassphrase := newPassphraseFlags(flags, "key-passphrase", "passphrase of the encrypted client certificate key")
+	alpn := flags.String("alpn", "", "comma separated list of application `protocols` to offer")
+	scan := flags.Bool("scan", true, "determine the supported versions and cipher suites via additional handshakes")
+	err := cmd.parseFlags(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	address := flags.Arg(0)
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid address '%s' (cause: %w)", address, err)
+	}
+	options := make([]tlsconf.TLSConfigOption, 0)
+	if *ignoreSystemCerts {
+		options = append(options, tlsclient.IgnoreSystemCerts())
+	}
+	if *caFile != "" {
+		options = append(options, tlsclient.AddCertificatesFromFile(*caFile))
+	}
+	if *caDir != "" {
+		options = append(options, tlsclient.AddCertificatesFromDir(*caDir))
+	}
+	if *certFile != "" {
+		options = append(options, tlsclient.UseClientCertificate(*certFile, *keyFile, keyPassphrase.passphrase()))
+	}
+	err = tlsclient.SetOptions(options...)
+	if err != nil {
+		return err
+	}
+	config := tlsclient.GetConfig().Clone()
+	config.ServerName = host
+	if *serverName != "" {
+		config.ServerName = *serverName
+	}
+	if *alpn != "" {
+		config.NextProtos = strings.Split(*alpn, ",")
+	}
+	prober := &prober{ctx: cmd.ctx, address: address, config: config, timeout: *timeout}
+	state, err := prober.handshake(func(*tls.Config) {})
+	if err != nil {
+		return fmt.Errorf("failed to connect to '%s' (cause: %w)", address, err)
+	}
+	chains, verifyErr := prober.verify(state)
+	printConnectionState(cmd.stdout, address, config.ServerName, state, ocspIssuer(chains))
+	if verifyErr != nil {
+		fmt.Fprintf(cmd.stdout, "Verification:    failed (%v)\n", verifyErr)
+	} else {
+		fmt.Fprintf(cmd.stdout, "Verification:    ok\n")
+	}
+	if *scan {
+		prober.scan(cmd.stdout)
+	}
+	if verifyErr != nil {
+		return fmt.Errorf("verification of '%s' failed (cause: %w)", address, verifyErr)
+	}
+	return nil
+}
+
+type prober struct {
+	ctx     context.Context
+	address string
+	config  *tls.Config
+	timeout time.Duration
+}
+
+// handshake performs a single handshake using the given modification of the base config.
+//
+// Certificate verification is disabled during the handshake and performed separately
+// (see [prober.verify]), hence the connection parameters can be determined for untrusted
+// endpoints as well.
+func (prober *prober) handshake(modify func(*tls.Config)) (*tls.ConnectionState, error) {
+	ctx, cancel := context.WithTimeout(prober.ctx, prober.timeout)
+	defer cancel()
+	config := prober.config.Clone()
+	config.InsecureSkipVerify = true
+	modify(config)
+	dialer := &tls.Dialer{Config: config}
+	conn, err := dialer.DialContext(ctx, "tcp", prober.address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	state := conn.(*tls.Conn).ConnectionState()
+	return &state, nil
+}
+
+func (prober *prober) verify(state *tls.ConnectionState) ([][]*x509.Certificate, error) {
+	if len(state.PeerCertificates) == 0 {
+		return nil, fmt.Errorf("no peer certificate received")
+	}
+	intermediates := x509.NewCertPool()
+	for _, intermediate := range state.PeerCertificates[1:] {
+		intermediates.AddCert(intermediate)
+	}
+	return state.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName:       prober.config.ServerName,
+		Roots:         prober.config.RootCAs,
+		Intermediates: intermediates,
+	})
+}
+
+// scan determines the supported versions and cipher suites by performing one handshake per version
+// and one handshake per cipher suite and version. As TLS 1.3 cipher suites are not configurable,
+// they are not scanned.
+func (prober *prober) scan(out io.Writer) {
+	supportedVersions := make([]uint16, 0, len(probeVersions))
+	for _, version := range probeVersions {
+		_, err := prober.handshake(func(config *tls.Config) {
+			config.MinVersion = version
+			config.MaxVersion = version
+		})
+		if err == nil {
+			supportedVersions = append(supportedVersions, version)
+		}
+	}
+	fmt.Fprintf(out, "Versions:        %s\n", strings.Join(probeNames(supportedVersions, tls.VersionName), ", "))
+	cipherSuites := append(tls.CipherSuites(), tls.InsecureCipherSuites()...)
+	for _, version := range supportedVersions {
+		if version == tls.VersionTLS13 {
+			continue
+		}
+		supportedCipherSuites := make([]uint16, 0)
+		for _, cipherSuite := range cipherSuites {
+			if !slices.Contains(cipherSuite.SupportedVersions, version) {
+				continue
+			}
+			_, err := prober.handshake(func(config *tls.Config) {
+				config.MinVersion = version
+				config.MaxVersion = version
+				config.CipherSuites = []uint16{cipherSuite.ID}
+			})
+			if err == nil {
+				supportedCipherSuites = append(supportedCipherSuites, cipherSuite.ID)
+			}
+		}
+		fmt.Fprintf(out, "%-17s%s\n", tls.VersionName(version)+":", strings.Join(probeNames(supportedCipherSuites, tls.CipherSuiteName), ", "))
+	}
+}
+
+func probeNames(ids []uint16, name func(uint16) string) []string {
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		names = append(names, name(id))
+	}
+	return names
+}
+
+func printConnectionState(out io.Writer, address, serverName string, state *tls.ConnectionState, ocspIssuer *x509.Certificate) {
+	now := time.Now()
+	protocol := state.NegotiatedProtocol
+	if protocol == "" {
+		protocol = "none"
+	}
+	fmt.Fprintf(out, "Connected to '%s' (server name: '%s')\n", address, serverName)
+	fmt.Fprintf(out, "  Version:       %s\n", tls.VersionName(state.Version))
+	fmt.Fprintf(out, "  Cipher suite:  %s\n", tls.CipherSuiteName(state.CipherSuite))
+	fmt.Fprintf(out, "  Curve:         %s\n", state.CurveID)
+	fmt.Fprintf(out, "  ALPN:          %s\n", protocol)
+	fmt.Fprintln(out, "Certificate chain:")
+	for index, certificate := range state.PeerCertificates {
+		daysRemaining := int(math.Floor(certificate.NotAfter.Sub(now).Hours() / 24))
+		fmt.Fprintf(out, "  %d: %s\n", index, certificate.Subject)
+		fmt.Fprintf(out, "     Issuer:    %s\n", certificate.Issuer)
+		fmt.Fprintf(out, "     Not after: %s (%d days remaining)\n", certificate.NotAfter.Format(time.RFC3339), daysRemaining)
+	}
+	fmt.Fprintf(out, "OCSP staple:     %s\n", ocspStapleStatus(state, ocspIssuer))
+}
+
+// ocspIssuer determines the issuer of the peer certificate from the verified chains or nil, if the
+// peer certificate could not be verified.
+func ocspIssuer(chains [][]*x509.Certificate) *x509.Certificate {
+	if len(chains) == 0 || len(chains[0]) < 2 {
+		return nil
+	}
+	return chains[0][1]
+}
+
+// ocspStapleStatus evaluates the stapled OCSP response. As the response's signature can only be checked
+// against a trusted issuer, the staple is reported as unverified, if the issuer is unknown.
+func ocspStapleStatus(state *tls.ConnectionState, issuer *x509.Certificate) string {
+	if len(state.OCSPResponse) == 0 {
+		return "none"
+	}
+	if len(state.PeerCertificates) == 0 {
+		return "invalid (no peer certificate)"
+	}
+	if issuer == nil {
+		return "unverified (issuer unknown)"
+	}
+	response, err := ocsp.ParseResponseForCert(state.OCSPResponse, state.PeerCertificates[0], issuer)
+	if err != nil {
+		return fmt.Sprintf("invalid (%v)", err)
+	}
+	switch response.Status {
+	case ocsp.Good:
+		return fmt.Sprintf("good (next update: %s)", response.NextUpdate.Format(time.RFC3339))
+	case ocsp.Revoked:
+		return fmt.Sprintf("revoked (since: %s)", response.RevokedAt.Format(time.RFC3339))
+	}
+	return "unknown"
+}
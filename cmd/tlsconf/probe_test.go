@@ -0,0 +1,125 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package main
+
+import (
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf"
+	"github.com/tdrn-org/go-tlsconf/tlsserver"
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestRunProbe(t *testing.T) {
+	store := tlsconf.NewMemoryKeyStore()
+	ca, err := tlsconf.NewLocalCA(store, "ca", tlsconf.CertificateAlgorithmDefault, 24*time.Hour)
+	require.NoError(t, err)
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	require.NoError(t, tlsconf.WriteCertificates([]*x509.Certificate{ca.Certificate()}, caFile, tlsconf.CertificateFormatPEM))
+	err = tlsserver.SetOptions(tlsserver.UseLocalCACertificate(ca, "localhost", tlsconf.CertificateAlgorithmDefault, time.Hour), func(config *tls.Config) error {
+		config.MaxVersion = tls.VersionTLS12
+		config.CipherSuites = []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256}
+		config.NextProtos = []string{"h2"}
+		return nil
+	}, staple(t, store))
+	require.NoError(t, err)
+	address := startTestServer(t, tlsserver.GetConfig())
+
+	stdout, stderr, exitCode := runTest(t, "probe", "-ca", caFile, "-alpn", "h2,http/1.1", address)
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	require.Contains(t, stdout, "Version:       TLS 1.2")
+	require.Contains(t, stdout, "ALPN:          h2")
+	require.Contains(t, stdout, "0: CN=localhost")
+	require.Contains(t, stdout, "Issuer:    CN=ca")
+	require.Contains(t, stdout, "OCSP staple:     good")
+	require.Contains(t, stdout, "Verification:    ok")
+	require.Contains(t, stdout, "Versions:        TLS 1.2\n")
+	require.Contains(t, stdout, "TLS 1.2:         TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256\n")
+
+	stdout, stderr, exitCode = runTest(t, "probe", "-ignore-system-certs", "-scan=false", address)
+	require.Equal(t, exitFailure, exitCode)
+	require.Contains(t, stdout, "OCSP staple:     unverified (issuer unknown)")
+	require.Contains(t, stdout, "Verification:    failed")
+	require.NotContains(t, stdout, "Versions:")
+	require.Contains(t, stderr, "verification of '"+address+"' failed")
+
+	_, stderr, exitCode = runTest(t, "probe", "-ca", caFile, "-servername", "example.org", "-scan=false", address)
+	require.Equal(t, exitFailure, exitCode)
+	require.Contains(t, stderr, "example.org")
+
+	_, stderr, exitCode = runTest(t, "probe", "localhost")
+	require.Equal(t, exitFailure, exitCode)
+	require.Contains(t, stderr, "invalid address 'localhost'")
+}
+
+func staple(t *testing.T, store tlsconf.KeyStore) tlsconf.TLSConfigOption {
+	return func(config *tls.Config) error {
+		caCertificate, err := store.Get("ca")
+		require.NoError(t, err)
+		now := time.Now()
+		response, err := ocsp.CreateResponse(caCertificate.Leaf, caCertificate.Leaf, ocsp.Response{
+			Status:       ocsp.Good,
+			SerialNumber: config.Certificates[0].Leaf.SerialNumber,
+			ThisUpdate:   now,
+			NextUpdate:   now.Add(time.Hour),
+		}, caCertificate.PrivateKey.(crypto.Signer))
+		require.NoError(t, err)
+		config.Certificates[0].OCSPStaple = response
+		return nil
+	}
+}
+
+func startTestServer(t *testing.T, config *tls.Config) string {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.(*tls.Conn).Handshake()
+			}()
+		}
+	}()
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	return net.JoinHostPort("localhost", port)
+}
+
+func TestOCSPStapleStatus(t *testing.T) {
+	ca, err := tlsconf.NewLocalCA(tlsconf.NewMemoryKeyStore(), "ca", tlsconf.CertificateAlgorithmDefault, 24*time.Hour)
+	require.NoError(t, err)
+	certificate, err := ca.IssueServerCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	forger, err := tlsconf.GenerateEphemeralCertificate("ca", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	now := time.Now()
+	response, err := ocsp.CreateResponse(forger.Leaf, forger.Leaf, ocsp.Response{
+		Status:       ocsp.Good,
+		SerialNumber: certificate.Leaf.SerialNumber,
+		ThisUpdate:   now,
+		NextUpdate:   now.Add(time.Hour),
+	}, forger.PrivateKey.(crypto.Signer))
+	require.NoError(t, err)
+	state := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{certificate.Leaf}, OCSPResponse: response}
+
+	require.Equal(t, "unverified (issuer unknown)", ocspStapleStatus(state, nil))
+	require.Contains(t, ocspStapleStatus(state, ca.Certificate()), "invalid")
+	require.Equal(t, "unverified (issuer unknown)", ocspStapleStatus(state, ocspIssuer(nil)))
+	require.Equal(t, ca.Certificate(), ocspIssuer([][]*x509.Certificate{{certificate.Leaf, ca.Certificate()}}))
+}
@@ -0,0 +1,165 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tdrn-org/go-tlsconf"
+	"github.com/tdrn-org/go-tlsconf/tlsserver"
+)
+
+const (
+	defaultServeAddress  = "localhost:8443"
+	defaultServeLifetime = 24 * time.Hour
+	serveShutdownTimeout = 5 * time.Second
+)
+
+func runServe(cmd *commandContext, args []string) error {
+	flags := cmd.newFlagSet("[directory]", "Starts an HTTPS server serving the given directory (default: the current directory) or proxying all requests\nto an upstream URL. The server runs until it is interrupted.")
+	listen := flags.String("listen", defaultServeAddress, "listen `address`")
+	proxy := flags.String("proxy", "", "upstream `URL` to proxy all requests to (instead of serving a directory)")
+	ca := newCAFlags(flags, "", "CA `directory` used to issue the server certificate; the CA is created if it does not exist (default: use an ephemeral certificate)")
+	algorithm := algorithmFlag(flags, "algorithm")
+	lifetime := flags.Duration("lifetime", defaultServeLifetime, "server certificate `lifetime`")
+	mtls := flags.Bool("mtls", false, "require client certificates issued by the CA (requires -ca)")
+	err := cmd.parseFlags(flags, args, 0, 1)
+	if err != nil {
+		return err
+	}
+	if *proxy != "" && flags.NArg() > 0 {
+		fmt.Fprintf(cmd.stderr, "%s: either a directory or an upstream URL can be served\n", cmd.name)
+		flags.Usage()
+		return errUsage
+	}
+	if *mtls && ca.dir == "" {
+		return errors.New("mutual TLS requires a CA (see flag -ca)")
+	}
+	handler, target, err := serveHandler(flags.Arg(0), *proxy)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on '%s' (cause: %w)", *listen, err)
+	}
+	defer listener.Close()
+	host := serveHost(*listen)
+	logger := slog.New(slog.NewTextHandler(cmd.stderr, nil))
+	options := make([]tlsconf.TLSConfigOption, 0)
+	trustFile := ""
+	if ca.dir != "" {
+		localCA, err := ca.loadOrCreate(*algorithm)
+		if err != nil {
+			return err
+		}
+		options = append(options, tlsserver.UseLocalCACertificate(localCA, host, *algorithm, *lifetime))
+		if *mtls {
+			options = append(options, tlsserver.RequireClientCertificate(localCA.Certificate()))
+		}
+		trustFile = filepath.Join(ca.dir, ca.name+".crt")
+	} else {
+		options = append(options, tlsserver.UseEphemeralCertificate(host, *algorithm, *lifetime))
+	}
+	options = append(options, tlsserver.EnableHandshakeLogging(logger, nil))
+	err = tlsserver.SetOptions(options...)
+	if err != nil {
+		return err
+	}
+	if trustFile == "" {
+		trustDir, err := os.MkdirTemp("", "tlsconf-serve-")
+		if err != nil {
+			return fmt.Errorf("failed to create temporary directory (cause: %w)", err)
+		}
+		defer os.RemoveAll(trustDir)
+		trustFile = filepath.Join(trustDir, host+".crt")
+		err = tlsconf.WriteCertificates([]*x509.Certificate{tlsserver.GetConfig().Certificates[0].Leaf}, trustFile, tlsconf.CertificateFormatPEM)
+		if err != nil {
+			return err
+		}
+	}
+	server := tlsserver.ApplyConfig(&http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	})
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	fmt.Fprintf(cmd.stdout, "Serving %s at https://%s/\n", target, net.JoinHostPort(host, port))
+	fmt.Fprintf(cmd.stdout, "Trust the certificate '%s' to access the server\n", trustFile)
+	if *mtls {
+		fmt.Fprintf(cmd.stdout, "Client certificates are required (issue them via 'tlsconf ca issue -ca %s -client <name>')\n", ca.dir)
+	}
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-cmd.ctx.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+	err = server.ServeTLS(listener, "", "")
+	if !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve (cause: %w)", err)
+	}
+	<-shutdownDone
+	return nil
+}
+
+// loadOrCreate loads the CA or creates it, if it does not exist.
+func (ca *caFlags) loadOrCreate(algorithm tlsconf.CertificateAlgorithm) (*tlsconf.LocalCA, error) {
+	err := os.MkdirAll(ca.dir, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CA directory '%s' (cause: %w)", ca.dir, err)
+	}
+	return tlsconf.NewLocalCA(ca.keyStore(), ca.name, algorithm, defaultCALifetime)
+}
+
+func serveHandler(dir, proxy string) (http.Handler, string, error) {
+	if proxy != "" {
+		upstream, err := url.Parse(proxy)
+		if err != nil || upstream.Scheme == "" || upstream.Host == "" {
+			return nil, "", fmt.Errorf("invalid upstream URL '%s'", proxy)
+		}
+		return httputil.NewSingleHostReverseProxy(upstream), fmt.Sprintf("'%s'", upstream), nil
+	}
+	if dir == "" {
+		dir = "."
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to access directory '%s' (cause: %w)", dir, err)
+	}
+	if !info.IsDir() {
+		return nil, "", fmt.Errorf("not a directory: '%s'", dir)
+	}
+	return http.FileServer(http.Dir(dir)), fmt.Sprintf("directory '%s'", dir), nil
+}
+
+// serveHost determines the host name the server certificate is issued for. Wildcard and empty
+// listen hosts are mapped to localhost.
+func serveHost(address string) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil || host == "" {
+		return "localhost"
+	}
+	ip := net.ParseIP(host)
+	if ip != nil && ip.IsUnspecified() {
+		return "localhost"
+	}
+	return host
+}
@@ -0,0 +1,181 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf"
+)
+
+func TestRunServeDirectory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte("hello"), 0644))
+
+	server := startServe(t, "-listen", "127.0.0.1:0", dir)
+	require.Contains(t, server.stdout.String(), "Serving directory '"+dir+"'")
+	client := server.client(t, nil)
+	response, err := client.Get(server.url + "index.html")
+	require.NoError(t, err)
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(body))
+	require.Equal(t, exitSuccess, server.stop(t))
+	require.Contains(t, server.stderr.String(), "side=server")
+}
+
+func TestRunServeProxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("upstream " + r.URL.Path))
+	}))
+	defer upstream.Close()
+	caDir := t.TempDir()
+	outDir := t.TempDir()
+
+	server := startServe(t, "-listen", "127.0.0.1:0", "-ca", caDir, "-mtls", "-proxy", upstream.URL)
+	require.Contains(t, server.stdout.String(), filepath.Join(caDir, "ca.crt"))
+	require.Contains(t, server.stdout.String(), "Client certificates are required")
+	_, err := server.client(t, nil).Get(server.url)
+	require.Error(t, err)
+
+	_, stderr, exitCode := runTest(t, "ca", "issue", "-ca", caDir, "-client", "-out", outDir, "client")
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	clientCertificate, err := tls.LoadX509KeyPair(filepath.Join(outDir, "client.crt"), filepath.Join(outDir, "client.key"))
+	require.NoError(t, err)
+	response, err := server.client(t, &clientCertificate).Get(server.url + "path")
+	require.NoError(t, err)
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.Equal(t, "upstream /path", string(body))
+	require.Equal(t, exitSuccess, server.stop(t))
+}
+
+func TestRunServeHandshakeLogging(t *testing.T) {
+	caDir := t.TempDir()
+	outDir := t.TempDir()
+
+	server := startServe(t, "-listen", "127.0.0.1:0", "-ca", caDir, "-mtls", t.TempDir())
+	_, err := server.client(t, nil).Get(server.url)
+	require.Error(t, err)
+	require.Eventually(t, func() bool {
+		return strings.Contains(server.stderr.String(), `level=WARN msg="TLS handshake failed" side=server`)
+	}, 10*time.Second, 10*time.Millisecond, "stderr: %s", server.stderr)
+	require.Contains(t, server.stderr.String(), "client didn't provide a certificate")
+	require.NotContains(t, server.stderr.String(), `msg="TLS handshake" `)
+
+	_, stderr, exitCode := runTest(t, "ca", "issue", "-ca", caDir, "-client", "-out", outDir, "client")
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	clientCertificate, err := tls.LoadX509KeyPair(filepath.Join(outDir, "client.crt"), filepath.Join(outDir, "client.key"))
+	require.NoError(t, err)
+	response, err := server.client(t, &clientCertificate).Get(server.url)
+	require.NoError(t, err)
+	require.NoError(t, response.Body.Close())
+	require.Equal(t, exitSuccess, server.stop(t))
+	require.Regexp(t, `level=INFO msg="TLS handshake" side=server sni="" version="TLS 1.3" .* peer="CN=client"`, server.stderr.String())
+}
+
+func TestRunServeUsage(t *testing.T) {
+	_, stderr, exitCode := runTest(t, "serve", "-proxy", "http://localhost", t.TempDir())
+	require.Equal(t, exitUsage, exitCode)
+	require.Contains(t, stderr, "either a directory or an upstream URL")
+	_, stderr, exitCode = runTest(t, "serve", "-mtls")
+	require.Equal(t, exitFailure, exitCode)
+	require.Contains(t, stderr, "mutual TLS requires a CA")
+	_, stderr, exitCode = runTest(t, "serve", "-proxy", "localhost")
+	require.Equal(t, exitFailure, exitCode)
+	require.Contains(t, stderr, "invalid upstream URL")
+}
+
+var serveURLPattern = regexp.MustCompile(`at (https://\S+/)\n`)
+var serveTrustFilePattern = regexp.MustCompile(`Trust the certificate '([^']+)'`)
+
+type serveTest struct {
+	stdout    *syncBuffer
+	stderr    *syncBuffer
+	url       string
+	trustFile string
+	cancel    context.CancelFunc
+	exitCode  chan int
+}
+
+func startServe(t *testing.T, args ...string) *serveTest {
+	ctx, cancel := context.WithCancel(t.Context())
+	server := &serveTest{
+		stdout:   &syncBuffer{},
+		stderr:   &syncBuffer{},
+		cancel:   cancel,
+		exitCode: make(chan int, 1),
+	}
+	go func() {
+		server.exitCode <- run(ctx, append([]string{"serve"}, args...), server.stdout, server.stderr)
+	}()
+	require.Eventually(t, func() bool {
+		return serveTrustFilePattern.MatchString(server.stdout.String())
+	}, 10*time.Second, 10*time.Millisecond, "stderr: %s", server.stderr)
+	server.url = serveURLPattern.FindStringSubmatch(server.stdout.String())[1]
+	server.trustFile = serveTrustFilePattern.FindStringSubmatch(server.stdout.String())[1]
+	return server
+}
+
+func (server *serveTest) client(t *testing.T, certificate *tls.Certificate) *http.Client {
+	trustData, err := os.ReadFile(server.trustFile)
+	require.NoError(t, err)
+	trusted, err := tlsconf.DecodeCertificates(trustData, tlsconf.DecodeStrict)
+	require.NoError(t, err)
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(trusted[0])
+	config := &tls.Config{RootCAs: rootCAs}
+	if certificate != nil {
+		config.Certificates = []tls.Certificate{*certificate}
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+}
+
+func (server *serveTest) stop(t *testing.T) int {
+	server.cancel()
+	select {
+	case exitCode := <-server.exitCode:
+		t.Logf("tlsconf serve (exit code: %d)\n%s%s", exitCode, server.stdout, server.stderr)
+		return exitCode
+	case <-time.After(10 * time.Second):
+		t.Fatal("server did not stop")
+	}
+	return -1
+}
+
+type syncBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (buffer *syncBuffer) Write(p []byte) (int, error) {
+	buffer.mutex.Lock()
+	defer buffer.mutex.Unlock()
+	return buffer.buffer.Write(p)
+}
+
+func (buffer *syncBuffer) String() string {
+	buffer.mutex.Lock()
+	defer buffer.mutex.Unlock()
+	return buffer.buffer.String()
+}
@@ -0,0 +1,106 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/tdrn-org/go-tlsconf"
+)
+
+var verifyKeyUsages = map[string]x509.ExtKeyUsage{
+	"any":    x509.ExtKeyUsageAny,
+	"server": x509.ExtKeyUsageServerAuth,
+	"client": x509.ExtKeyUsageClientAuth,
+}
+
+func runVerify(cmd *commandContext, args []string) error {
+	flags := cmd.newFlagSet("<certificate file>", "Verifies the certificate chain contained in the given file against a CA bundle.\nThe first certificate in the file is verified, all others are used as intermediates.")
+	caFile := flags.String("ca", "", "CA bundle `file` containing the trusted root certificates (required)")
+	host := flags.String("host", "", "host `name` or IP address the certificate must be valid for")
+	usage := flags.String("usage", "any", "required extended key `usage` (any, server, client)")
+	crlFile := flags.String("crl", "", "revocation list `file` to check the certificate against")
+	password := newPassphraseFlags(flags, "password", "PKCS#12 password")
+	err := cmd.parseFlags(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	keyUsage, ok := verifyKeyUsages[*usage]
+	if *caFile == "" || !ok {
+		fmt.Fprintf(cmd.stderr, "%s: missing CA bundle or invalid key usage\n", cmd.name)
+		flags.Usage()
+		return errUsage
+	}
+	caData, err := os.ReadFile(*caFile)
+	if err != nil {
+		return fmt.Errorf("failed to read CA bundle '%s' (cause: %w)", *caFile, err)
+	}
+	caCertificates, err := tlsconf.DecodeCertificates(caData, tlsconf.DecodeStrict)
+	if err != nil {
+		return fmt.Errorf("failed to decode CA bundle '%s' (cause: %w)", *caFile, err)
+	}
+	certificateFile, err := loadCertificateFile(flags.Arg(0), password)
+	if err != nil {
+		return err
+	}
+	roots := x509.NewCertPool()
+	for _, caCertificate := range caCertificates {
+		roots.AddCert(caCertificate)
+	}
+	intermediates := x509.NewCertPool()
+	for _, intermediate := range certificateFile.certificates[1:] {
+		intermediates.AddCert(intermediate)
+	}
+	certificate := certificateFile.certificates[0]
+	chains, err := certificate.Verify(x509.VerifyOptions{
+		DNSName:       *host,
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{keyUsage},
+	})
+	if err != nil {
+		return fmt.Errorf("verification of certificate '%s' failed (cause: %w)", certificate.Subject, err)
+	}
+	if *crlFile != "" {
+		err = checkRevocationList(*crlFile, chains[0])
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(cmd.stdout, "Certificate '%s' verified:\n", certificate.Subject)
+	for index, chainCertificate := range chains[0] {
+		fmt.Fprintf(cmd.stdout, "  %d: %s\n", index, chainCertificate.Subject)
+	}
+	return nil
+}
+
+// checkRevocationList checks whether the chain's leaf certificate is listed in the given
+// revocation list. The revocation list must have been issued by the leaf certificate's issuer.
+func checkRevocationList(crlFile string, chain []*x509.Certificate) error {
+	crl, err := readRevocationList(crlFile)
+	if err != nil {
+		return err
+	}
+	certificate := chain[0]
+	issuer := certificate
+	if len(chain) > 1 {
+		issuer = chain[1]
+	}
+	err = crl.CheckSignatureFrom(issuer)
+	if err != nil {
+		return fmt.Errorf("revocation list '%s' has not been issued by '%s' (cause: %w)", crlFile, issuer.Subject, err)
+	}
+	if slices.ContainsFunc(crl.RevokedCertificateEntries, func(entry x509.RevocationListEntry) bool {
+		return entry.SerialNumber.Cmp(certificate.SerialNumber) == 0
+	}) {
+		return fmt.Errorf("certificate '%s' (serial %s) has been revoked", certificate.Subject, certificate.SerialNumber.Text(16))
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunVerify(t *testing.T) {
+	caDir := t.TempDir()
+	outDir := t.TempDir()
+	_, stderr, exitCode := runTest(t, "ca", "init", "-ca", caDir)
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	_, stderr, exitCode = runTest(t, "ca", "issue", "-ca", caDir, "-out", outDir, "localhost")
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	caFile := filepath.Join(caDir, "ca.crt")
+	certFile := filepath.Join(outDir, "localhost.crt")
+
+	stdout, stderr, exitCode := runTest(t, "verify", "-ca", caFile, "-host", "localhost", "-usage", "server", certFile)
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	require.Contains(t, stdout, "Certificate 'CN=localhost' verified")
+	require.Contains(t, stdout, "1: CN=ca")
+	_, stderr, exitCode = runTest(t, "verify", "-ca", caFile, "-host", "example.org", certFile)
+	require.Equal(t, exitFailure, exitCode)
+	require.Contains(t, stderr, "verification of certificate 'CN=localhost' failed")
+	_, stderr, exitCode = runTest(t, "verify", "-ca", caFile, "-usage", "client", certFile)
+	require.Equal(t, exitFailure, exitCode)
+	_, _, exitCode = runTest(t, "verify", certFile)
+	require.Equal(t, exitUsage, exitCode)
+
+	_, stderr, exitCode = runTest(t, "ca", "revoke", "-ca", caDir)
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	crlFile := filepath.Join(caDir, "ca.crl")
+	_, stderr, exitCode = runTest(t, "verify", "-ca", caFile, "-crl", crlFile, certFile)
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	_, stderr, exitCode = runTest(t, "ca", "revoke", "-ca", caDir, certFile)
+	require.Equal(t, exitSuccess, exitCode, stderr)
+	_, stderr, exitCode = runTest(t, "verify", "-ca", caFile, "-crl", crlFile, certFile)
+	require.Equal(t, exitFailure, exitCode)
+	require.Contains(t, stderr, "has been revoked")
+}
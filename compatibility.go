@@ -0,0 +1,481 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// The following tables mirror the defaults and preference orders of crypto/tls. GODEBUG and
+// FIPS 140-3 settings are not taken into account.
+
+var tlsVersions = []uint16{
+	tls.VersionTLS13,
+	tls.VersionTLS12,
+	tls.VersionTLS11,
+	tls.VersionTLS10,
+}
+
+var tls13CipherSuites = []uint16{
+	tls.TLS_AES_128_GCM_SHA256,
+	tls.TLS_AES_256_GCM_SHA384,
+	tls.TLS_CHACHA20_POLY1305_SHA256,
+}
+
+var cipherSuitesPreferenceOrder = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256, tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA, tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA, tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+	tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+	tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+	tls.TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA,
+	tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256, tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256,
+	tls.TLS_RSA_WITH_AES_128_CBC_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_RC4_128_SHA, tls.TLS_ECDHE_RSA_WITH_RC4_128_SHA,
+	tls.TLS_RSA_WITH_RC4_128_SHA,
+}
+
+var defaultDisabledCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256,
+	tls.TLS_RSA_WITH_AES_128_CBC_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_RC4_128_SHA,
+	tls.TLS_ECDHE_RSA_WITH_RC4_128_SHA,
+	tls.TLS_RSA_WITH_RC4_128_SHA,
+	tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
+	tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+	tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+	tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA,
+}
+
+var curvePreferenceOrder = []tls.CurveID{
+	tls.X25519MLKEM768,
+	tls.SecP256r1MLKEM768,
+	tls.SecP384r1MLKEM1024,
+	tls.X25519,
+	tls.CurveP256,
+	tls.CurveP384,
+	tls.CurveP521,
+}
+
+var tls13OnlyCurves = []tls.CurveID{
+	tls.X25519MLKEM768,
+	tls.SecP256r1MLKEM768,
+	tls.SecP384r1MLKEM1024,
+}
+
+var signatureSchemesPreferenceOrder = []tls.SignatureScheme{
+	tls.PSSWithSHA256,
+	tls.ECDSAWithP256AndSHA256,
+	tls.Ed25519,
+	tls.PSSWithSHA384,
+	tls.PSSWithSHA512,
+	tls.PKCS1WithSHA256,
+	tls.PKCS1WithSHA384,
+	tls.PKCS1WithSHA512,
+	tls.ECDSAWithP384AndSHA384,
+	tls.ECDSAWithP521AndSHA512,
+}
+
+var tls12OnlySignatureSchemes = []tls.SignatureScheme{
+	tls.PKCS1WithSHA256,
+	tls.PKCS1WithSHA384,
+	tls.PKCS1WithSHA512,
+}
+
+// TLSCapabilities describes the TLS parameters supported by one side of a TLS connection.
+//
+// All lists are ordered by preference. Cipher suites include the TLS 1.3 cipher suites,
+// if TLS 1.3 is supported. Curves and signature schemes are evaluated for the highest
+// supported TLS version. For a server, the signature schemes are the ones usable with
+// its certificates. For a client, the signature schemes are the ones accepted for the
+// server's signature.
+type TLSCapabilities struct {
+	Versions         []uint16
+	CipherSuites     []uint16
+	Curves           []tls.CurveID
+	SignatureSchemes []tls.SignatureScheme
+	Protocols        []string
+}
+
+// GetTLSCapabilities determines the TLS parameters supported by the given side's config.
+//
+// Only the static attributes of the config are evaluated. Callbacks like GetCertificate
+// or GetConfigForClient are not invoked.
+func GetTLSCapabilities(side HandshakeSide, config *tls.Config) *TLSCapabilities {
+	capabilities := &TLSCapabilities{
+		Versions:  configVersions(side, config),
+		Protocols: slices.Clone(config.NextProtos),
+	}
+	if len(capabilities.Versions) == 0 {
+		return capabilities
+	}
+	version := capabilities.Versions[0]
+	capabilities.CipherSuites = configCipherSuites(config, capabilities.Versions)
+	capabilities.Curves = configCurves(config, version)
+	capabilities.SignatureSchemes = configSignatureSchemes(side, config, version)
+	return capabilities
+}
+
+// CompatibilityReport contains the outcome of a configuration compatibility check run via
+// [CheckCompatibility].
+type CompatibilityReport struct {
+	Client             *TLSCapabilities
+	Server             *TLSCapabilities
+	Common             *TLSCapabilities
+	Version            uint16
+	CipherSuite        uint16
+	Curve              tls.CurveID
+	SignatureScheme    tls.SignatureScheme
+	NegotiatedProtocol string
+	Incompatibilities  []error
+}
+
+// CheckCompatibility checks whether a TLS handshake between the given client and server
+// configs is possible.
+//
+// The report contains the capabilities of both sides, their intersection (evaluated for the
+// predicted TLS version), the predicted negotiated parameters as well as the reasons for any
+// incompatibility. The prediction assumes hardware support for AES-GCM. Only the static
+// attributes of the configs are evaluated (see [GetTLSCapabilities]). Certificate trust is not
+// checked. Use an actual handshake (e.g. via tlstest.Handshake) for the latter.
+func CheckCompatibility(clientConfig, serverConfig *tls.Config) *CompatibilityReport {
+	report := &CompatibilityReport{
+		Client: GetTLSCapabilities(HandshakeSideClient, clientConfig),
+		Server: GetTLSCapabilities(HandshakeSideServer, serverConfig),
+		Common: &TLSCapabilities{},
+	}
+	report.checkProtocols()
+	report.checkClientCertificate(clientConfig, serverConfig)
+	if !report.checkVersion() {
+		return report
+	}
+	report.checkCurve(clientConfig, serverConfig)
+	report.checkSignatureScheme(clientConfig, serverConfig)
+	report.checkCipherSuite(clientConfig, serverConfig)
+	return report
+}
+
+// Compatible returns true, if no incompatibility has been detected.
+func (report *CompatibilityReport) Compatible() bool {
+	return len(report.Incompatibilities) == 0
+}
+
+// Err gets the combined incompatibilities or nil, if the configs are compatible.
+func (report *CompatibilityReport) Err() error {
+	return errors.Join(report.Incompatibilities...)
+}
+
+// LogAttrs gets the [slog.Attr]s describing the predicted negotiated parameters.
+func (report *CompatibilityReport) LogAttrs() []slog.Attr {
+	attrs := []slog.Attr{
+		slog.Bool("compatible", report.Compatible()),
+	}
+	if report.Version != 0 {
+		attrs = append(attrs, slog.String("version", tls.VersionName(report.Version)))
+	}
+	if report.CipherSuite != 0 {
+		attrs = append(attrs, slog.String("cipher_suite", tls.CipherSuiteName(report.CipherSuite)))
+	}
+	if report.Curve != 0 {
+		attrs = append(attrs, slog.String("curve", report.Curve.String()))
+	}
+	if report.SignatureScheme != 0 {
+		attrs = append(attrs, slog.String("signature_scheme", report.SignatureScheme.String()))
+	}
+	attrs = append(attrs, slog.String("alpn", report.NegotiatedProtocol))
+	if err := report.Err(); err != nil {
+		attrs = append(attrs, slog.Any("err", err))
+	}
+	return attrs
+}
+
+func (report *CompatibilityReport) incompatible(format string, args ...any) {
+	report.Incompatibilities = append(report.Incompatibilities, fmt.Errorf(format, args...))
+}
+
+func (report *CompatibilityReport) checkVersion() bool {
+	report.Common.Versions = intersect(report.Client.Versions, report.Server.Versions)
+	if len(report.Common.Versions) == 0 {
+		report.incompatible("no common TLS version (client: %s; server: %s)", versionNames(report.Client.Versions), versionNames(report.Server.Versions))
+		return false
+	}
+	report.Version = report.Common.Versions[0]
+	return true
+}
+
+func (report *CompatibilityReport) checkCurve(clientConfig, serverConfig *tls.Config) {
+	// the server selects the curve according to the fixed preference order
+	report.Common.Curves = intersect(configCurves(serverConfig, report.Version), configCurves(clientConfig, report.Version))
+	if len(report.Common.Curves) > 0 {
+		report.Curve = report.Common.Curves[0]
+	} else if report.Version == tls.VersionTLS13 {
+		report.incompatible("no common key exchange for %s (client: %s; server: %s)", tls.VersionName(report.Version), curveNames(report.Client.Curves), curveNames(report.Server.Curves))
+	}
+}
+
+func (report *CompatibilityReport) checkSignatureScheme(clientConfig, serverConfig *tls.Config) {
+	if len(serverConfig.Certificates) == 0 {
+		if serverConfig.GetCertificate == nil && serverConfig.GetConfigForClient == nil {
+			report.incompatible("server has no certificate")
+		}
+		return
+	}
+	if report.Version < tls.VersionTLS12 {
+		return
+	}
+	// the server selects the signature scheme according to the client's preference order
+	report.Common.SignatureSchemes = intersect(configSignatureSchemes(HandshakeSideClient, clientConfig, report.Version), configSignatureSchemes(HandshakeSideServer, serverConfig, report.Version))
+	if len(report.Common.SignatureSchemes) == 0 {
+		report.incompatible("no common signature scheme for %s (client: %s; server: %s)", tls.VersionName(report.Version), signatureSchemeNames(report.Client.SignatureSchemes), signatureSchemeNames(report.Server.SignatureSchemes))
+		return
+	}
+	report.SignatureScheme = report.Common.SignatureSchemes[0]
+}
+
+func (report *CompatibilityReport) checkCipherSuite(clientConfig, serverConfig *tls.Config) {
+	if report.Version == tls.VersionTLS13 {
+		report.Common.CipherSuites = slices.Clone(tls13CipherSuites)
+		report.CipherSuite = report.Common.CipherSuites[0]
+		return
+	}
+	versions := []uint16{report.Version}
+	// the server selects the cipher suite according to its own preference order
+	cipherSuites := intersect(configCipherSuites(serverConfig, versions), configCipherSuites(clientConfig, versions))
+	keyTypes := certificateKeyTypes(serverConfig)
+	report.Common.CipherSuites = slices.DeleteFunc(cipherSuites, func(cipherSuite uint16) bool {
+		name := tls.CipherSuiteName(cipherSuite)
+		if strings.HasPrefix(name, "TLS_ECDHE_") && report.Curve == 0 {
+			return true
+		}
+		if keyTypes == nil {
+			return false
+		}
+		if strings.Contains(name, "_ECDSA_") {
+			return !keyTypes["ECDSA"]
+		}
+		return !keyTypes["RSA"]
+	})
+	if len(report.Common.CipherSuites) == 0 {
+		report.incompatible("no common cipher suite for %s (client: %s; server: %s)", tls.VersionName(report.Version), cipherSuiteNames(report.Client.CipherSuites), cipherSuiteNames(report.Server.CipherSuites))
+		return
+	}
+	report.CipherSuite = report.Common.CipherSuites[0]
+	if !strings.HasPrefix(tls.CipherSuiteName(report.CipherSuite), "TLS_ECDHE_") {
+		// RSA key exchange neither uses a curve nor a signature
+		report.Curve = 0
+		report.SignatureScheme = 0
+	}
+}
+
+func (report *CompatibilityReport) checkProtocols() {
+	report.Common.Protocols = intersect(report.Server.Protocols, report.Client.Protocols)
+	if len(report.Common.Protocols) > 0 {
+		report.NegotiatedProtocol = report.Common.Protocols[0]
+		return
+	}
+	if len(report.Server.Protocols) == 0 || len(report.Client.Protocols) == 0 {
+		return
+	}
+	// crypto/tls lets http/1.1 clients connect to h2 servers as if they did not support ALPN
+	if slices.Contains(report.Server.Protocols, "h2") && slices.Contains(report.Client.Protocols, "http/1.1") {
+		return
+	}
+	report.incompatible("no common application protocol (client: %q; server: %q)", report.Client.Protocols, report.Server.Protocols)
+}
+
+func (report *CompatibilityReport) checkClientCertificate(clientConfig, serverConfig *tls.Config) {
+	if serverConfig.ClientAuth != tls.RequireAnyClientCert && serverConfig.ClientAuth != tls.RequireAndVerifyClientCert {
+		return
+	}
+	if len(clientConfig.Certificates) == 0 && clientConfig.GetClientCertificate == nil {
+		report.incompatible("server requires a client certificate, but client has none")
+	}
+}
+
+func configVersions(side HandshakeSide, config *tls.Config) []uint16 {
+	return slices.DeleteFunc(slices.Clone(tlsVersions), func(version uint16) bool {
+		switch {
+		case config.MinVersion == 0 && version < tls.VersionTLS12:
+			return true
+		case side == HandshakeSideClient && config.EncryptedClientHelloConfigList != nil && version < tls.VersionTLS13:
+			return true
+		case config.MinVersion != 0 && version < config.MinVersion:
+			return true
+		case config.MaxVersion != 0 && version > config.MaxVersion:
+			return true
+		}
+		return false
+	})
+}
+
+func configCipherSuites(config *tls.Config, versions []uint16) []uint16 {
+	supportedVersions := make(map[uint16][]uint16)
+	for _, cipherSuite := range slices.Concat(tls.CipherSuites(), tls.InsecureCipherSuites()) {
+		supportedVersions[cipherSuite.ID] = cipherSuite.SupportedVersions
+	}
+	cipherSuites := make([]uint16, 0)
+	if slices.Contains(versions, tls.VersionTLS13) {
+		cipherSuites = append(cipherSuites, tls13CipherSuites...)
+	}
+	for _, cipherSuite := range cipherSuitesPreferenceOrder {
+		if config.CipherSuites == nil && slices.Contains(defaultDisabledCipherSuites, cipherSuite) {
+			continue
+		}
+		if config.CipherSuites != nil && !slices.Contains(config.CipherSuites, cipherSuite) {
+			continue
+		}
+		if !slices.ContainsFunc(supportedVersions[cipherSuite], func(version uint16) bool {
+			return version < tls.VersionTLS13 && slices.Contains(versions, version)
+		}) {
+			continue
+		}
+		cipherSuites = append(cipherSuites, cipherSuite)
+	}
+	return cipherSuites
+}
+
+func configCurves(config *tls.Config, version uint16) []tls.CurveID {
+	return slices.DeleteFunc(slices.Clone(curvePreferenceOrder), func(curve tls.CurveID) bool {
+		if len(config.CurvePreferences) > 0 && !slices.Contains(config.CurvePreferences, curve) {
+			return true
+		}
+		return version < tls.VersionTLS13 && slices.Contains(tls13OnlyCurves, curve)
+	})
+}
+
+func configSignatureSchemes(side HandshakeSide, config *tls.Config, version uint16) []tls.SignatureScheme {
+	if version < tls.VersionTLS12 {
+		return nil
+	}
+	var supported func(tls.SignatureScheme) bool
+	if side == HandshakeSideServer {
+		supported = func(signatureScheme tls.SignatureScheme) bool {
+			return slices.ContainsFunc(config.Certificates, func(certificate tls.Certificate) bool {
+				if certificate.SupportedSignatureAlgorithms != nil && !slices.Contains(certificate.SupportedSignatureAlgorithms, signatureScheme) {
+					return false
+				}
+				return slices.Contains(publicKeySignatureSchemes(certificatePublicKey(&certificate), version), signatureScheme)
+			})
+		}
+	} else {
+		supported = func(tls.SignatureScheme) bool { return true }
+	}
+	signatureSchemes := make([]tls.SignatureScheme, 0)
+	for _, signatureScheme := range signatureSchemesPreferenceOrder {
+		if version >= tls.VersionTLS13 && slices.Contains(tls12OnlySignatureSchemes, signatureScheme) {
+			continue
+		}
+		if supported(signatureScheme) {
+			signatureSchemes = append(signatureSchemes, signatureScheme)
+		}
+	}
+	return signatureSchemes
+}
+
+func publicKeySignatureSchemes(publicKey crypto.PublicKey, version uint16) []tls.SignatureScheme {
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if version < tls.VersionTLS13 {
+			return []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256, tls.ECDSAWithP384AndSHA384, tls.ECDSAWithP521AndSHA512}
+		}
+		switch key.Curve {
+		case elliptic.P256():
+			return []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256}
+		case elliptic.P384():
+			return []tls.SignatureScheme{tls.ECDSAWithP384AndSHA384}
+		case elliptic.P521():
+			return []tls.SignatureScheme{tls.ECDSAWithP521AndSHA512}
+		}
+	case *rsa.PublicKey:
+		return []tls.SignatureScheme{tls.PSSWithSHA256, tls.PSSWithSHA384, tls.PSSWithSHA512, tls.PKCS1WithSHA256, tls.PKCS1WithSHA384, tls.PKCS1WithSHA512}
+	case ed25519.PublicKey:
+		return []tls.SignatureScheme{tls.Ed25519}
+	}
+	return nil
+}
+
+func certificatePublicKey(certificate *tls.Certificate) crypto.PublicKey {
+	signer, ok := certificate.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil
+	}
+	return signer.Public()
+}
+
+// certificateKeyTypes determines the key exchange signature types ("RSA" or "ECDSA") the static
+// certificates of the given config can be used with or nil, if the config has no static certificates.
+func certificateKeyTypes(config *tls.Config) map[string]bool {
+	if len(config.Certificates) == 0 {
+		return nil
+	}
+	keyTypes := make(map[string]bool)
+	for _, certificate := range config.Certificates {
+		switch certificatePublicKey(&certificate).(type) {
+		case *rsa.PublicKey:
+			keyTypes["RSA"] = true
+		case *ecdsa.PublicKey, ed25519.PublicKey:
+			keyTypes["ECDSA"] = true
+		}
+	}
+	return keyTypes
+}
+
+func intersect[T comparable](preferred, other []T) []T {
+	return slices.DeleteFunc(slices.Clone(preferred), func(element T) bool {
+		return !slices.Contains(other, element)
+	})
+}
+
+func versionNames(versions []uint16) []string {
+	names := make([]string, 0, len(versions))
+	for _, version := range versions {
+		names = append(names, tls.VersionName(version))
+	}
+	return names
+}
+
+func cipherSuiteNames(cipherSuites []uint16) []string {
+	names := make([]string, 0, len(cipherSuites))
+	for _, cipherSuite := range cipherSuites {
+		names = append(names, tls.CipherSuiteName(cipherSuite))
+	}
+	return names
+}
+
+func curveNames(curves []tls.CurveID) []string {
+	names := make([]string, 0, len(curves))
+	for _, curve := range curves {
+		names = append(names, curve.String())
+	}
+	return names
+}
+
+func signatureSchemeNames(signatureSchemes []tls.SignatureScheme) []string {
+	names := make([]string, 0, len(signatureSchemes))
+	for _, signatureScheme := range signatureSchemes {
+		names = append(names, signatureScheme.String())
+	}
+	return names
+}
@@ -0,0 +1,191 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf_test
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf"
+	"github.com/tdrn-org/go-tlsconf/tlstest"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	ecdsaPKI := tlstest.NewPKIWithAlgorithm(t, tlsconf.CertificateAlgorithmECDSA256)
+	rsaPKI := tlstest.NewPKIWithAlgorithm(t, tlsconf.CertificateAlgorithmRSA2048)
+	ed25519PKI := tlstest.NewPKIWithAlgorithm(t, tlsconf.CertificateAlgorithmED25519)
+	tests := []struct {
+		name            string
+		pki             *tlstest.PKI
+		configure       func(serverConfig, clientConfig *tls.Config)
+		version         uint16
+		cipherSuite     uint16
+		curve           tls.CurveID
+		signatureScheme tls.SignatureScheme
+		protocol        string
+	}{
+		{
+			name:            "defaults",
+			pki:             ecdsaPKI,
+			configure:       func(*tls.Config, *tls.Config) {},
+			version:         tls.VersionTLS13,
+			cipherSuite:     tls.TLS_AES_128_GCM_SHA256,
+			curve:           tls.X25519MLKEM768,
+			signatureScheme: tls.ECDSAWithP256AndSHA256,
+		},
+		{
+			name: "tls12-rsa",
+			pki:  rsaPKI,
+			configure: func(serverConfig, clientConfig *tls.Config) {
+				clientConfig.MaxVersion = tls.VersionTLS12
+				serverConfig.NextProtos = []string{"h2", "http/1.1"}
+				clientConfig.NextProtos = []string{"http/1.1"}
+			},
+			version:         tls.VersionTLS12,
+			cipherSuite:     tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			curve:           tls.X25519,
+			signatureScheme: tls.PSSWithSHA256,
+			protocol:        "http/1.1",
+		},
+		{
+			name: "tls12-ed25519",
+			pki:  ed25519PKI,
+			configure: func(serverConfig, clientConfig *tls.Config) {
+				serverConfig.MaxVersion = tls.VersionTLS12
+				serverConfig.CipherSuites = []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384}
+				serverConfig.CurvePreferences = []tls.CurveID{tls.CurveP384}
+			},
+			version:         tls.VersionTLS12,
+			cipherSuite:     tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			curve:           tls.CurveP384,
+			signatureScheme: tls.Ed25519,
+		},
+		{
+			name: "tls13-curve",
+			pki:  rsaPKI,
+			configure: func(serverConfig, clientConfig *tls.Config) {
+				serverConfig.CurvePreferences = []tls.CurveID{tls.CurveP521, tls.CurveP256}
+				serverConfig.NextProtos = []string{"h2"}
+				clientConfig.NextProtos = []string{"http/1.1"}
+			},
+			version:         tls.VersionTLS13,
+			cipherSuite:     tls.TLS_AES_128_GCM_SHA256,
+			curve:           tls.CurveP256,
+			signatureScheme: tls.PSSWithSHA256,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			serverConfig, clientConfig := test.pki.ConfigPair("localhost")
+			test.configure(serverConfig, clientConfig)
+			report := tlsconf.CheckCompatibility(clientConfig, serverConfig)
+			require.True(t, report.Compatible())
+			require.NoError(t, report.Err())
+			require.Equal(t, tls.VersionName(test.version), tls.VersionName(report.Version))
+			require.Equal(t, tls.CipherSuiteName(test.cipherSuite), tls.CipherSuiteName(report.CipherSuite))
+			require.Equal(t, test.curve, report.Curve)
+			require.Equal(t, test.signatureScheme, report.SignatureScheme)
+			require.Equal(t, test.protocol, report.NegotiatedProtocol)
+
+			result := tlstest.Handshake(t.Context(), clientConfig, serverConfig, "")
+			require.NoError(t, result.Err())
+			require.Equal(t, report.Version, result.ClientState.Version)
+			require.Equal(t, report.CipherSuite, result.ClientState.CipherSuite)
+			require.Equal(t, report.Curve, result.ClientState.CurveID)
+			require.Equal(t, report.NegotiatedProtocol, result.ClientState.NegotiatedProtocol)
+		})
+	}
+}
+
+func TestCheckCompatibilityIncompatible(t *testing.T) {
+	ecdsaPKI := tlstest.NewPKIWithAlgorithm(t, tlsconf.CertificateAlgorithmECDSA256)
+	tests := []struct {
+		name      string
+		configure func(serverConfig, clientConfig *tls.Config)
+		reason    string
+	}{
+		{
+			name: "version",
+			configure: func(serverConfig, clientConfig *tls.Config) {
+				serverConfig.MinVersion = tls.VersionTLS13
+				clientConfig.MaxVersion = tls.VersionTLS12
+			},
+			reason: "no common TLS version (client: [TLS 1.2]; server: [TLS 1.3])",
+		},
+		{
+			name: "cipher-suite",
+			configure: func(serverConfig, clientConfig *tls.Config) {
+				serverConfig.MaxVersion = tls.VersionTLS12
+				serverConfig.CipherSuites = []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}
+			},
+			reason: "no common cipher suite for TLS 1.2",
+		},
+		{
+			name: "curve",
+			configure: func(serverConfig, clientConfig *tls.Config) {
+				serverConfig.CurvePreferences = []tls.CurveID{tls.CurveP384}
+				clientConfig.CurvePreferences = []tls.CurveID{tls.X25519}
+			},
+			reason: "no common key exchange for TLS 1.3",
+		},
+		{
+			name: "signature-scheme",
+			configure: func(serverConfig, clientConfig *tls.Config) {
+				serverConfig.Certificates[0].SupportedSignatureAlgorithms = []tls.SignatureScheme{tls.ECDSAWithP384AndSHA384}
+			},
+			reason: "no common signature scheme for TLS 1.3",
+		},
+		{
+			name: "alpn",
+			configure: func(serverConfig, clientConfig *tls.Config) {
+				serverConfig.NextProtos = []string{"h2"}
+				clientConfig.NextProtos = []string{"acme-tls/1"}
+			},
+			reason: "no common application protocol",
+		},
+		{
+			name: "client-certificate",
+			configure: func(serverConfig, clientConfig *tls.Config) {
+				serverConfig.ClientAuth = tls.RequireAnyClientCert
+			},
+			reason: "server requires a client certificate, but client has none",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			serverConfig, clientConfig := ecdsaPKI.ConfigPair("localhost")
+			test.configure(serverConfig, clientConfig)
+			report := tlsconf.CheckCompatibility(clientConfig, serverConfig)
+			require.False(t, report.Compatible())
+			require.Len(t, report.Incompatibilities, 1)
+			require.ErrorContains(t, report.Err(), test.reason)
+
+			result := tlstest.Handshake(t.Context(), clientConfig, serverConfig, "")
+			require.Error(t, result.Err())
+		})
+	}
+}
+
+func TestGetTLSCapabilities(t *testing.T) {
+	serverConfig, clientConfig := tlstest.NewPKIWithAlgorithm(t, tlsconf.CertificateAlgorithmED25519).ConfigPair("localhost")
+	clientConfig.MinVersion = tls.VersionTLS10
+	clientConfig.MaxVersion = tls.VersionTLS11
+	clientCapabilities := tlsconf.GetTLSCapabilities(tlsconf.HandshakeSideClient, clientConfig)
+	require.Equal(t, []uint16{tls.VersionTLS11, tls.VersionTLS10}, clientCapabilities.Versions)
+	require.NotContains(t, clientCapabilities.CipherSuites, tls.TLS_AES_128_GCM_SHA256)
+	require.NotContains(t, clientCapabilities.CipherSuites, tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256)
+	require.Contains(t, clientCapabilities.CipherSuites, tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA)
+	require.NotContains(t, clientCapabilities.Curves, tls.X25519MLKEM768)
+	require.Empty(t, clientCapabilities.SignatureSchemes)
+
+	serverCapabilities := tlsconf.GetTLSCapabilities(tlsconf.HandshakeSideServer, serverConfig)
+	require.Equal(t, []uint16{tls.VersionTLS13, tls.VersionTLS12}, serverCapabilities.Versions)
+	require.Equal(t, tls.TLS_AES_128_GCM_SHA256, serverCapabilities.CipherSuites[0])
+	require.Equal(t, tls.X25519MLKEM768, serverCapabilities.Curves[0])
+	require.Equal(t, []tls.SignatureScheme{tls.Ed25519}, serverCapabilities.SignatureSchemes)
+}
@@ -0,0 +1,235 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DecodeMode defines how decoding errors are handled by [DecodeCertificates].
+type DecodeMode int
+
+const (
+	DecodeStrict  DecodeMode = iota // Fail on the first malformed block
+	DecodeLenient                   // Skip malformed blocks and report them collectively
+)
+
+// ErrNoCertificates indicates certificate data which does not contain any certificate.
+var ErrNoCertificates = errors.New("no certificates found")
+
+// ErrMalformedPEMBlock indicates a PEM block which cannot be decoded.
+var ErrMalformedPEMBlock = errors.New("malformed PEM block")
+
+// DecodeError describes a single block which failed to decode.
+type DecodeError struct {
+	// Offset is the byte offset of the block within the decoded data.
+	Offset int
+	// Type is the PEM block type (empty if the block could not be decoded at all).
+	Type string
+	// Err is the cause of the failure.
+	Err error
+}
+
+func (err *DecodeError) Error() string {
+	if err.Type == "" {
+		return fmt.Sprintf("failed to decode block at offset %d (cause: %v)", err.Offset, err.Err)
+	}
+	return fmt.Sprintf("failed to decode %s block at offset %d (cause: %v)", err.Type, err.Offset, err.Err)
+}
+
+func (err *DecodeError) Unwrap() error {
+	return err.Err
+}
+
+// DecodeErrors collects the [DecodeError]s encountered in [DecodeLenient] mode.
+type DecodeErrors []*DecodeError
+
+func (errs DecodeErrors) Error() string {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (errs DecodeErrors) Unwrap() []error {
+	unwrapped := make([]error, 0, len(errs))
+	for _, err := range errs {
+		unwrapped = append(unwrapped, err)
+	}
+	return unwrapped
+}
+
+// CertificateFormat defines the supported certificate encodings.
+type CertificateFormat string
+
+const (
+	CertificateFormatUnknown CertificateFormat = ""      // Unknown format
+	CertificateFormatPEM     CertificateFormat = "pem"   // PEM encoded (CERTIFICATE or PKCS7 blocks)
+	CertificateFormatDER     CertificateFormat = "der"   // DER encoded (one or more concatenated certificates)
+	CertificateFormatPKCS7   CertificateFormat = "pkcs7" // DER encoded PKCS#7 signed data (certificates only)
+)
+
+var pemBegin = []byte("-----BEGIN")
+
+// DetectCertificateFormat determines the [CertificateFormat] of the given data.
+func DetectCertificateFormat(certData []byte) CertificateFormat {
+	if bytes.Contains(certData, pemBegin) {
+		return CertificateFormatPEM
+	}
+	if isPKCS7(certData) {
+		return CertificateFormatPKCS7
+	}
+	if len(certData) > 0 && certData[0] == 0x30 {
+		return CertificateFormatDER
+	}
+	return CertificateFormatUnknown
+}
+
+// DecodeCertificates decodes all certificates contained in the given data.
+//
+// The data format is detected automatically (see [DetectCertificateFormat]). PEM data may contain
+// any mix of CERTIFICATE and PKCS7 blocks. Blocks of other types as well as text outside of PEM blocks
+// are ignored. In [DecodeStrict] mode decoding fails with a [DecodeError] on the first malformed block.
+// In [DecodeLenient] mode malformed blocks are skipped and the successfully decoded certificates
+// are returned together with a [DecodeErrors] error describing all skipped blocks. In both modes
+// [ErrNoCertificates] is returned, if the data does not contain any certificate.
+func DecodeCertificates(certData []byte, mode DecodeMode) ([]*x509.Certificate, error) {
+	var certificates []*x509.Certificate
+	var decodeErrs DecodeErrors
+	var err error
+	switch DetectCertificateFormat(certData) {
+	case CertificateFormatPEM:
+		certificates, decodeErrs, err = decodePEMCertificates(certData, mode)
+	case CertificateFormatPKCS7:
+		certificates, err = parsePKCS7Certificates(certData)
+		if err != nil {
+			err = &DecodeError{Offset: 0, Err: err}
+		}
+	case CertificateFormatDER:
+		certificates, decodeErrs, err = decodeDERCertificates(certData, 0, mode)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(certificates) == 0 {
+		if len(decodeErrs) > 0 {
+			return nil, errors.Join(ErrNoCertificates, decodeErrs)
+		}
+		return nil, ErrNoCertificates
+	}
+	if len(decodeErrs) > 0 {
+		return certificates, decodeErrs
+	}
+	return certificates, nil
+}
+
+func decodePEMCertificates(certData []byte, mode DecodeMode) ([]*x509.Certificate, DecodeErrors, error) {
+	certificates := make([]*x509.Certificate, 0)
+	decodeErrs := make(DecodeErrors, 0)
+	rest := certData
+	for {
+		blockIndex := bytes.Index(rest, pemBegin)
+		if blockIndex < 0 {
+			break
+		}
+		offset := len(certData) - len(rest) + blockIndex
+		var segment []byte
+		segment, rest = nextPEMSegment(rest[blockIndex:])
+		pemBlock, _ := pem.Decode(segment)
+		if pemBlock == nil {
+			decodeErr := &DecodeError{Offset: offset, Err: ErrMalformedPEMBlock}
+			if mode == DecodeStrict {
+				return nil, nil, decodeErr
+			}
+			decodeErrs = append(decodeErrs, decodeErr)
+			continue
+		}
+		var blockCertificates []*x509.Certificate
+		var err error
+		switch pemBlock.Type {
+		case "CERTIFICATE":
+			var certificate *x509.Certificate
+			certificate, err = x509.ParseCertificate(pemBlock.Bytes)
+			blockCertificates = []*x509.Certificate{certificate}
+		case "PKCS7":
+			blockCertificates, err = parsePKCS7Certificates(pemBlock.Bytes)
+		default:
+			continue
+		}
+		if err != nil {
+			decodeErr := &DecodeError{Offset: offset, Type: pemBlock.Type, Err: err}
+			if mode == DecodeStrict {
+				return nil, nil, decodeErr
+			}
+			decodeErrs = append(decodeErrs, decodeErr)
+			continue
+		}
+		certificates = append(certificates, blockCertificates...)
+	}
+	return certificates, decodeErrs, nil
+}
+
+var pemEnd = []byte("-----END")
+
+// nextPEMSegment splits off the PEM block starting at the beginning of the given data. The segment
+// ends with the line containing the block's END marker or right before the next BEGIN marker, whatever
+// comes first. Decoding the segment on its own ensures that a malformed block is reported as such
+// instead of being silently skipped by [pem.Decode] in favour of the following block.
+func nextPEMSegment(data []byte) ([]byte, []byte) {
+	segmentEnd := len(data)
+	nextBegin := bytes.Index(data[len(pemBegin):], pemBegin)
+	if nextBegin >= 0 {
+		segmentEnd = len(pemBegin) + nextBegin
+	}
+	end := bytes.Index(data[:segmentEnd], pemEnd)
+	if end >= 0 {
+		lineEnd := bytes.IndexByte(data[end:segmentEnd], '\n')
+		if lineEnd >= 0 {
+			segmentEnd = end + lineEnd + 1
+		}
+	}
+	return data[:segmentEnd], data[segmentEnd:]
+}
+
+func decodeDERCertificates(certData []byte, baseOffset int, mode DecodeMode) ([]*x509.Certificate, DecodeErrors, error) {
+	certificates := make([]*x509.Certificate, 0)
+	decodeErrs := make(DecodeErrors, 0)
+	rest := certData
+	for len(rest) > 0 {
+		offset := baseOffset + len(certData) - len(rest)
+		var element asn1.RawValue
+		next, err := asn1.Unmarshal(rest, &element)
+		if err != nil {
+			// without a valid ASN.1 structure we cannot determine where the next certificate starts
+			decodeErr := &DecodeError{Offset: offset, Err: err}
+			if mode == DecodeStrict {
+				return nil, nil, decodeErr
+			}
+			decodeErrs = append(decodeErrs, decodeErr)
+			break
+		}
+		rest = next
+		certificate, err := x509.ParseCertificate(element.FullBytes)
+		if err != nil {
+			decodeErr := &DecodeError{Offset: offset, Err: err}
+			if mode == DecodeStrict {
+				return nil, nil, decodeErr
+			}
+			decodeErrs = append(decodeErrs, decodeErr)
+			continue
+		}
+		certificates = append(certificates, certificate)
+	}
+	return certificates, decodeErrs, nil
+}
@@ -0,0 +1,170 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf_test
+
+import (
+	"bytes"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf"
+)
+
+func TestDecodeCertificates(t *testing.T) {
+	certData := newDecodeTestData(t, 2)
+	certificates, err := tlsconf.DecodeCertificates(certData, tlsconf.DecodeStrict)
+	require.NoError(t, err)
+	require.Len(t, certificates, 2)
+}
+
+func TestDecodeCertificatesNoCertificates(t *testing.T) {
+	certificates, err := tlsconf.DecodeCertificates([]byte("# empty bundle\n"), tlsconf.DecodeStrict)
+	require.ErrorIs(t, err, tlsconf.ErrNoCertificates)
+	require.Nil(t, certificates)
+	certificates, err = tlsconf.DecodeCertificates(nil, tlsconf.DecodeLenient)
+	require.ErrorIs(t, err, tlsconf.ErrNoCertificates)
+	require.Nil(t, certificates)
+}
+
+func TestDecodeCertificatesStrict(t *testing.T) {
+	certData := newDecodeTestData(t, 1)
+	invalidOffset := len(certData)
+	certData = append(certData, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("invalid")})...)
+	certData = append(certData, newDecodeTestData(t, 1)...)
+	certificates, err := tlsconf.DecodeCertificates(certData, tlsconf.DecodeStrict)
+	require.Nil(t, certificates)
+	decodeErr := &tlsconf.DecodeError{}
+	require.True(t, errors.As(err, &decodeErr))
+	require.Equal(t, invalidOffset, decodeErr.Offset)
+	require.Equal(t, "CERTIFICATE", decodeErr.Type)
+}
+
+func TestDecodeCertificatesLenient(t *testing.T) {
+	certData := newDecodeTestData(t, 1)
+	invalidOffset1 := len(certData)
+	certData = append(certData, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("invalid")})...)
+	certData = append(certData, newDecodeTestData(t, 1)...)
+	invalidOffset2 := len(certData)
+	certData = append(certData, []byte("-----BEGIN CERTIFICATE-----\ntruncated\n")...)
+	certificates, err := tlsconf.DecodeCertificates(certData, tlsconf.DecodeLenient)
+	require.Len(t, certificates, 2)
+	decodeErrs := tlsconf.DecodeErrors{}
+	require.True(t, errors.As(err, &decodeErrs))
+	require.Len(t, decodeErrs, 2)
+	require.Equal(t, invalidOffset1, decodeErrs[0].Offset)
+	require.Equal(t, invalidOffset2, decodeErrs[1].Offset)
+	require.ErrorIs(t, err, tlsconf.ErrMalformedPEMBlock)
+}
+
+func TestDecodeCertificatesBadBase64(t *testing.T) {
+	certData := []byte("-----BEGIN CERTIFICATE-----\n!!! not base64 !!!\n-----END CERTIFICATE-----\n")
+	validOffset := len(certData)
+	certData = append(certData, newDecodeTestData(t, 1)...)
+	certificates, err := tlsconf.DecodeCertificates(certData, tlsconf.DecodeStrict)
+	require.Nil(t, certificates)
+	decodeErr := &tlsconf.DecodeError{}
+	require.True(t, errors.As(err, &decodeErr))
+	require.Equal(t, 0, decodeErr.Offset)
+	require.ErrorIs(t, err, tlsconf.ErrMalformedPEMBlock)
+	certificates, err = tlsconf.DecodeCertificates(certData, tlsconf.DecodeLenient)
+	require.Len(t, certificates, 1)
+	decodeErrs := tlsconf.DecodeErrors{}
+	require.True(t, errors.As(err, &decodeErrs))
+	require.Len(t, decodeErrs, 1)
+	require.Equal(t, 0, decodeErrs[0].Offset)
+	require.ErrorIs(t, err, tlsconf.ErrMalformedPEMBlock)
+	certificates, err = tlsconf.DecodeCertificates(certData[validOffset:], tlsconf.DecodeStrict)
+	require.NoError(t, err)
+	require.Len(t, certificates, 1)
+}
+
+func TestDecodeCertificatesTruncatedBlock(t *testing.T) {
+	certData := []byte("-----BEGIN CERTIFICATE-----\ntruncated\n")
+	certData = append(certData, newDecodeTestData(t, 1)...)
+	_, err := tlsconf.DecodeCertificates(certData, tlsconf.DecodeStrict)
+	require.ErrorIs(t, err, tlsconf.ErrMalformedPEMBlock)
+	certificates, err := tlsconf.DecodeCertificates(certData, tlsconf.DecodeLenient)
+	require.Len(t, certificates, 1)
+	require.ErrorIs(t, err, tlsconf.ErrMalformedPEMBlock)
+}
+
+func newDecodeTestData(t *testing.T, count int) []byte {
+	certData := &bytes.Buffer{}
+	for range count {
+		certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+		require.NoError(t, err)
+		certData.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Certificate[0]}))
+	}
+	return certData.Bytes()
+}
+
+func TestDecodeCertificatesFormats(t *testing.T) {
+	certificates, err := tlsconf.DecodeCertificates(newDecodeTestData(t, 2), tlsconf.DecodeStrict)
+	require.NoError(t, err)
+	formats := []tlsconf.CertificateFormat{
+		tlsconf.CertificateFormatPEM,
+		tlsconf.CertificateFormatDER,
+		tlsconf.CertificateFormatPKCS7,
+	}
+	for _, format := range formats {
+		t.Run(string(format), func(t *testing.T) {
+			certData, err := tlsconf.EncodeCertificates(certificates, format)
+			require.NoError(t, err)
+			require.Equal(t, format, tlsconf.DetectCertificateFormat(certData))
+			decodedCertificates, err := tlsconf.DecodeCertificates(certData, tlsconf.DecodeStrict)
+			require.NoError(t, err)
+			require.Equal(t, certificates, decodedCertificates)
+		})
+	}
+}
+
+func TestDecodeCertificatesMixedPEM(t *testing.T) {
+	certificates, err := tlsconf.DecodeCertificates(newDecodeTestData(t, 2), tlsconf.DecodeStrict)
+	require.NoError(t, err)
+	pkcs7Data, err := tlsconf.EncodeCertificates(certificates, tlsconf.CertificateFormatPKCS7)
+	require.NoError(t, err)
+	certData := newDecodeTestData(t, 1)
+	certData = append(certData, pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: pkcs7Data})...)
+	decodedCertificates, err := tlsconf.DecodeCertificates(certData, tlsconf.DecodeStrict)
+	require.NoError(t, err)
+	require.Len(t, decodedCertificates, 3)
+	require.Equal(t, certificates, decodedCertificates[1:])
+}
+
+func TestDecodeCertificatesDERLenient(t *testing.T) {
+	certificates, err := tlsconf.DecodeCertificates(newDecodeTestData(t, 1), tlsconf.DecodeStrict)
+	require.NoError(t, err)
+	invalidCertificate := []byte{0x30, 0x03, 0x02, 0x01, 0x00}
+	certData := append(slices.Clone(invalidCertificate), certificates[0].Raw...)
+	_, err = tlsconf.DecodeCertificates(certData, tlsconf.DecodeStrict)
+	require.Error(t, err)
+	decodedCertificates, err := tlsconf.DecodeCertificates(certData, tlsconf.DecodeLenient)
+	require.Equal(t, certificates, decodedCertificates)
+	decodeErrs := tlsconf.DecodeErrors{}
+	require.True(t, errors.As(err, &decodeErrs))
+	require.Len(t, decodeErrs, 1)
+	require.Equal(t, 0, decodeErrs[0].Offset)
+}
+
+func TestWriteCertificates(t *testing.T) {
+	certificates, err := tlsconf.DecodeCertificates(newDecodeTestData(t, 2), tlsconf.DecodeStrict)
+	require.NoError(t, err)
+	certFile := filepath.Join(t.TempDir(), "bundle.p7b")
+	err = tlsconf.WriteCertificates(certificates, certFile, tlsconf.CertificateFormatPKCS7)
+	require.NoError(t, err)
+	certData, err := os.ReadFile(certFile)
+	require.NoError(t, err)
+	decodedCertificates, err := tlsconf.DecodeCertificates(certData, tlsconf.DecodeStrict)
+	require.NoError(t, err)
+	require.Equal(t, certificates, decodedCertificates)
+}
@@ -0,0 +1,76 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf
+
+import (
+	"math/big"
+	"sync"
+	"time"
+)
+
+var deterministicClock func() time.Time
+var deterministicSerialNumber int64 // guarded by certificateSerialNumberLock
+var deterministicLock sync.RWMutex = sync.RWMutex{}
+
+// EnableDeterministicMode makes certificate generation reproducible. TEST-ONLY!
+//
+// While enabled, the given clock is used for all validity periods and serial numbers are derived
+// from the clock in a strictly increasing sequence. Enabled key pools (see [EnableKeyPool]) are bypassed
+// and their background workers are paused (after completing any running key generation), hence they
+// do not consume random data. Combined with a seeded random source (see [testing/cryptotest.SetGlobalRandom])
+// keys and certificates are byte-identical across runs. The random source must be seeded after
+// enabling deterministic mode. The returned function disables deterministic mode again.
+//
+// As this setting affects the whole process, it must only be used in non-parallel tests. See
+// the tlstest package for a helper combining both settings.
+func EnableDeterministicMode(clock func() time.Time) func() {
+	previousClock, previousSerialNumber := setDeterministicMode(clock, 0)
+	pauseKeyPools()
+	return func() {
+		setDeterministicMode(previousClock, previousSerialNumber)
+		resumeKeyPools()
+	}
+}
+
+func setDeterministicMode(clock func() time.Time, serialNumber int64) (func() time.Time, int64) {
+	certificateSerialNumberLock.Lock()
+	defer certificateSerialNumberLock.Unlock()
+	deterministicLock.Lock()
+	defer deterministicLock.Unlock()
+	previousClock := deterministicClock
+	previousSerialNumber := deterministicSerialNumber
+	deterministicClock = clock
+	deterministicSerialNumber = serialNumber
+	return previousClock, previousSerialNumber
+}
+
+func isDeterministic() bool {
+	deterministicLock.RLock()
+	defer deterministicLock.RUnlock()
+	return deterministicClock != nil
+}
+
+func certificateNow() time.Time {
+	deterministicLock.RLock()
+	defer deterministicLock.RUnlock()
+	if deterministicClock != nil {
+		return deterministicClock().UTC()
+	}
+	return time.Now().UTC()
+}
+
+// nextDeterministicSerialNumber must be called with certificateSerialNumberLock held and
+// returns nil, if deterministic mode is disabled.
+func nextDeterministicSerialNumber() *big.Int {
+	deterministicLock.RLock()
+	defer deterministicLock.RUnlock()
+	if deterministicClock == nil {
+		return nil
+	}
+	deterministicSerialNumber = max(deterministicClock().UnixMilli(), deterministicSerialNumber+1)
+	return big.NewInt(deterministicSerialNumber)
+}
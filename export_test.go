@@ -0,0 +1,17 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf
+
+// SetRename replaces the function used to install written files and returns a function
+// restoring the original one.
+func SetRename(renameFunc func(string, string) error) func() {
+	original := rename
+	rename = renameFunc
+	return func() {
+		rename = original
+	}
+}
@@ -3,8 +3,11 @@ module github.com/tdrn-org/go-tlsconf
 go 1.26.5
 
 require (
+	github.com/miekg/pkcs11 v1.1.2
 	github.com/stretchr/testify v1.11.1
 	github.com/tdrn-org/go-conf v0.0.8
+	golang.org/x/crypto v0.54.0
+	software.sslmate.com/src/go-pkcs12 v0.5.0
 )
 
 require (
@@ -0,0 +1,266 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HandshakeSide defines the side of a TLS handshake.
+type HandshakeSide string
+
+const (
+	HandshakeSideClient HandshakeSide = "client" // Handshake observed on client side
+	HandshakeSideServer HandshakeSide = "server" // Handshake observed on server side
+)
+
+// HandshakeEvent contains the information of an observed TLS handshake.
+type HandshakeEvent struct {
+	Side               HandshakeSide
+	Version            uint16
+	CipherSuite        uint16
+	NegotiatedProtocol string
+	ServerName         string
+	DidResume          bool
+	PeerSubject        string
+	Err                error
+}
+
+func newHandshakeEvent(side HandshakeSide, state *tls.ConnectionState, err error) *HandshakeEvent {
+	event := &HandshakeEvent{
+		Side:               side,
+		Version:            state.Version,
+		CipherSuite:        state.CipherSuite,
+		NegotiatedProtocol: state.NegotiatedProtocol,
+		ServerName:         state.ServerName,
+		DidResume:          state.DidResume,
+		Err:                err,
+	}
+	if len(state.PeerCertificates) > 0 {
+		event.PeerSubject = state.PeerCertificates[0].Subject.String()
+	}
+	return event
+}
+
+// LogAttrs gets the [slog.Attr]s describing this event.
+func (event *HandshakeEvent) LogAttrs() []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("side", string(event.Side)),
+		slog.String("sni", event.ServerName),
+	}
+	if event.Version != 0 {
+		attrs = append(attrs,
+			slog.String("version", tls.VersionName(event.Version)),
+			slog.String("cipher_suite", tls.CipherSuiteName(event.CipherSuite)),
+			slog.String("alpn", event.NegotiatedProtocol),
+			slog.Bool("resumed", event.DidResume),
+			slog.String("peer", event.PeerSubject),
+		)
+	}
+	if event.Err != nil {
+		attrs = append(attrs, slog.Any("err", event.Err))
+	}
+	return attrs
+}
+
+// HandshakeHook functions are invoked for every observed TLS handshake.
+type HandshakeHook func(*HandshakeEvent)
+
+// HandshakeSampler decides which observed TLS handshakes are logged.
+type HandshakeSampler interface {
+	// Sample returns true, if the given event should be logged.
+	Sample(event *HandshakeEvent) bool
+}
+
+// NewHandshakeSampler creates a [HandshakeSampler] logging the first events per tick
+// and every thereafter event after that.
+//
+// Successful and failed handshakes are counted separately, hence failures are not
+// suppressed by a flood of successful handshakes (and vice versa). A thereafter value
+// of 0 drops all events exceeding first until the next tick. The tick must be positive.
+func NewHandshakeSampler(tick time.Duration, first, thereafter int) (HandshakeSampler, error) {
+	if tick <= 0 {
+		return nil, fmt.Errorf("invalid handshake sampler tick: %s", tick)
+	}
+	if first < 0 || thereafter < 0 {
+		return nil, fmt.Errorf("invalid handshake sampler counts: %d, %d", first, thereafter)
+	}
+	return &handshakeSampler{
+		tick:       tick,
+		first:      first,
+		thereafter: thereafter,
+	}, nil
+}
+
+type handshakeSampler struct {
+	tick        time.Duration
+	first       int
+	thereafter  int
+	mutex       sync.Mutex
+	windowStart time.Time
+	counts      [2]int
+}
+
+func (sampler *handshakeSampler) Sample(event *HandshakeEvent) bool {
+	sampler.mutex.Lock()
+	defer sampler.mutex.Unlock()
+	now := time.Now()
+	if now.Sub(sampler.windowStart) >= sampler.tick {
+		sampler.windowStart = now
+		sampler.counts = [2]int{}
+	}
+	countIndex := 0
+	if event.Err != nil {
+		countIndex = 1
+	}
+	sampler.counts[countIndex]++
+	count := sampler.counts[countIndex]
+	if count <= sampler.first {
+		return true
+	}
+	return sampler.thereafter > 0 && (count-sampler.first)%sampler.thereafter == 0
+}
+
+// ErrHandshakeAborted indicates a TLS handshake, which failed before the connection has been verified.
+var ErrHandshakeAborted = errors.New("TLS handshake aborted")
+
+// EnableHandshakeLogging wraps the VerifyConnection (client side) respectively GetConfigForClient
+// (server side) attribute to log every TLS handshake and to invoke the given hooks.
+//
+// See [EnableHandshakeLoggingWithTimeout] for details.
+func EnableHandshakeLogging(side HandshakeSide, logger *slog.Logger, sampler HandshakeSampler, hooks ...HandshakeHook) TLSConfigOption {
+	return EnableHandshakeLoggingWithTimeout(side, 0, logger, sampler, hooks...)
+}
+
+// EnableHandshakeLoggingWithTimeout wraps the VerifyConnection (client side) respectively
+// GetConfigForClient (server side) attribute to log every TLS handshake and to invoke the given hooks.
+//
+// Already set VerifyConnection and GetConfigForClient functions are still invoked and their errors
+// are reported as handshake failures. Hence this option should be applied after all options setting
+// these attributes. If logger is nil, [slog.Default] is used. If sampler is nil, all handshakes are
+// logged. Hooks are invoked for all handshakes regardless of sampling.
+//
+// On server side every handshake is tracked from the client hello until its conclusion. Handshakes
+// failing before the connection has been verified (e.g. due to a rejected certificate or a version
+// mismatch) are reported with [ErrHandshakeAborted] (the actual cause is returned to the caller of
+// the handshake). Note that a TLS 1.3 server verifies the connection before it has received the
+// client's finished message, unless client certificates are requested. Hence a client rejecting the
+// server's certificate is only reported for TLS 1.2 or if client certificates are requested.
+// A positive timeout limits the duration of server side handshakes by closing the connection, if
+// the handshake has not been concluded in time.
+//
+// On client side only handshakes reaching the VerifyConnection stage are observed, as failures of
+// the standard certificate verification are returned by the handshake directly. Use the dialer's
+// timeout to limit client side handshakes.
+func EnableHandshakeLoggingWithTimeout(side HandshakeSide, timeout time.Duration, logger *slog.Logger, sampler HandshakeSampler, hooks ...HandshakeHook) TLSConfigOption {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	observer := &handshakeObserver{
+		side:    side,
+		logger:  logger,
+		sampler: sampler,
+		hooks:   hooks,
+	}
+	return func(config *tls.Config) error {
+		if timeout < 0 {
+			return fmt.Errorf("invalid handshake timeout: %s", timeout)
+		}
+		switch side {
+		case HandshakeSideClient:
+			config.VerifyConnection = observer.wrapVerifyConnection(config.VerifyConnection)
+		case HandshakeSideServer:
+			config.GetConfigForClient = observer.wrapGetConfigForClient(config, config.VerifyConnection, config.GetConfigForClient, timeout)
+		default:
+			return fmt.Errorf("unknown handshake side: %s", side)
+		}
+		return nil
+	}
+}
+
+type handshakeObserver struct {
+	side    HandshakeSide
+	logger  *slog.Logger
+	sampler HandshakeSampler
+	hooks   []HandshakeHook
+}
+
+// wrapVerifyConnection observes the verification of a connection.
+func (observer *handshakeObserver) wrapVerifyConnection(verifyConnection func(tls.ConnectionState) error) func(tls.ConnectionState) error {
+	return func(state tls.ConnectionState) error {
+		var err error
+		if verifyConnection != nil {
+			err = verifyConnection(state)
+		}
+		observer.observe(newHandshakeEvent(observer.side, &state, err))
+		return err
+	}
+}
+
+// wrapGetConfigForClient tracks every server side handshake by handing out a dedicated config
+// per handshake.
+func (observer *handshakeObserver) wrapGetConfigForClient(baseConfig *tls.Config, baseVerifyConnection func(tls.ConnectionState) error, getConfigForClient func(*tls.ClientHelloInfo) (*tls.Config, error), timeout time.Duration) func(*tls.ClientHelloInfo) (*tls.Config, error) {
+	return func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		config := baseConfig
+		verifyConnection := baseVerifyConnection
+		if getConfigForClient != nil {
+			clientConfig, err := getConfigForClient(hello)
+			if err != nil {
+				observer.observe(&HandshakeEvent{Side: observer.side, ServerName: hello.ServerName, Err: err})
+				return nil, err
+			}
+			if clientConfig != nil {
+				config = clientConfig
+				verifyConnection = clientConfig.VerifyConnection
+			}
+		}
+		config = config.Clone()
+		var timer *time.Timer
+		if timeout > 0 {
+			timer = time.AfterFunc(timeout, func() {
+				hello.Conn.Close()
+			})
+		}
+		observed := &atomic.Bool{}
+		context.AfterFunc(hello.Context(), func() {
+			if timer != nil {
+				timer.Stop()
+			}
+			if observed.CompareAndSwap(false, true) {
+				observer.observe(&HandshakeEvent{Side: observer.side, ServerName: hello.ServerName, Err: ErrHandshakeAborted})
+			}
+		})
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			if !observed.CompareAndSwap(false, true) {
+				return ErrHandshakeAborted
+			}
+			return observer.wrapVerifyConnection(verifyConnection)(state)
+		}
+		return config, nil
+	}
+}
+
+func (observer *handshakeObserver) observe(event *HandshakeEvent) {
+	for _, hook := range observer.hooks {
+		hook(event)
+	}
+	if observer.sampler != nil && !observer.sampler.Sample(event) {
+		return
+	}
+	if event.Err != nil {
+		observer.logger.LogAttrs(context.Background(), slog.LevelWarn, "TLS handshake failed", event.LogAttrs()...)
+	} else {
+		observer.logger.LogAttrs(context.Background(), slog.LevelInfo, "TLS handshake", event.LogAttrs()...)
+	}
+}
@@ -0,0 +1,227 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf_test
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf"
+)
+
+func TestEnableHandshakeLogging(t *testing.T) {
+	serverConfig, clientConfig := newHandshakeTestConfigs(t)
+	serverLog := &syncBuffer{}
+	serverEvents := make(chan *tlsconf.HandshakeEvent, 1)
+	applyHandshakeLogging(t, serverConfig, tlsconf.EnableHandshakeLogging(tlsconf.HandshakeSideServer, slog.New(slog.NewTextHandler(serverLog, nil)), nil, func(event *tlsconf.HandshakeEvent) {
+		serverEvents <- event
+	}))
+	clientLog := &syncBuffer{}
+	applyHandshakeLogging(t, clientConfig, tlsconf.EnableHandshakeLogging(tlsconf.HandshakeSideClient, slog.New(slog.NewTextHandler(clientLog, nil)), nil))
+
+	clientErr, serverErr := observedHandshake(t, serverConfig, clientConfig)
+	require.NoError(t, clientErr)
+	require.NoError(t, serverErr)
+	serverEvent := <-serverEvents
+	require.NoError(t, serverEvent.Err)
+	require.Equal(t, tlsconf.HandshakeSideServer, serverEvent.Side)
+	require.Equal(t, uint16(tls.VersionTLS13), serverEvent.Version)
+	require.Equal(t, "localhost", serverEvent.ServerName)
+	require.Equal(t, "h2", serverEvent.NegotiatedProtocol)
+	require.Contains(t, serverLog.String(), "msg=\"TLS handshake\" side=server sni=localhost version=\"TLS 1.3\"")
+	require.Contains(t, clientLog.String(), "msg=\"TLS handshake\" side=client sni=localhost version=\"TLS 1.3\"")
+	require.Contains(t, clientLog.String(), "peer=\"CN=localhost\"\n")
+	require.Len(t, serverEvents, 0)
+}
+
+func TestEnableHandshakeLoggingServerCertificateRejected(t *testing.T) {
+	serverConfig, clientConfig := newHandshakeTestConfigs(t)
+	// Request client certificates to have the client verify the server before the server concludes
+	serverConfig.ClientAuth = tls.RequestClientCert
+	clientConfig.RootCAs = x509.NewCertPool()
+	serverLog := &syncBuffer{}
+	serverEvents := make(chan *tlsconf.HandshakeEvent, 1)
+	applyHandshakeLogging(t, serverConfig, tlsconf.EnableHandshakeLogging(tlsconf.HandshakeSideServer, slog.New(slog.NewTextHandler(serverLog, nil)), nil, func(event *tlsconf.HandshakeEvent) {
+		serverEvents <- event
+	}))
+
+	clientErr, serverErr := observedHandshake(t, serverConfig, clientConfig)
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	require.ErrorAs(t, clientErr, &unknownAuthorityErr)
+	require.ErrorContains(t, serverErr, "remote error: tls: bad certificate")
+	serverEvent := <-serverEvents
+	require.ErrorIs(t, serverEvent.Err, tlsconf.ErrHandshakeAborted)
+	require.Equal(t, "localhost", serverEvent.ServerName)
+	require.Contains(t, serverLog.String(), "level=WARN msg=\"TLS handshake failed\" side=server sni=localhost")
+}
+
+func TestEnableHandshakeLoggingClientVerifyConnectionFailure(t *testing.T) {
+	serverConfig, clientConfig := newHandshakeTestConfigs(t)
+	rejectErr := errors.New("rejected")
+	clientConfig.VerifyConnection = func(tls.ConnectionState) error {
+		return rejectErr
+	}
+	clientLog := &syncBuffer{}
+	clientEvents := make(chan *tlsconf.HandshakeEvent, 1)
+	applyHandshakeLogging(t, clientConfig, tlsconf.EnableHandshakeLogging(tlsconf.HandshakeSideClient, slog.New(slog.NewTextHandler(clientLog, nil)), nil, func(event *tlsconf.HandshakeEvent) {
+		clientEvents <- event
+	}))
+
+	clientErr, _ := observedHandshake(t, serverConfig, clientConfig)
+	require.ErrorIs(t, clientErr, rejectErr)
+	clientEvent := <-clientEvents
+	require.ErrorIs(t, clientEvent.Err, rejectErr)
+	require.Equal(t, tlsconf.HandshakeSideClient, clientEvent.Side)
+	require.Contains(t, clientLog.String(), "level=WARN msg=\"TLS handshake failed\" side=client sni=localhost")
+	require.Contains(t, clientLog.String(), "err=rejected\n")
+}
+
+func TestEnableHandshakeLoggingGetConfigForClientFailure(t *testing.T) {
+	serverConfig, clientConfig := newHandshakeTestConfigs(t)
+	rejectErr := errors.New("rejected")
+	serverConfig.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		return nil, rejectErr
+	}
+	serverLog := &syncBuffer{}
+	serverEvents := make(chan *tlsconf.HandshakeEvent, 2)
+	applyHandshakeLogging(t, serverConfig, tlsconf.EnableHandshakeLogging(tlsconf.HandshakeSideServer, slog.New(slog.NewTextHandler(serverLog, nil)), nil, func(event *tlsconf.HandshakeEvent) {
+		serverEvents <- event
+	}))
+
+	clientErr, serverErr := observedHandshake(t, serverConfig, clientConfig)
+	require.Error(t, clientErr)
+	require.ErrorIs(t, serverErr, rejectErr)
+	serverEvent := <-serverEvents
+	require.ErrorIs(t, serverEvent.Err, rejectErr)
+	require.Contains(t, serverLog.String(), "level=WARN msg=\"TLS handshake failed\" side=server sni=localhost")
+	require.Contains(t, serverLog.String(), "err=rejected\n")
+	require.Len(t, serverEvents, 0)
+}
+
+func TestEnableHandshakeLoggingWithTimeout(t *testing.T) {
+	serverConfig, clientConfig := newHandshakeTestConfigs(t)
+	serverEvents := make(chan *tlsconf.HandshakeEvent, 1)
+	applyHandshakeLogging(t, serverConfig, tlsconf.EnableHandshakeLoggingWithTimeout(tlsconf.HandshakeSideServer, 100*time.Millisecond, slog.New(slog.NewTextHandler(io.Discard, nil)), nil, func(event *tlsconf.HandshakeEvent) {
+		serverEvents <- event
+	}))
+	// Stall the client after it has received the server's flight and before it has sent its certificate
+	serverConfig.ClientAuth = tls.RequestClientCert
+	clientConfig.VerifyConnection = func(tls.ConnectionState) error {
+		time.Sleep(time.Second)
+		return nil
+	}
+
+	start := time.Now()
+	_, serverErr := observedHandshake(t, serverConfig, clientConfig)
+	require.Error(t, serverErr)
+	serverEvent := <-serverEvents
+	require.ErrorIs(t, serverEvent.Err, tlsconf.ErrHandshakeAborted)
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestEnableHandshakeLoggingInvalid(t *testing.T) {
+	config := &tls.Config{}
+	err := tlsconf.EnableHandshakeLoggingWithTimeout(tlsconf.HandshakeSideServer, -time.Second, nil, nil)(config)
+	require.Error(t, err)
+	err = tlsconf.EnableHandshakeLogging(tlsconf.HandshakeSide("unknown"), nil, nil)(config)
+	require.Error(t, err)
+}
+
+func TestHandshakeSampler(t *testing.T) {
+	sampler, err := tlsconf.NewHandshakeSampler(time.Hour, 2, 3)
+	require.NoError(t, err)
+	success := &tlsconf.HandshakeEvent{}
+	failure := &tlsconf.HandshakeEvent{Err: errors.New("failure")}
+	sampled := make([]bool, 0)
+	for range 8 {
+		sampled = append(sampled, sampler.Sample(success))
+	}
+	require.Equal(t, []bool{true, true, false, false, true, false, false, true}, sampled)
+	require.True(t, sampler.Sample(failure))
+	require.True(t, sampler.Sample(failure))
+	require.False(t, sampler.Sample(failure))
+	_, err = tlsconf.NewHandshakeSampler(0, 2, 3)
+	require.Error(t, err)
+	_, err = tlsconf.NewHandshakeSampler(-time.Second, 2, 3)
+	require.Error(t, err)
+	_, err = tlsconf.NewHandshakeSampler(time.Hour, -1, 3)
+	require.Error(t, err)
+}
+
+func applyHandshakeLogging(t *testing.T, config *tls.Config, option tlsconf.TLSConfigOption) {
+	require.NoError(t, option(config))
+}
+
+// observedHandshake runs a handshake via a local TCP connection and returns the client's
+// and the server's handshake error.
+func observedHandshake(t *testing.T, serverConfig *tls.Config, clientConfig *tls.Config) (error, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	serverErrs := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			serverErrs <- err
+			return
+		}
+		defer conn.Close()
+		tlsConn := tls.Server(conn, serverConfig)
+		serverErrs <- tlsConn.HandshakeContext(t.Context())
+		io.Copy(io.Discard, tlsConn)
+	}()
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	tlsConn := tls.Client(conn, clientConfig)
+	clientErr := tlsConn.HandshakeContext(t.Context())
+	tlsConn.Close()
+	return clientErr, <-serverErrs
+}
+
+// syncBuffer is a [bytes.Buffer] safe for concurrent use.
+type syncBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (buffer *syncBuffer) Write(p []byte) (int, error) {
+	buffer.mutex.Lock()
+	defer buffer.mutex.Unlock()
+	return buffer.buffer.Write(p)
+}
+
+func (buffer *syncBuffer) String() string {
+	buffer.mutex.Lock()
+	defer buffer.mutex.Unlock()
+	return buffer.buffer.String()
+}
+
+func newHandshakeTestConfigs(t *testing.T) (*tls.Config, *tls.Config) {
+	certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	serverConfig := &tls.Config{
+		Certificates: []tls.Certificate{*certificate},
+		NextProtos:   []string{"h2"},
+	}
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(certificate.Leaf)
+	clientConfig := &tls.Config{
+		RootCAs:    rootCAs,
+		ServerName: "localhost",
+		NextProtos: []string{"h2"},
+	}
+	return serverConfig, clientConfig
+}
@@ -0,0 +1,111 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// KeyLogFileEnv defines the environment variable evaluated by [EnableKeyLogFile].
+const KeyLogFileEnv = "SSLKEYLOGFILE"
+
+// EnableKeyLogFile sets the KeyLogWriter attribute to write the TLS master secrets
+// to the given key log file (NSS key log format).
+//
+// If keyLogFile is empty, the file name is taken from the environment variable
+// [KeyLogFileEnv]. If neither is set, this option has no effect. As the key log file
+// allows decryption of all TLS traffic, the option refuses to enable itself unless
+// debug is true. The key log file is created with mode 0600 and opened in append mode.
+// Existing key log files must be regular files not accessible by group or others.
+// An opened key log file is shared by all configs using it and re-opened, if it has been
+// rotated or deleted in the meantime.
+func EnableKeyLogFile(debug bool, keyLogFile string) TLSConfigOption {
+	return func(config *tls.Config) error {
+		file := keyLogFile
+		if file == "" {
+			file = os.Getenv(KeyLogFileEnv)
+			if file == "" {
+				return nil
+			}
+		}
+		if !debug {
+			slog.Warn("TLS key log file requested without debug flag; ignoring key log file", slog.String("file", file))
+			return nil
+		}
+		keyLogWriter, err := openKeyLogFile(file)
+		if err != nil {
+			return err
+		}
+		slog.Warn("!!! TLS KEY LOGGING ENABLED !!! all TLS traffic of this process can be decrypted using the key log file; never use this setting in production", slog.String("file", keyLogWriter.Name()))
+		config.KeyLogWriter = keyLogWriter
+		return nil
+	}
+}
+
+var keyLogFiles map[string]*os.File = make(map[string]*os.File)
+var keyLogFilesLock sync.Mutex = sync.Mutex{}
+
+// openKeyLogFile opens the given key log file or returns the already opened one.
+//
+// Already opened files are re-checked on every call, hence a key log file which has been rotated,
+// deleted or made accessible by group or others in the meantime is not re-used.
+func openKeyLogFile(keyLogFile string) (*os.File, error) {
+	absKeyLogFile, err := filepath.Abs(keyLogFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve key log file '%s' (cause: %w)", keyLogFile, err)
+	}
+	keyLogFilesLock.Lock()
+	defer keyLogFilesLock.Unlock()
+	keyLogWriter := keyLogFiles[absKeyLogFile]
+	if keyLogWriter != nil {
+		if checkKeyLogFile(keyLogWriter, absKeyLogFile) == nil {
+			return keyLogWriter, nil
+		}
+		// the file is still referenced by the configs it has been set up for, hence it is not closed
+		delete(keyLogFiles, absKeyLogFile)
+	}
+	keyLogWriter, err = os.OpenFile(absKeyLogFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE|keyLogOpenNoFollow, fs.FileMode(0600))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open key log file '%s' (cause: %w)", absKeyLogFile, err)
+	}
+	err = checkKeyLogFile(keyLogWriter, absKeyLogFile)
+	if err != nil {
+		keyLogWriter.Close()
+		return nil, err
+	}
+	keyLogFiles[absKeyLogFile] = keyLogWriter
+	return keyLogWriter, nil
+}
+
+// checkKeyLogFile checks whether the given opened key log file is a regular file not accessible
+// by group or others and is still accessible via the given path.
+func checkKeyLogFile(keyLogWriter *os.File, absKeyLogFile string) error {
+	fileInfo, err := keyLogWriter.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat key log file '%s' (cause: %w)", absKeyLogFile, err)
+	}
+	if !fileInfo.Mode().IsRegular() {
+		return fmt.Errorf("key log file '%s' is not a regular file", absKeyLogFile)
+	}
+	if fileInfo.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("key log file '%s' is accessible by group or others (mode: %s)", absKeyLogFile, fileInfo.Mode().Perm())
+	}
+	pathInfo, err := os.Lstat(absKeyLogFile)
+	if err != nil {
+		return fmt.Errorf("failed to stat key log file '%s' (cause: %w)", absKeyLogFile, err)
+	}
+	if !os.SameFile(fileInfo, pathInfo) {
+		return fmt.Errorf("key log file '%s' has been replaced", absKeyLogFile)
+	}
+	return nil
+}
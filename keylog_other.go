@@ -0,0 +1,12 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+//go:build !unix
+
+package tlsconf
+
+// keyLogOpenNoFollow is not supported on this platform.
+const keyLogOpenNoFollow = 0
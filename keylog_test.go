@@ -0,0 +1,139 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf_test
+
+import (
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf"
+	"github.com/tdrn-org/go-tlsconf/tlstest"
+)
+
+func TestEnableKeyLogFile(t *testing.T) {
+	keyLogFile := filepath.Join(t.TempDir(), "keylog.txt")
+	serverConfig, clientConfig := newHandshakeTestConfigs(t)
+	err := tlsconf.EnableKeyLogFile(true, keyLogFile)(clientConfig)
+	require.NoError(t, err)
+	require.NotNil(t, clientConfig.KeyLogWriter)
+
+	result := tlstest.Handshake(t.Context(), clientConfig, serverConfig, "")
+	require.NoError(t, result.Err())
+	fileInfo, err := os.Stat(keyLogFile)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), fileInfo.Mode().Perm())
+	keyLog, err := os.ReadFile(keyLogFile)
+	require.NoError(t, err)
+	require.Contains(t, string(keyLog), "CLIENT_HANDSHAKE_TRAFFIC_SECRET ")
+}
+
+func TestEnableKeyLogFileFromEnv(t *testing.T) {
+	keyLogFile := filepath.Join(t.TempDir(), "keylog.txt")
+	t.Setenv(tlsconf.KeyLogFileEnv, keyLogFile)
+	config := &tls.Config{}
+	err := tlsconf.EnableKeyLogFile(true, "")(config)
+	require.NoError(t, err)
+	require.NotNil(t, config.KeyLogWriter)
+	require.FileExists(t, keyLogFile)
+}
+
+func TestEnableKeyLogFileWithoutDebug(t *testing.T) {
+	keyLogFile := filepath.Join(t.TempDir(), "keylog.txt")
+	config := &tls.Config{}
+	err := tlsconf.EnableKeyLogFile(false, keyLogFile)(config)
+	require.NoError(t, err)
+	require.Nil(t, config.KeyLogWriter)
+	require.NoFileExists(t, keyLogFile)
+}
+
+func TestEnableKeyLogFileUnsafeMode(t *testing.T) {
+	keyLogFile := filepath.Join(t.TempDir(), "keylog.txt")
+	err := os.WriteFile(keyLogFile, []byte{}, 0644)
+	require.NoError(t, err)
+	err = os.Chmod(keyLogFile, 0644)
+	require.NoError(t, err)
+	config := &tls.Config{}
+	err = tlsconf.EnableKeyLogFile(true, keyLogFile)(config)
+	require.Error(t, err)
+	require.Nil(t, config.KeyLogWriter)
+}
+
+func TestEnableKeyLogFileSymlink(t *testing.T) {
+	dir := t.TempDir()
+	targetFile := filepath.Join(dir, "target.txt")
+	err := os.WriteFile(targetFile, []byte{}, 0600)
+	require.NoError(t, err)
+	keyLogFile := filepath.Join(dir, "keylog.txt")
+	err = os.Symlink(targetFile, keyLogFile)
+	require.NoError(t, err)
+	config := &tls.Config{}
+	err = tlsconf.EnableKeyLogFile(true, keyLogFile)(config)
+	require.Error(t, err)
+	require.Nil(t, config.KeyLogWriter)
+}
+
+func TestEnableKeyLogFileFromEnvChanged(t *testing.T) {
+	option := tlsconf.EnableKeyLogFile(true, "")
+	keyLogFile1 := filepath.Join(t.TempDir(), "keylog1.txt")
+	t.Setenv(tlsconf.KeyLogFileEnv, keyLogFile1)
+	config1 := &tls.Config{}
+	err := option(config1)
+	require.NoError(t, err)
+	keyLogFile2 := filepath.Join(t.TempDir(), "keylog2.txt")
+	t.Setenv(tlsconf.KeyLogFileEnv, keyLogFile2)
+	config2 := &tls.Config{}
+	err = option(config2)
+	require.NoError(t, err)
+	require.Equal(t, keyLogFile1, config1.KeyLogWriter.(*os.File).Name())
+	require.Equal(t, keyLogFile2, config2.KeyLogWriter.(*os.File).Name())
+}
+
+func TestEnableKeyLogFileRotated(t *testing.T) {
+	keyLogFile := filepath.Join(t.TempDir(), "keylog.txt")
+	option := tlsconf.EnableKeyLogFile(true, keyLogFile)
+	config1 := &tls.Config{}
+	err := option(config1)
+	require.NoError(t, err)
+	config2 := &tls.Config{}
+	err = option(config2)
+	require.NoError(t, err)
+	require.Same(t, config1.KeyLogWriter, config2.KeyLogWriter)
+
+	err = os.Rename(keyLogFile, keyLogFile+".1")
+	require.NoError(t, err)
+	config3 := &tls.Config{}
+	err = option(config3)
+	require.NoError(t, err)
+	require.NotSame(t, config1.KeyLogWriter, config3.KeyLogWriter)
+	require.FileExists(t, keyLogFile)
+
+	err = os.Remove(keyLogFile)
+	require.NoError(t, err)
+	config4 := &tls.Config{}
+	err = option(config4)
+	require.NoError(t, err)
+	require.NotSame(t, config3.KeyLogWriter, config4.KeyLogWriter)
+	require.FileExists(t, keyLogFile)
+}
+
+func TestEnableKeyLogFileUnsafeModeChanged(t *testing.T) {
+	keyLogFile := filepath.Join(t.TempDir(), "keylog.txt")
+	option := tlsconf.EnableKeyLogFile(true, keyLogFile)
+	config1 := &tls.Config{}
+	err := option(config1)
+	require.NoError(t, err)
+
+	err = os.Chmod(keyLogFile, 0644)
+	require.NoError(t, err)
+	config2 := &tls.Config{}
+	err = option(config2)
+	require.Error(t, err)
+	require.Nil(t, config2.KeyLogWriter)
+}
@@ -0,0 +1,14 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+//go:build unix
+
+package tlsconf
+
+import "syscall"
+
+// keyLogOpenNoFollow prevents opening a key log file via a symbolic link.
+const keyLogOpenNoFollow = syscall.O_NOFOLLOW
@@ -0,0 +1,266 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"slices"
+	"sync"
+)
+
+var keyPoolAlgorithms = []CertificateAlgorithm{
+	CertificateAlgorithmDefault,
+	CertificateAlgorithmRSA2048,
+	CertificateAlgorithmRSA3072,
+	CertificateAlgorithmRSA4096,
+	CertificateAlgorithmRSA8192,
+	CertificateAlgorithmECDSA224,
+	CertificateAlgorithmECDSA256,
+	CertificateAlgorithmECDSA384,
+	CertificateAlgorithmECDSA521,
+	CertificateAlgorithmED25519,
+}
+
+// KeyPool pre-generates key pairs for a single [CertificateAlgorithm] in the background.
+//
+// The pool keeps up to a bounded number of key pairs available. Whenever a key pair is taken
+// from the pool, a background worker generates a replacement. If the pool is empty, key pairs
+// are generated on demand.
+type KeyPool struct {
+	algorithm CertificateAlgorithm
+	keys      chan *keyPair
+	filled    chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	mutex     sync.Mutex
+	stop      chan struct{} // guarded by mutex; nil while paused
+	workers   sync.WaitGroup
+}
+
+type keyPair struct {
+	publicKey  crypto.PublicKey
+	privateKey crypto.PrivateKey
+}
+
+// NewKeyPool creates a new [KeyPool] for the given algorithm keeping up to size key pairs.
+//
+// The pool immediately starts generating key pairs using up to [runtime.GOMAXPROCS] workers.
+// The pool must be closed, if it is no longer needed.
+func NewKeyPool(algorithm CertificateAlgorithm, size int) (*KeyPool, error) {
+	pool, err := newKeyPool(algorithm, size)
+	if err != nil {
+		return nil, err
+	}
+	pool.resume()
+	return pool, nil
+}
+
+func newKeyPool(algorithm CertificateAlgorithm, size int) (*KeyPool, error) {
+	if !slices.Contains(keyPoolAlgorithms, algorithm) {
+		return nil, fmt.Errorf("unknown certificate algorithm: %s", algorithm)
+	}
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid key pool size: %d", size)
+	}
+	return &KeyPool{
+		algorithm: algorithm,
+		keys:      make(chan *keyPair, size),
+		filled:    make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}, nil
+}
+
+// resume starts the pool's background workers (if not yet running).
+func (pool *KeyPool) resume() {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	if pool.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	pool.stop = stop
+	for range min(cap(pool.keys), runtime.GOMAXPROCS(0)) {
+		pool.workers.Go(func() {
+			pool.generate(stop)
+		})
+	}
+}
+
+// pause stops the pool's background workers and waits until running key generations are completed.
+func (pool *KeyPool) pause() {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	if pool.stop == nil {
+		return
+	}
+	close(pool.stop)
+	pool.stop = nil
+	pool.workers.Wait()
+}
+
+func (pool *KeyPool) generate(stop chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-pool.done:
+			return
+		default:
+		}
+		publicKey, privateKey, err := pool.algorithm.generateCertificateKey()
+		if err != nil {
+			slog.Error("key pool worker failed", slog.String("algorithm", string(pool.algorithm)), slog.Any("err", err))
+			return
+		}
+		select {
+		case pool.keys <- &keyPair{publicKey: publicKey, privateKey: privateKey}:
+			select {
+			case pool.filled <- struct{}{}:
+			default:
+			}
+		case <-stop:
+			return
+		case <-pool.done:
+			return
+		}
+	}
+}
+
+// Algorithm gets the algorithm of the key pairs provided by this pool.
+func (pool *KeyPool) Algorithm() CertificateAlgorithm {
+	return pool.algorithm
+}
+
+// Len gets the number of currently available key pairs.
+func (pool *KeyPool) Len() int {
+	return len(pool.keys)
+}
+
+// Cap gets the maximum number of key pairs kept by this pool.
+func (pool *KeyPool) Cap() int {
+	return cap(pool.keys)
+}
+
+// Get takes a key pair from the pool.
+//
+// If the pool is empty, a new key pair is generated on demand.
+func (pool *KeyPool) Get() (crypto.PublicKey, crypto.PrivateKey, error) {
+	select {
+	case key := <-pool.keys:
+		return key.publicKey, key.privateKey, nil
+	default:
+	}
+	return pool.algorithm.generateCertificateKey()
+}
+
+// Warm blocks until the pool is filled up completely or the given context is done.
+//
+// While deterministic mode is enabled (see [EnableDeterministicMode]), the background workers
+// of enabled pools are paused, hence Warm blocks until deterministic mode is disabled again.
+func (pool *KeyPool) Warm(ctx context.Context) error {
+	select {
+	case <-pool.done:
+		return fmt.Errorf("key pool closed")
+	default:
+	}
+	for len(pool.keys) < cap(pool.keys) {
+		select {
+		case <-pool.filled:
+		case <-pool.done:
+			return fmt.Errorf("key pool closed")
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Close stops the pool's background workers.
+//
+// Close does not wait for running key generations to complete. Already generated key pairs
+// remain available via [KeyPool.Get].
+func (pool *KeyPool) Close() {
+	pool.closeOnce.Do(func() {
+		close(pool.done)
+	})
+}
+
+var keyPools map[CertificateAlgorithm]*KeyPool = make(map[CertificateAlgorithm]*KeyPool)
+var keyPoolsLock sync.RWMutex = sync.RWMutex{}
+
+// EnableKeyPool creates a [KeyPool] for the given algorithm and size and uses it for all
+// subsequent key generations via [CertificateAlgorithm.GenerateCertificateKey].
+//
+// A previously enabled pool for the same algorithm is closed. Pools are registered per algorithm
+// value, hence [CertificateAlgorithmDefault] and [CertificateAlgorithmECDSA256] use different pools.
+// To speed up tests, a pool can be enabled and warmed up in the test package's init function.
+// While deterministic mode is enabled (see [EnableDeterministicMode]), the background workers
+// of all enabled pools are paused.
+func EnableKeyPool(algorithm CertificateAlgorithm, size int) (*KeyPool, error) {
+	pool, err := newKeyPool(algorithm, size)
+	if err != nil {
+		return nil, err
+	}
+	keyPoolsLock.Lock()
+	previousPool := keyPools[algorithm]
+	keyPools[algorithm] = pool
+	if !isDeterministic() {
+		pool.resume()
+	}
+	keyPoolsLock.Unlock()
+	if previousPool != nil {
+		previousPool.pause()
+		previousPool.Close()
+	}
+	return pool, nil
+}
+
+// DisableKeyPool closes and removes a previously enabled [KeyPool] for the given algorithm.
+//
+// Other than [KeyPool.Close], DisableKeyPool waits for running key generations to complete.
+func DisableKeyPool(algorithm CertificateAlgorithm) {
+	keyPoolsLock.Lock()
+	pool := keyPools[algorithm]
+	delete(keyPools, algorithm)
+	keyPoolsLock.Unlock()
+	if pool != nil {
+		pool.pause()
+		pool.Close()
+	}
+}
+
+func lookupKeyPool(algorithm CertificateAlgorithm) *KeyPool {
+	keyPoolsLock.RLock()
+	defer keyPoolsLock.RUnlock()
+	return keyPools[algorithm]
+}
+
+// pauseKeyPools pauses the background workers of all enabled pools.
+func pauseKeyPools() {
+	keyPoolsLock.RLock()
+	defer keyPoolsLock.RUnlock()
+	for _, pool := range keyPools {
+		pool.pause()
+	}
+}
+
+// resumeKeyPools resumes the background workers of all enabled pools, unless deterministic
+// mode is enabled.
+func resumeKeyPools() {
+	keyPoolsLock.RLock()
+	defer keyPoolsLock.RUnlock()
+	if isDeterministic() {
+		return
+	}
+	for _, pool := range keyPools {
+		pool.resume()
+	}
+}
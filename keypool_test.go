@@ -0,0 +1,91 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf"
+)
+
+func TestKeyPool(t *testing.T) {
+	pool, err := tlsconf.NewKeyPool(tlsconf.CertificateAlgorithmECDSA256, 4)
+	require.NoError(t, err)
+	defer pool.Close()
+	require.Equal(t, tlsconf.CertificateAlgorithmECDSA256, pool.Algorithm())
+	require.Equal(t, 4, pool.Cap())
+	err = pool.Warm(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, 4, pool.Len())
+	for range 2 * pool.Cap() {
+		publicKey, privateKey, err := pool.Get()
+		require.NoError(t, err)
+		require.IsType(t, &ecdsa.PublicKey{}, publicKey)
+		require.IsType(t, &ecdsa.PrivateKey{}, privateKey)
+	}
+	pool.Close()
+	_, _, err = pool.Get()
+	require.NoError(t, err)
+}
+
+func TestKeyPoolWarmTimeout(t *testing.T) {
+	pool, err := tlsconf.NewKeyPool(tlsconf.CertificateAlgorithmRSA8192, 1)
+	require.NoError(t, err)
+	defer pool.Close()
+	ctx, cancel := context.WithTimeout(t.Context(), time.Millisecond)
+	defer cancel()
+	err = pool.Warm(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestKeyPoolInvalid(t *testing.T) {
+	_, err := tlsconf.NewKeyPool("unknown", 1)
+	require.Error(t, err)
+	_, err = tlsconf.NewKeyPool(tlsconf.CertificateAlgorithmDefault, 0)
+	require.Error(t, err)
+}
+
+func TestEnableKeyPool(t *testing.T) {
+	pool, err := tlsconf.EnableKeyPool(tlsconf.CertificateAlgorithmRSA2048, 2)
+	require.NoError(t, err)
+	defer tlsconf.DisableKeyPool(tlsconf.CertificateAlgorithmRSA2048)
+	err = pool.Warm(t.Context())
+	require.NoError(t, err)
+	certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmRSA2048, time.Hour)
+	require.NoError(t, err)
+	require.IsType(t, &rsa.PrivateKey{}, certificate.PrivateKey)
+	replacement, err := tlsconf.EnableKeyPool(tlsconf.CertificateAlgorithmRSA2048, 1)
+	require.NoError(t, err)
+	err = pool.Warm(t.Context())
+	require.Error(t, err)
+	err = replacement.Warm(t.Context())
+	require.NoError(t, err)
+}
+
+func BenchmarkGenerateCertificateKeyUnpooled(b *testing.B) {
+	for b.Loop() {
+		_, _, err := tlsconf.CertificateAlgorithmRSA4096.GenerateCertificateKey()
+		require.NoError(b, err)
+	}
+}
+
+func BenchmarkGenerateCertificateKeyPooled(b *testing.B) {
+	pool, err := tlsconf.EnableKeyPool(tlsconf.CertificateAlgorithmRSA4096, 16)
+	require.NoError(b, err)
+	defer tlsconf.DisableKeyPool(tlsconf.CertificateAlgorithmRSA4096)
+	err = pool.Warm(b.Context())
+	require.NoError(b, err)
+	for b.Loop() {
+		_, _, err := tlsconf.CertificateAlgorithmRSA4096.GenerateCertificateKey()
+		require.NoError(b, err)
+	}
+}
@@ -0,0 +1,322 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf
+
+import (
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// KeyStore provides access to named certificates and their private keys.
+type KeyStore interface {
+	// Put stores the given certificate (including its chain and private key) under the given name.
+	// An already existing entry with the same name is replaced.
+	Put(name string, certificate *tls.Certificate) error
+	// Get retrieves the certificate stored under the given name. If no such entry exists,
+	// an error wrapping [ErrKeyStoreEntryNotFound] is returned.
+	Get(name string) (*tls.Certificate, error)
+	// List returns the names of all stored entries in lexical order.
+	List() ([]string, error)
+	// Delete removes the entry stored under the given name. Deleting a non-existing entry is
+	// not an error.
+	Delete(name string) error
+}
+
+// ErrKeyStoreEntryNotFound indicates a non-existing [KeyStore] entry.
+var ErrKeyStoreEntryNotFound = fmt.Errorf("key store entry not found (%w)", fs.ErrNotExist)
+
+// ErrKeyStoreReadOnly indicates a modifying operation on a read-only [KeyStore].
+var ErrKeyStoreReadOnly = errors.New("key store is read-only")
+
+// KeyNotFoundError indicates a [KeyStore] entry stored without private key (e.g. because the
+// private key is not exportable, see [IsExportableKey]). The entry's certificate chain is
+// still available and can be combined with the matching signer via [NewSignerCertificate]
+// (see also [NewSignerResolvingKeyStore]).
+type KeyNotFoundError struct {
+	// Name is the name of the key store entry.
+	Name string
+	// Chain is the certificate chain of the key store entry.
+	Chain [][]byte
+}
+
+func (err *KeyNotFoundError) Error() string {
+	return fmt.Sprintf("private key of key store entry '%s' not found", err.Name)
+}
+
+func checkKeyStoreEntryName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid key store entry name: '%s'", name)
+	}
+	return nil
+}
+
+const (
+	keyStoreCertSuffix = ".crt"
+	keyStoreKeySuffix  = ".key"
+)
+
+// NewDirKeyStore creates a [KeyStore] backed by the given directory.
+//
+// Every entry is stored in a certificate and key file pair as written by [WriteCertificate].
+// The given options are applied when writing entries. Entries with a non-exportable private key
+// are stored without key file and their retrieval fails with a [KeyNotFoundError].
+func NewDirKeyStore(dir string, options ...WriteOption) KeyStore {
+	return &dirKeyStore{
+		dir:     dir,
+		options: options,
+	}
+}
+
+// NewEncryptedDirKeyStore creates a [KeyStore] backed by the given directory encrypting all
+// private keys.
+//
+// Every entry is stored in a certificate and key file pair as written by [WriteEncryptedCertificate].
+// The given options are applied when writing entries.
+func NewEncryptedDirKeyStore(dir string, passphrase PassphraseFunc, encryption KeyEncryption, options ...WriteOption) KeyStore {
+	return &dirKeyStore{
+		dir:        dir,
+		passphrase: passphrase,
+		encryption: encryption,
+		options:    options,
+	}
+}
+
+type dirKeyStore struct {
+	dir        string
+	passphrase PassphraseFunc
+	encryption KeyEncryption
+	options    []WriteOption
+}
+
+func (store *dirKeyStore) Put(name string, certificate *tls.Certificate) error {
+	err := checkKeyStoreEntryName(name)
+	if err != nil {
+		return err
+	}
+	if store.passphrase != nil {
+		_, _, err = WriteEncryptedCertificate(certificate, store.dir, name, store.passphrase, store.encryption, store.options...)
+	} else {
+		_, _, err = WriteCertificate(certificate, store.dir, name, store.options...)
+	}
+	return err
+}
+
+func (store *dirKeyStore) Get(name string) (*tls.Certificate, error) {
+	err := checkKeyStoreEntryName(name)
+	if err != nil {
+		return nil, err
+	}
+	certFile := filepath.Join(store.dir, name+keyStoreCertSuffix)
+	keyFile := filepath.Join(store.dir, name+keyStoreKeySuffix)
+	return readKeyStoreEntry(os.ReadFile, name, certFile, keyFile, store.passphrase)
+}
+
+func (store *dirKeyStore) List() ([]string, error) {
+	return listKeyStoreEntries(os.DirFS(store.dir), ".")
+}
+
+func (store *dirKeyStore) Delete(name string) error {
+	err := checkKeyStoreEntryName(name)
+	if err != nil {
+		return err
+	}
+	return deleteFileSet(store.dir, name, []string{
+		filepath.Join(store.dir, name+keyStoreKeySuffix),
+		filepath.Join(store.dir, name+keyStoreCertSuffix),
+	})
+}
+
+// readKeyStoreEntry reads and decodes a certificate and key file pair. A missing key file
+// (as written for non-exportable keys) results in a [KeyNotFoundError].
+func readKeyStoreEntry(readFile func(string) ([]byte, error), name, certFile, keyFile string, passphrase PassphraseFunc) (*tls.Certificate, error) {
+	certData, err := readFile(certFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: '%s'", ErrKeyStoreEntryNotFound, name)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read certificate file '%s' (cause: %w)", certFile, err)
+	}
+	keyData, err := readFile(keyFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		certificates, err := DecodeCertificates(certData, DecodeStrict)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode certificate file '%s' (cause: %w)", certFile, err)
+		}
+		chain := make([][]byte, 0, len(certificates))
+		for _, certificate := range certificates {
+			chain = append(chain, certificate.Raw)
+		}
+		return nil, &KeyNotFoundError{Name: name, Chain: chain}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read key file '%s' (cause: %w)", keyFile, err)
+	}
+	certificate, err := DecodeCertificate(certData, keyData, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate from '%s' and '%s' (cause: %w)", certFile, keyFile, err)
+	}
+	return certificate, nil
+}
+
+func listKeyStoreEntries(fsys fs.FS, dir string) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key store directory '%s' (cause: %w)", dir, err)
+	}
+	names := make([]string, 0)
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), keyStoreCertSuffix)
+		if ok && !entry.IsDir() && !strings.HasPrefix(name, ".") {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// NewMemoryKeyStore creates a [KeyStore] keeping all entries in memory.
+//
+// This store is primarily meant for testing purposes.
+func NewMemoryKeyStore() KeyStore {
+	return &memoryKeyStore{
+		entries: make(map[string]*tls.Certificate),
+	}
+}
+
+type memoryKeyStore struct {
+	mutex   sync.RWMutex
+	entries map[string]*tls.Certificate
+}
+
+func (store *memoryKeyStore) Put(name string, certificate *tls.Certificate) error {
+	err := checkKeyStoreEntryName(name)
+	if err != nil {
+		return err
+	}
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	storedCertificate := *certificate
+	storedCertificate.Certificate = slices.Clone(certificate.Certificate)
+	store.entries[name] = &storedCertificate
+	return nil
+}
+
+func (store *memoryKeyStore) Get(name string) (*tls.Certificate, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	storedCertificate, ok := store.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrKeyStoreEntryNotFound, name)
+	}
+	certificate := *storedCertificate
+	certificate.Certificate = slices.Clone(storedCertificate.Certificate)
+	return &certificate, nil
+}
+
+func (store *memoryKeyStore) List() ([]string, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	names := make([]string, 0, len(store.entries))
+	for name := range store.entries {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+func (store *memoryKeyStore) Delete(name string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	delete(store.entries, name)
+	return nil
+}
+
+// NewFSKeyStore creates a read-only [KeyStore] backed by the given directory of the given [fs.FS]
+// (e.g. an [embed.FS]).
+//
+// The directory must use the same layout as the [KeyStore] created by [NewDirKeyStore]. The given
+// passphrase function is used to decrypt encrypted private keys and may be nil. Put and Delete
+// fail with [ErrKeyStoreReadOnly].
+func NewFSKeyStore(fsys fs.FS, dir string, passphrase PassphraseFunc) KeyStore {
+	return &fsKeyStore{
+		fsys:       fsys,
+		dir:        dir,
+		passphrase: passphrase,
+	}
+}
+
+type fsKeyStore struct {
+	fsys       fs.FS
+	dir        string
+	passphrase PassphraseFunc
+}
+
+func (store *fsKeyStore) Put(_ string, _ *tls.Certificate) error {
+	return ErrKeyStoreReadOnly
+}
+
+func (store *fsKeyStore) Get(name string) (*tls.Certificate, error) {
+	err := checkKeyStoreEntryName(name)
+	if err != nil {
+		return nil, err
+	}
+	certFile := path.Join(store.dir, name+keyStoreCertSuffix)
+	keyFile := path.Join(store.dir, name+keyStoreKeySuffix)
+	return readKeyStoreEntry(func(file string) ([]byte, error) {
+		return fs.ReadFile(store.fsys, file)
+	}, name, certFile, keyFile, store.passphrase)
+}
+
+func (store *fsKeyStore) List() ([]string, error) {
+	return listKeyStoreEntries(store.fsys, store.dir)
+}
+
+func (store *fsKeyStore) Delete(_ string) error {
+	return ErrKeyStoreReadOnly
+}
+
+// SignerResolver functions resolve the signer for a [KeyStore] entry stored without private key.
+type SignerResolver func(name string, certificate *x509.Certificate) (crypto.Signer, error)
+
+// NewSignerResolvingKeyStore creates a [KeyStore] wrapping the given one and resolving the
+// signer of entries stored without private key (see [KeyNotFoundError]) via the given
+// [SignerResolver].
+func NewSignerResolvingKeyStore(store KeyStore, resolver SignerResolver) KeyStore {
+	return &signerResolvingKeyStore{
+		KeyStore: store,
+		resolver: resolver,
+	}
+}
+
+type signerResolvingKeyStore struct {
+	KeyStore
+	resolver SignerResolver
+}
+
+func (store *signerResolvingKeyStore) Get(name string) (*tls.Certificate, error) {
+	certificate, err := store.KeyStore.Get(name)
+	var keyNotFoundErr *KeyNotFoundError
+	if !errors.As(err, &keyNotFoundErr) {
+		return certificate, err
+	}
+	leaf, err := x509.ParseCertificate(keyNotFoundErr.Chain[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate of key store entry '%s' (cause: %w)", name, err)
+	}
+	signer, err := store.resolver(name, leaf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve signer of key store entry '%s' (cause: %w)", name, err)
+	}
+	return NewSignerCertificate(keyNotFoundErr.Chain, signer)
+}
@@ -0,0 +1,123 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf_test
+
+import (
+	"crypto"
+	"crypto/x509"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf"
+)
+
+func TestDirKeyStore(t *testing.T) {
+	signer := newOpaqueSigner(t, tlsconf.CertificateAlgorithmDefault)
+	testKeyStore(t, tlsconf.NewSignerResolvingKeyStore(tlsconf.NewDirKeyStore(t.TempDir()), staticSignerResolver(signer)), signer)
+}
+
+func TestEncryptedDirKeyStore(t *testing.T) {
+	signer := newOpaqueSigner(t, tlsconf.CertificateAlgorithmDefault)
+	store := tlsconf.NewEncryptedDirKeyStore(t.TempDir(), tlsconf.StaticPassphrase("secret"), tlsconf.KeyEncryptionScryptAES256GCM)
+	testKeyStore(t, tlsconf.NewSignerResolvingKeyStore(store, staticSignerResolver(signer)), signer)
+}
+
+func TestMemoryKeyStore(t *testing.T) {
+	testKeyStore(t, tlsconf.NewMemoryKeyStore(), newOpaqueSigner(t, tlsconf.CertificateAlgorithmDefault))
+}
+
+func TestDirKeyStoreSignerEntry(t *testing.T) {
+	dir := t.TempDir()
+	certificate, err := tlsconf.GenerateEphemeralSignerCertificate("localhost", newOpaqueSigner(t, tlsconf.CertificateAlgorithmDefault), time.Hour)
+	require.NoError(t, err)
+	err = tlsconf.NewDirKeyStore(dir).Put("localhost", certificate)
+	require.NoError(t, err)
+	for _, store := range []tlsconf.KeyStore{tlsconf.NewDirKeyStore(dir), tlsconf.NewFSKeyStore(os.DirFS(dir), ".", nil)} {
+		_, err = store.Get("localhost")
+		var keyNotFoundErr *tlsconf.KeyNotFoundError
+		require.ErrorAs(t, err, &keyNotFoundErr)
+		require.Equal(t, "localhost", keyNotFoundErr.Name)
+		require.Equal(t, certificate.Certificate, keyNotFoundErr.Chain)
+	}
+	_, err = tlsconf.NewSignerResolvingKeyStore(tlsconf.NewDirKeyStore(dir), staticSignerResolver(newOpaqueSigner(t, tlsconf.CertificateAlgorithmDefault))).Get("localhost")
+	require.Error(t, err)
+}
+
+func staticSignerResolver(signer crypto.Signer) tlsconf.SignerResolver {
+	return func(_ string, _ *x509.Certificate) (crypto.Signer, error) {
+		return signer, nil
+	}
+}
+
+func TestFSKeyStore(t *testing.T) {
+	dir := t.TempDir()
+	certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	err = tlsconf.NewDirKeyStore(dir).Put("localhost", certificate)
+	require.NoError(t, err)
+
+	store := tlsconf.NewFSKeyStore(os.DirFS(dir), ".", nil)
+	names, err := store.List()
+	require.NoError(t, err)
+	require.Equal(t, []string{"localhost"}, names)
+	storedCertificate, err := store.Get("localhost")
+	require.NoError(t, err)
+	require.Equal(t, certificate, storedCertificate)
+	_, err = store.Get("unknown")
+	require.ErrorIs(t, err, tlsconf.ErrKeyStoreEntryNotFound)
+	err = store.Put("localhost", certificate)
+	require.ErrorIs(t, err, tlsconf.ErrKeyStoreReadOnly)
+	err = store.Delete("localhost")
+	require.ErrorIs(t, err, tlsconf.ErrKeyStoreReadOnly)
+}
+
+func testKeyStore(t *testing.T, store tlsconf.KeyStore, signer crypto.Signer) {
+	certificate1, err := tlsconf.GenerateEphemeralCertificate("host1", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	certificate2, err := tlsconf.GenerateEphemeralCertificate("host2", tlsconf.CertificateAlgorithmED25519, time.Hour)
+	require.NoError(t, err)
+	signerCertificate, err := tlsconf.GenerateEphemeralSignerCertificate("signer", signer, time.Hour)
+	require.NoError(t, err)
+
+	names, err := store.List()
+	require.NoError(t, err)
+	require.Empty(t, names)
+	err = store.Put("host2", certificate2)
+	require.NoError(t, err)
+	err = store.Put("host1", certificate1)
+	require.NoError(t, err)
+	err = store.Put("../host", certificate1)
+	require.Error(t, err)
+	names, err = store.List()
+	require.NoError(t, err)
+	require.Equal(t, []string{"host1", "host2"}, names)
+	storedCertificate, err := store.Get("host1")
+	require.NoError(t, err)
+	require.Equal(t, certificate1, storedCertificate)
+	_, err = store.Get("host3")
+	require.ErrorIs(t, err, tlsconf.ErrKeyStoreEntryNotFound)
+	err = store.Delete("host1")
+	require.NoError(t, err)
+	err = store.Delete("host1")
+	require.NoError(t, err)
+	names, err = store.List()
+	require.NoError(t, err)
+	require.Equal(t, []string{"host2"}, names)
+
+	err = store.Put("signer", signerCertificate)
+	require.NoError(t, err)
+	storedCertificate, err = store.Get("signer")
+	require.NoError(t, err)
+	require.Equal(t, signerCertificate, storedCertificate)
+	err = store.Delete("signer")
+	require.NoError(t, err)
+	names, err = store.List()
+	require.NoError(t, err)
+	require.Equal(t, []string{"host2"}, names)
+}
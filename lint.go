@@ -0,0 +1,211 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// LintSeverity defines the severity of a [LintFinding].
+type LintSeverity int
+
+const (
+	LintSeverityInfo    LintSeverity = iota // Informational finding
+	LintSeverityWarning                     // Risky setting, which should be reviewed
+	LintSeverityError                       // Insecure setting, which must be fixed
+)
+
+func (severity LintSeverity) String() string {
+	switch severity {
+	case LintSeverityInfo:
+		return "info"
+	case LintSeverityWarning:
+		return "warning"
+	case LintSeverityError:
+		return "error"
+	}
+	return fmt.Sprintf("LintSeverity(%d)", int(severity))
+}
+
+// LintID identifies the check reporting a [LintFinding].
+type LintID string
+
+const (
+	LintInsecureSkipVerify  LintID = "insecure-skip-verify"  // Certificate verification disabled without custom verification
+	LintWeakVersion         LintID = "weak-version"          // TLS versions below TLS 1.2 enabled
+	LintInsecureCipherSuite LintID = "insecure-cipher-suite" // Insecure (e.g. 3DES or RC4) cipher suite enabled
+	LintCBCCipherSuite      LintID = "cbc-cipher-suite"      // CBC mode cipher suite enabled
+	LintWeakKey             LintID = "weak-key"              // Certificate key weaker than RSA-2048 or P-256
+	LintInvalidCertificate  LintID = "invalid-certificate"   // Certificate cannot be parsed
+	LintCertificateExpired  LintID = "certificate-expired"   // Certificate expired or not yet valid
+	LintCertificateExpiring LintID = "certificate-expiring"  // Certificate expires within LintExpiryThreshold
+	LintCACertificate       LintID = "ca-certificate"        // End-entity certificate marked as CA
+	LintMissingSAN          LintID = "missing-san"           // End-entity certificate without subject alternative names
+)
+
+// LintExpiryThreshold defines the remaining lifetime below which a certificate is reported
+// as [LintCertificateExpiring].
+const LintExpiryThreshold = 30 * 24 * time.Hour
+
+// LintFinding describes a risky setting detected by [Lint].
+type LintFinding struct {
+	ID       LintID
+	Severity LintSeverity
+	Message  string
+}
+
+func (finding *LintFinding) String() string {
+	return fmt.Sprintf("%s [%s]: %s", finding.Severity, finding.ID, finding.Message)
+}
+
+// LintFindings contains the findings reported by [Lint].
+type LintFindings []*LintFinding
+
+// MaxSeverity gets the highest severity of all findings or -1, if there are no findings.
+func (findings LintFindings) MaxSeverity() LintSeverity {
+	maxSeverity := LintSeverity(-1)
+	for _, finding := range findings {
+		maxSeverity = max(maxSeverity, finding.Severity)
+	}
+	return maxSeverity
+}
+
+// Err gets an error combining all findings with the given severity or above or nil,
+// if there are no such findings.
+//
+// This allows a CI check to fail on relevant findings only.
+func (findings LintFindings) Err(severity LintSeverity) error {
+	var errs []error
+	for _, finding := range findings {
+		if finding.Severity >= severity {
+			errs = append(errs, errors.New(finding.String()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Lint checks the given [tls.Config] (e.g. as set up via a package's SetOptions function)
+// for risky settings.
+//
+// Only the static attributes of the config are evaluated. The certificates in the Certificates
+// attribute are checked as end-entity certificates (including their chain for key strength
+// and validity).
+func Lint(config *tls.Config) LintFindings {
+	linter := &configLinter{now: time.Now()}
+	linter.lintVerification(config)
+	linter.lintVersions(config)
+	linter.lintCipherSuites(config)
+	for index := range config.Certificates {
+		linter.lintCertificate(&config.Certificates[index])
+	}
+	return linter.findings
+}
+
+type configLinter struct {
+	now      time.Time
+	findings LintFindings
+}
+
+func (linter *configLinter) report(id LintID, severity LintSeverity, format string, args ...any) {
+	linter.findings = append(linter.findings, &LintFinding{
+		ID:       id,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (linter *configLinter) lintVerification(config *tls.Config) {
+	if config.InsecureSkipVerify && config.VerifyPeerCertificate == nil && config.VerifyConnection == nil {
+		linter.report(LintInsecureSkipVerify, LintSeverityError, "certificate verification is disabled without custom verification function")
+	}
+}
+
+func (linter *configLinter) lintVersions(config *tls.Config) {
+	if config.MinVersion != 0 && config.MinVersion < tls.VersionTLS12 {
+		linter.report(LintWeakVersion, LintSeverityError, "minimum TLS version %s is below TLS 1.2", tls.VersionName(config.MinVersion))
+	}
+	if config.MaxVersion != 0 && config.MaxVersion < tls.VersionTLS12 {
+		linter.report(LintWeakVersion, LintSeverityError, "maximum TLS version %s is below TLS 1.2", tls.VersionName(config.MaxVersion))
+	}
+}
+
+func (linter *configLinter) lintCipherSuites(config *tls.Config) {
+	insecureCipherSuites := tls.InsecureCipherSuites()
+	for _, cipherSuite := range config.CipherSuites {
+		name := tls.CipherSuiteName(cipherSuite)
+		if slices.ContainsFunc(insecureCipherSuites, func(insecureCipherSuite *tls.CipherSuite) bool {
+			return insecureCipherSuite.ID == cipherSuite
+		}) {
+			linter.report(LintInsecureCipherSuite, LintSeverityError, "insecure cipher suite %s is enabled", name)
+		} else if strings.Contains(name, "_CBC_") {
+			linter.report(LintCBCCipherSuite, LintSeverityWarning, "CBC mode cipher suite %s is enabled", name)
+		}
+	}
+}
+
+func (linter *configLinter) lintCertificate(certificate *tls.Certificate) {
+	chain := make([]*x509.Certificate, 0, len(certificate.Certificate))
+	for index, certificateBytes := range certificate.Certificate {
+		if index == 0 && certificate.Leaf != nil {
+			chain = append(chain, certificate.Leaf)
+			continue
+		}
+		parsed, err := x509.ParseCertificate(certificateBytes)
+		if err != nil {
+			linter.report(LintInvalidCertificate, LintSeverityError, "failed to parse certificate (cause: %v)", err)
+			return
+		}
+		chain = append(chain, parsed)
+	}
+	if len(chain) == 0 {
+		return
+	}
+	leaf := chain[0]
+	if leaf.IsCA {
+		linter.report(LintCACertificate, LintSeverityWarning, "certificate '%s' is marked as CA", leaf.Subject)
+	}
+	if len(leaf.DNSNames) == 0 && len(leaf.IPAddresses) == 0 && len(leaf.URIs) == 0 && len(leaf.EmailAddresses) == 0 {
+		linter.report(LintMissingSAN, LintSeverityWarning, "certificate '%s' has no subject alternative names", leaf.Subject)
+	}
+	for _, chainCertificate := range chain {
+		linter.lintCertificateKey(chainCertificate)
+		linter.lintCertificateValidity(chainCertificate)
+	}
+}
+
+func (linter *configLinter) lintCertificateKey(certificate *x509.Certificate) {
+	switch publicKey := certificate.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if publicKey.N.BitLen() < 2048 {
+			linter.report(LintWeakKey, LintSeverityError, "certificate '%s' uses a %d bit RSA key", certificate.Subject, publicKey.N.BitLen())
+		}
+	case *ecdsa.PublicKey:
+		if publicKey.Curve == elliptic.P224() {
+			linter.report(LintWeakKey, LintSeverityError, "certificate '%s' uses a P-224 ECDSA key", certificate.Subject)
+		}
+	}
+}
+
+func (linter *configLinter) lintCertificateValidity(certificate *x509.Certificate) {
+	switch {
+	case linter.now.After(certificate.NotAfter):
+		linter.report(LintCertificateExpired, LintSeverityError, "certificate '%s' expired at %s", certificate.Subject, certificate.NotAfter.Format(time.RFC3339))
+	case linter.now.Before(certificate.NotBefore):
+		linter.report(LintCertificateExpired, LintSeverityError, "certificate '%s' is not valid before %s", certificate.Subject, certificate.NotBefore.Format(time.RFC3339))
+	case certificate.NotAfter.Sub(linter.now) < LintExpiryThreshold:
+		linter.report(LintCertificateExpiring, LintSeverityWarning, "certificate '%s' expires at %s", certificate.Subject, certificate.NotAfter.Format(time.RFC3339))
+	}
+}
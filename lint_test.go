@@ -0,0 +1,99 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf"
+	"github.com/tdrn-org/go-tlsconf/tlstest"
+)
+
+func TestLintClean(t *testing.T) {
+	ca, err := tlsconf.NewLocalCA(tlsconf.NewMemoryKeyStore(), "ca", tlsconf.CertificateAlgorithmDefault, 365*24*time.Hour)
+	require.NoError(t, err)
+	certificate, err := ca.IssueServerCertificate("localhost", tlsconf.CertificateAlgorithmDefault, 90*24*time.Hour)
+	require.NoError(t, err)
+	findings := tlsconf.Lint(&tls.Config{
+		Certificates: []tls.Certificate{*certificate},
+		MinVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+	})
+	require.Empty(t, findings)
+	require.Equal(t, tlsconf.LintSeverity(-1), findings.MaxSeverity())
+	require.NoError(t, findings.Err(tlsconf.LintSeverityInfo))
+}
+
+func TestLintConfig(t *testing.T) {
+	findings := tlsconf.Lint(&tls.Config{
+		InsecureSkipVerify: true,
+		MinVersion:         tls.VersionTLS10,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+			tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
+		},
+	})
+	requireLintFindings(t, findings, map[tlsconf.LintID]tlsconf.LintSeverity{
+		tlsconf.LintInsecureSkipVerify:  tlsconf.LintSeverityError,
+		tlsconf.LintWeakVersion:         tlsconf.LintSeverityError,
+		tlsconf.LintCBCCipherSuite:      tlsconf.LintSeverityWarning,
+		tlsconf.LintInsecureCipherSuite: tlsconf.LintSeverityError,
+	})
+	require.Equal(t, tlsconf.LintSeverityError, findings.MaxSeverity())
+	err := findings.Err(tlsconf.LintSeverityError)
+	require.ErrorContains(t, err, "error [insecure-skip-verify]: certificate verification is disabled")
+	require.NotContains(t, err.Error(), "cbc-cipher-suite")
+
+	findings = tlsconf.Lint(&tls.Config{
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func([][]byte, [][]*x509.Certificate) error {
+			return nil
+		},
+	})
+	require.Empty(t, findings)
+}
+
+func TestLintCertificates(t *testing.T) {
+	factory := tlstest.NewBrokenCertificateFactory(t, tlsconf.CertificateAlgorithmDefault)
+	weakKey := factory.Generate(tlstest.BrokenWeakKey, "localhost")
+	expired := factory.Generate(tlstest.BrokenExpired, "localhost")
+	ca, err := tlsconf.NewLocalCA(tlsconf.NewMemoryKeyStore(), "ca", tlsconf.CertificateAlgorithmDefault, 365*24*time.Hour)
+	require.NoError(t, err)
+	caCertificate := tls.Certificate{Certificate: [][]byte{ca.Certificate().Raw}}
+	p224Certificate, err := ca.IssueClientCertificate("client", tlsconf.CertificateAlgorithmECDSA224, time.Hour)
+	require.NoError(t, err)
+
+	requireLintFindings(t, tlsconf.Lint(&tls.Config{Certificates: []tls.Certificate{*weakKey.Certificate}}), map[tlsconf.LintID]tlsconf.LintSeverity{
+		tlsconf.LintWeakKey:             tlsconf.LintSeverityError,
+		tlsconf.LintCertificateExpiring: tlsconf.LintSeverityWarning,
+	})
+	requireLintFindings(t, tlsconf.Lint(&tls.Config{Certificates: []tls.Certificate{*expired.Certificate}}), map[tlsconf.LintID]tlsconf.LintSeverity{
+		tlsconf.LintCertificateExpired: tlsconf.LintSeverityError,
+	})
+	requireLintFindings(t, tlsconf.Lint(&tls.Config{Certificates: []tls.Certificate{caCertificate}}), map[tlsconf.LintID]tlsconf.LintSeverity{
+		tlsconf.LintCACertificate: tlsconf.LintSeverityWarning,
+		tlsconf.LintMissingSAN:    tlsconf.LintSeverityWarning,
+	})
+	requireLintFindings(t, tlsconf.Lint(&tls.Config{Certificates: []tls.Certificate{*p224Certificate}}), map[tlsconf.LintID]tlsconf.LintSeverity{
+		tlsconf.LintWeakKey:             tlsconf.LintSeverityError,
+		tlsconf.LintMissingSAN:          tlsconf.LintSeverityWarning,
+		tlsconf.LintCertificateExpiring: tlsconf.LintSeverityWarning,
+	})
+}
+
+func requireLintFindings(t *testing.T, findings tlsconf.LintFindings, expected map[tlsconf.LintID]tlsconf.LintSeverity) {
+	actual := make(map[tlsconf.LintID]tlsconf.LintSeverity)
+	for _, finding := range findings {
+		actual[finding.ID] = finding.Severity
+	}
+	require.Equal(t, expected, actual, "findings: %v", findings)
+}
@@ -0,0 +1,132 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf
+
+import (
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// PKCS12Encryption defines the supported PKCS#12 encryption schemes.
+type PKCS12Encryption string
+
+const (
+	PKCS12EncryptionModern PKCS12Encryption = "modern" // AES-256-CBC with PBKDF2 and SHA-256 based MAC
+	PKCS12EncryptionLegacy PKCS12Encryption = "legacy" // 3DES with SHA-1 based MAC (for older Java and Windows versions)
+)
+
+func (encryption PKCS12Encryption) encoder() (*pkcs12.Encoder, error) {
+	switch encryption {
+	case PKCS12EncryptionModern:
+		return pkcs12.Modern, nil
+	case PKCS12EncryptionLegacy:
+		return pkcs12.Legacy, nil
+	}
+	return nil, fmt.Errorf("unknown PKCS#12 encryption: %s", encryption)
+}
+
+// DecodePKCS12 decodes the given PKCS#12 archive into a [tls.Certificate].
+//
+// The archive must contain exactly one private key and its certificate. The private key must
+// match the certificate's public key. Any additional certificates are added to the resulting
+// certificate chain.
+func DecodePKCS12(pfxData []byte, password string) (*tls.Certificate, error) {
+	privateKey, certificate, caCertificates, err := pkcs12.DecodeChain(pfxData, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode PKCS#12 archive (cause: %w)", err)
+	}
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok || !publicKeysEqual(certificate.PublicKey, signer.Public()) {
+		return nil, fmt.Errorf("failed to decode PKCS#12 archive (cause: private key does not match certificate public key)")
+	}
+	chain := make([][]byte, 0, 1+len(caCertificates))
+	chain = append(chain, certificate.Raw)
+	for _, caCertificate := range caCertificates {
+		chain = append(chain, caCertificate.Raw)
+	}
+	return &tls.Certificate{
+		Certificate: chain,
+		PrivateKey:  privateKey,
+		Leaf:        certificate,
+	}, nil
+}
+
+// LoadPKCS12 loads the given PKCS#12 file into a [tls.Certificate].
+//
+// See [DecodePKCS12] for details.
+func LoadPKCS12(pfxFile, password string) (*tls.Certificate, error) {
+	pfxData, err := os.ReadFile(pfxFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PKCS#12 file '%s' (cause: %w)", pfxFile, err)
+	}
+	certificate, err := DecodePKCS12(pfxData, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load PKCS#12 file '%s' (cause: %w)", pfxFile, err)
+	}
+	return certificate, nil
+}
+
+// EncodePKCS12 encodes the given certificate including its chain and private key
+// into a PKCS#12 archive.
+func EncodePKCS12(certificate *tls.Certificate, password string, encryption PKCS12Encryption) ([]byte, error) {
+	encoder, err := encryption.encoder()
+	if err != nil {
+		return nil, err
+	}
+	if !IsExportableKey(certificate.PrivateKey) {
+		return nil, fmt.Errorf("failed to encode PKCS#12 archive (cause: private key not exportable)")
+	}
+	if len(certificate.Certificate) == 0 {
+		return nil, fmt.Errorf("failed to encode PKCS#12 archive (cause: empty certificate chain)")
+	}
+	chain := make([]*x509.Certificate, 0, len(certificate.Certificate))
+	for _, certificateBytes := range certificate.Certificate {
+		x509Certificate, err := x509.ParseCertificate(certificateBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse X.509 certificate (cause: %w)", err)
+		}
+		chain = append(chain, x509Certificate)
+	}
+	pfxData, err := encoder.Encode(certificate.PrivateKey, chain[0], chain[1:], password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode PKCS#12 archive (cause: %w)", err)
+	}
+	return pfxData, nil
+}
+
+// WritePKCS12 writes the given certificate to the given directory using the given name.
+//
+// A successfull write will create the PKCS#12 file (<dir>/<name>.p12) containing the
+// full certificate chain as well as the private key. The file is replaced atomically using the
+// key file mode (see [WriteOption] for the available write options).
+func WritePKCS12(certificate *tls.Certificate, dir, name, password string, encryption PKCS12Encryption, options ...WriteOption) (string, error) {
+	pfxFile := filepath.Join(dir, name+".p12")
+	err := WritePKCS12File(certificate, pfxFile, password, encryption, options...)
+	if err != nil {
+		return "", err
+	}
+	return pfxFile, nil
+}
+
+// WritePKCS12File writes the given certificate to the given PKCS#12 file.
+//
+// The file is replaced atomically using the key file mode (see [WriteOption] for the available
+// write options).
+func WritePKCS12File(certificate *tls.Certificate, pfxFile, password string, encryption PKCS12Encryption, options ...WriteOption) error {
+	pfxData, err := EncodePKCS12(certificate, password, encryption)
+	if err != nil {
+		return err
+	}
+	writeOptions := newWriteOptions(options)
+	return writeFile(&fileWrite{path: pfxFile, data: pfxData, mode: writeOptions.keyMode}, writeOptions)
+}
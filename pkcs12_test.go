@@ -0,0 +1,60 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf"
+)
+
+func TestWritePKCS12(t *testing.T) {
+	encryptions := []tlsconf.PKCS12Encryption{
+		tlsconf.PKCS12EncryptionModern,
+		tlsconf.PKCS12EncryptionLegacy,
+	}
+	certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	for _, encryption := range encryptions {
+		t.Run(string(encryption), func(t *testing.T) {
+			pfxFile, err := tlsconf.WritePKCS12(certificate, t.TempDir(), "test", "secret", encryption)
+			require.NoError(t, err)
+			reloadedCertificate, err := tlsconf.LoadPKCS12(pfxFile, "secret")
+			require.NoError(t, err)
+			require.Equal(t, certificate, reloadedCertificate)
+			_, err = tlsconf.LoadPKCS12(pfxFile, "wrong")
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestEncodePKCS12WithChain(t *testing.T) {
+	certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	caCertificate, err := tlsconf.GenerateEphemeralCertificate("ca", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	certificate.Certificate = append(certificate.Certificate, caCertificate.Certificate[0])
+	pfxData, err := tlsconf.EncodePKCS12(certificate, "secret", tlsconf.PKCS12EncryptionModern)
+	require.NoError(t, err)
+	decodedCertificate, err := tlsconf.DecodePKCS12(pfxData, "secret")
+	require.NoError(t, err)
+	require.Equal(t, certificate.Certificate, decodedCertificate.Certificate)
+}
+
+func TestDecodePKCS12KeyMismatch(t *testing.T) {
+	certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	otherCertificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	certificate.PrivateKey = otherCertificate.PrivateKey
+	pfxData, err := tlsconf.EncodePKCS12(certificate, "secret", tlsconf.PKCS12EncryptionModern)
+	require.NoError(t, err)
+	_, err = tlsconf.DecodePKCS12(pfxData, "secret")
+	require.ErrorContains(t, err, "private key does not match certificate public key")
+}
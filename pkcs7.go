@@ -0,0 +1,93 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+)
+
+var oidPKCS7Data = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+var oidPKCS7SignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	ContentInfo      pkcs7ContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      asn1.RawValue
+}
+
+// ErrNotPKCS7 indicates data which is not a PKCS#7 signed data structure.
+var ErrNotPKCS7 = errors.New("not a PKCS#7 signed data structure")
+
+func isPKCS7(data []byte) bool {
+	contentInfo := &pkcs7ContentInfo{}
+	_, err := asn1.Unmarshal(data, contentInfo)
+	return err == nil && contentInfo.ContentType.Equal(oidPKCS7SignedData)
+}
+
+func parsePKCS7Certificates(data []byte) ([]*x509.Certificate, error) {
+	contentInfo := &pkcs7ContentInfo{}
+	rest, err := asn1.Unmarshal(data, contentInfo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode PKCS#7 content info (cause: %w)", err)
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("failed to decode PKCS#7 content info (cause: trailing data)")
+	}
+	if !contentInfo.ContentType.Equal(oidPKCS7SignedData) {
+		return nil, ErrNotPKCS7
+	}
+	signedData := &pkcs7SignedData{}
+	_, err = asn1.Unmarshal(contentInfo.Content.Bytes, signedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode PKCS#7 signed data (cause: %w)", err)
+	}
+	certificates, err := x509.ParseCertificates(signedData.Certificates.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PKCS#7 certificates (cause: %w)", err)
+	}
+	return certificates, nil
+}
+
+func marshalPKCS7Certificates(certificates []*x509.Certificate) ([]byte, error) {
+	encodedCertificates := &bytes.Buffer{}
+	for _, certificate := range certificates {
+		encodedCertificates.Write(certificate.Raw)
+	}
+	emptySet := asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true}
+	signedData := &pkcs7SignedData{
+		Version:          1,
+		DigestAlgorithms: emptySet,
+		ContentInfo:      pkcs7ContentInfo{ContentType: oidPKCS7Data},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: encodedCertificates.Bytes()},
+		SignerInfos:      emptySet,
+	}
+	encodedSignedData, err := asn1.Marshal(*signedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode PKCS#7 signed data (cause: %w)", err)
+	}
+	contentInfo := &pkcs7ContentInfo{
+		ContentType: oidPKCS7SignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: encodedSignedData},
+	}
+	encodedContentInfo, err := asn1.Marshal(*contentInfo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode PKCS#7 content info (cause: %w)", err)
+	}
+	return encodedContentInfo, nil
+}
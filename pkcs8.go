@@ -0,0 +1,482 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// KeyEncryption defines the supported private key encryption schemes.
+//
+// All schemes are PKCS#8 PBES2 schemes (RFC 8018) using the named key derivation
+// function and cipher. Be aware that the GCM based schemes are not supported by OpenSSL.
+type KeyEncryption string
+
+const (
+	KeyEncryptionPBKDF2AES256CBC KeyEncryption = "pbkdf2-aes256-cbc" // PBKDF2 (HMAC-SHA256) with AES-256-CBC
+	KeyEncryptionPBKDF2AES256GCM KeyEncryption = "pbkdf2-aes256-gcm" // PBKDF2 (HMAC-SHA256) with AES-256-GCM
+	KeyEncryptionScryptAES256CBC KeyEncryption = "scrypt-aes256-cbc" // scrypt with AES-256-CBC
+	KeyEncryptionScryptAES256GCM KeyEncryption = "scrypt-aes256-gcm" // scrypt with AES-256-GCM
+)
+
+// KeyEncryptionDefault defines the default private key encryption scheme (as it is
+// the most interoperable one).
+const KeyEncryptionDefault = KeyEncryptionPBKDF2AES256CBC
+
+// PassphraseFunc functions are used to retrieve the passphrase for private key encryption and decryption.
+type PassphraseFunc func() ([]byte, error)
+
+// StaticPassphrase returns a [PassphraseFunc] providing the given passphrase.
+func StaticPassphrase(passphrase string) PassphraseFunc {
+	return func() ([]byte, error) {
+		return []byte(passphrase), nil
+	}
+}
+
+// PassphraseFromEnv returns a [PassphraseFunc] reading the passphrase from the given environment variable.
+func PassphraseFromEnv(name string) PassphraseFunc {
+	return func() ([]byte, error) {
+		passphrase, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, fmt.Errorf("passphrase environment variable '%s' not set", name)
+		}
+		return []byte(passphrase), nil
+	}
+}
+
+// PassphraseFromFile returns a [PassphraseFunc] reading the passphrase from the given file.
+//
+// Only the first line of the file is used as passphrase.
+func PassphraseFromFile(passphraseFile string) PassphraseFunc {
+	return func() ([]byte, error) {
+		passphraseData, err := os.ReadFile(passphraseFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read passphrase file '%s' (cause: %w)", passphraseFile, err)
+		}
+		passphrase, _, _ := strings.Cut(string(passphraseData), "\n")
+		return []byte(strings.TrimSuffix(passphrase, "\r")), nil
+	}
+}
+
+// ErrPassphraseRequired indicates an encrypted private key without a passphrase being provided.
+var ErrPassphraseRequired = errors.New("passphrase required for encrypted private key")
+
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidScrypt         = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11591, 4, 11}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+	oidAES128CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidAES128GCM      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 6}
+	oidAES192GCM      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 26}
+	oidAES256GCM      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 46}
+)
+
+const (
+	pbkdf2Iterations = 600000
+	scryptCost       = 1 << 14 // OpenSSL default (higher values exceed OpenSSL's default memory limit)
+	scryptBlockSize  = 8
+	scryptParallel   = 1
+	keySaltLength    = 16
+	gcmTagLength     = 16
+)
+
+// Upper limits for the key derivation parameters accepted during decryption. They exceed the
+// parameters used for encryption by far, but prevent excessive CPU or memory usage caused by
+// malicious or corrupt key files.
+const (
+	maxPBKDF2Iterations = 10000000
+	maxScryptCost       = 1 << 20
+	maxScryptBlockSize  = 32
+	maxScryptParallel   = 16
+	maxScryptMemory     = 1 << 30 // 128 * N * r bytes
+)
+
+type encryptedPrivateKeyInfo struct {
+	EncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedData       []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+type scryptParams struct {
+	Salt                     []byte
+	CostParameter            int
+	BlockSize                int
+	ParallelizationParameter int
+	KeyLength                int `asn1:"optional"`
+}
+
+type gcmParams struct {
+	Nonce  []byte
+	ICVLen int `asn1:"default:12"`
+}
+
+// EncryptPrivateKey encodes the given private key as a PKCS#8 ENCRYPTED PRIVATE KEY [pem.Block].
+func EncryptPrivateKey(privateKey crypto.PrivateKey, passphrase []byte, encryption KeyEncryption) (*pem.Block, error) {
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key (cause: %w)", err)
+	}
+	salt := make([]byte, keySaltLength)
+	_, err = rand.Read(salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate salt (cause: %w)", err)
+	}
+	var kdf pkix.AlgorithmIdentifier
+	var key []byte
+	switch encryption {
+	case KeyEncryptionPBKDF2AES256CBC, KeyEncryptionPBKDF2AES256GCM:
+		kdf, key, err = newPBKDF2Key(passphrase, salt)
+	case KeyEncryptionScryptAES256CBC, KeyEncryptionScryptAES256GCM:
+		kdf, key, err = newScryptKey(passphrase, salt)
+	default:
+		return nil, fmt.Errorf("unknown key encryption: %s", encryption)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var scheme pkix.AlgorithmIdentifier
+	var encryptedData []byte
+	switch encryption {
+	case KeyEncryptionPBKDF2AES256CBC, KeyEncryptionScryptAES256CBC:
+		scheme, encryptedData, err = encryptAESCBC(key, keyBytes)
+	default:
+		scheme, encryptedData, err = encryptAESGCM(key, keyBytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	params, err := asn1.Marshal(pbes2Params{KeyDerivationFunc: kdf, EncryptionScheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode PBES2 parameters (cause: %w)", err)
+	}
+	encryptedKeyBytes, err := asn1.Marshal(encryptedPrivateKeyInfo{
+		EncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		EncryptedData:       encryptedData,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode encrypted private key (cause: %w)", err)
+	}
+	return &pem.Block{
+		Type:  "ENCRYPTED PRIVATE KEY",
+		Bytes: encryptedKeyBytes,
+	}, nil
+}
+
+func newPBKDF2Key(passphrase, salt []byte) (pkix.AlgorithmIdentifier, []byte, error) {
+	key, err := pbkdf2.Key(sha256.New, string(passphrase), salt, pbkdf2Iterations, 32)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, fmt.Errorf("failed to derive key (cause: %w)", err)
+	}
+	params, err := asn1.Marshal(pbkdf2Params{
+		Salt:           salt,
+		IterationCount: pbkdf2Iterations,
+		PRF:            pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+	})
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, fmt.Errorf("failed to encode PBKDF2 parameters (cause: %w)", err)
+	}
+	return pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: params}}, key, nil
+}
+
+func newScryptKey(passphrase, salt []byte) (pkix.AlgorithmIdentifier, []byte, error) {
+	key, err := scrypt.Key(passphrase, salt, scryptCost, scryptBlockSize, scryptParallel, 32)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, fmt.Errorf("failed to derive key (cause: %w)", err)
+	}
+	params, err := asn1.Marshal(scryptParams{
+		Salt:                     salt,
+		CostParameter:            scryptCost,
+		BlockSize:                scryptBlockSize,
+		ParallelizationParameter: scryptParallel,
+		KeyLength:                32,
+	})
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, fmt.Errorf("failed to encode scrypt parameters (cause: %w)", err)
+	}
+	return pkix.AlgorithmIdentifier{Algorithm: oidScrypt, Parameters: asn1.RawValue{FullBytes: params}}, key, nil
+}
+
+func encryptAESCBC(key, plaintext []byte) (pkix.AlgorithmIdentifier, []byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, fmt.Errorf("failed to create cipher (cause: %w)", err)
+	}
+	iv := make([]byte, aes.BlockSize)
+	_, err = rand.Read(iv)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, fmt.Errorf("failed to generate IV (cause: %w)", err)
+	}
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	paddedPlaintext := make([]byte, len(plaintext), len(plaintext)+padding)
+	copy(paddedPlaintext, plaintext)
+	for range padding {
+		paddedPlaintext = append(paddedPlaintext, byte(padding))
+	}
+	ciphertext := make([]byte, len(paddedPlaintext))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, paddedPlaintext)
+	params, err := asn1.Marshal(iv)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, fmt.Errorf("failed to encode cipher parameters (cause: %w)", err)
+	}
+	return pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: params}}, ciphertext, nil
+}
+
+func encryptAESGCM(key, plaintext []byte) (pkix.AlgorithmIdentifier, []byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, fmt.Errorf("failed to create cipher (cause: %w)", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, fmt.Errorf("failed to create cipher (cause: %w)", err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, fmt.Errorf("failed to generate nonce (cause: %w)", err)
+	}
+	ciphertext := aead.Seal(nil, nonce, plaintext, nil)
+	params, err := asn1.Marshal(gcmParams{Nonce: nonce, ICVLen: gcmTagLength})
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, fmt.Errorf("failed to encode cipher parameters (cause: %w)", err)
+	}
+	return pkix.AlgorithmIdentifier{Algorithm: oidAES256GCM, Parameters: asn1.RawValue{FullBytes: params}}, ciphertext, nil
+}
+
+// DecodePrivateKey decodes the given private key [pem.Block].
+//
+// Plain (PRIVATE KEY, RSA PRIVATE KEY and EC PRIVATE KEY) blocks as well as encrypted blocks
+// are supported. Encrypted blocks are either PKCS#8 ENCRYPTED PRIVATE KEY blocks using one of the
+// PBES2 schemes or legacy OpenSSL blocks (Proc-Type: 4,ENCRYPTED). The passphrase is only
+// retrieved, if the block is encrypted. A nil passphrase causes [ErrPassphraseRequired] for
+// encrypted blocks. PBES2 blocks with excessive key derivation parameters (e.g. more than
+// 10000000 PBKDF2 iterations) are rejected.
+func DecodePrivateKey(keyBlock *pem.Block, passphrase PassphraseFunc) (crypto.PrivateKey, error) {
+	keyBytes := keyBlock.Bytes
+	//lint:ignore SA1019 legacy encryption is only supported for reading existing keys
+	legacyEncrypted := x509.IsEncryptedPEMBlock(keyBlock)
+	if keyBlock.Type == "ENCRYPTED PRIVATE KEY" || legacyEncrypted {
+		if passphrase == nil {
+			return nil, ErrPassphraseRequired
+		}
+		passphraseBytes, err := passphrase()
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve passphrase (cause: %w)", err)
+		}
+		if legacyEncrypted {
+			//lint:ignore SA1019 legacy encryption is only supported for reading existing keys
+			keyBytes, err = x509.DecryptPEMBlock(keyBlock, passphraseBytes)
+		} else {
+			keyBytes, err = decryptPKCS8(keyBytes, passphraseBytes)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt private key (cause: %w)", err)
+		}
+	}
+	return parsePrivateKey(keyBytes)
+}
+
+func parsePrivateKey(keyBytes []byte) (crypto.PrivateKey, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(keyBytes); err == nil {
+		switch key := key.(type) {
+		case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+			return key, nil
+		}
+		return nil, fmt.Errorf("unsupported private key type: %T", key)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(keyBytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(keyBytes); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("failed to parse private key")
+}
+
+func decryptPKCS8(encryptedKeyBytes, passphrase []byte) ([]byte, error) {
+	encryptedKeyInfo := &encryptedPrivateKeyInfo{}
+	_, err := asn1.Unmarshal(encryptedKeyBytes, encryptedKeyInfo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode encrypted private key (cause: %w)", err)
+	}
+	if !encryptedKeyInfo.EncryptionAlgorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("unsupported key encryption algorithm: %s", encryptedKeyInfo.EncryptionAlgorithm.Algorithm)
+	}
+	params := &pbes2Params{}
+	_, err = asn1.Unmarshal(encryptedKeyInfo.EncryptionAlgorithm.Parameters.FullBytes, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode PBES2 parameters (cause: %w)", err)
+	}
+	keyLength, err := aesKeyLength(params.EncryptionScheme.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	key, err := deriveKey(&params.KeyDerivationFunc, passphrase, keyLength)
+	if err != nil {
+		return nil, err
+	}
+	return decryptAES(&params.EncryptionScheme, key, encryptedKeyInfo.EncryptedData)
+}
+
+func aesKeyLength(oid asn1.ObjectIdentifier) (int, error) {
+	switch {
+	case oid.Equal(oidAES128CBC), oid.Equal(oidAES128GCM):
+		return 16, nil
+	case oid.Equal(oidAES192CBC), oid.Equal(oidAES192GCM):
+		return 24, nil
+	case oid.Equal(oidAES256CBC), oid.Equal(oidAES256GCM):
+		return 32, nil
+	}
+	return 0, fmt.Errorf("unsupported key encryption cipher: %s", oid)
+}
+
+func deriveKey(kdf *pkix.AlgorithmIdentifier, passphrase []byte, keyLength int) ([]byte, error) {
+	switch {
+	case kdf.Algorithm.Equal(oidPBKDF2):
+		params := &pbkdf2Params{}
+		_, err := asn1.Unmarshal(kdf.Parameters.FullBytes, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode PBKDF2 parameters (cause: %w)", err)
+		}
+		if params.IterationCount < 1 || params.IterationCount > maxPBKDF2Iterations {
+			return nil, fmt.Errorf("unsupported PBKDF2 iteration count: %d", params.IterationCount)
+		}
+		prf, err := pbkdf2PRF(&params.PRF)
+		if err != nil {
+			return nil, err
+		}
+		return pbkdf2.Key(prf, string(passphrase), params.Salt, params.IterationCount, keyLength)
+	case kdf.Algorithm.Equal(oidScrypt):
+		params := &scryptParams{}
+		_, err := asn1.Unmarshal(kdf.Parameters.FullBytes, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode scrypt parameters (cause: %w)", err)
+		}
+		err = checkScryptParams(params)
+		if err != nil {
+			return nil, err
+		}
+		return scrypt.Key(passphrase, params.Salt, params.CostParameter, params.BlockSize, params.ParallelizationParameter, keyLength)
+	}
+	return nil, fmt.Errorf("unsupported key derivation function: %s", kdf.Algorithm)
+}
+
+func checkScryptParams(params *scryptParams) error {
+	if params.CostParameter < 2 || params.CostParameter > maxScryptCost {
+		return fmt.Errorf("unsupported scrypt cost parameter: %d", params.CostParameter)
+	}
+	if params.BlockSize < 1 || params.BlockSize > maxScryptBlockSize {
+		return fmt.Errorf("unsupported scrypt block size: %d", params.BlockSize)
+	}
+	if params.ParallelizationParameter < 1 || params.ParallelizationParameter > maxScryptParallel {
+		return fmt.Errorf("unsupported scrypt parallelization parameter: %d", params.ParallelizationParameter)
+	}
+	if 128*params.CostParameter*params.BlockSize > maxScryptMemory {
+		return fmt.Errorf("unsupported scrypt parameters (memory limit exceeded: N=%d, r=%d)", params.CostParameter, params.BlockSize)
+	}
+	return nil
+}
+
+func pbkdf2PRF(prf *pkix.AlgorithmIdentifier) (func() hash.Hash, error) {
+	switch {
+	case len(prf.Algorithm) == 0, prf.Algorithm.Equal(oidHMACWithSHA1):
+		return sha1.New, nil
+	case prf.Algorithm.Equal(oidHMACWithSHA256):
+		return sha256.New, nil
+	case prf.Algorithm.Equal(oidHMACWithSHA384):
+		return sha512.New384, nil
+	case prf.Algorithm.Equal(oidHMACWithSHA512):
+		return sha512.New, nil
+	}
+	return nil, fmt.Errorf("unsupported PBKDF2 pseudo random function: %s", prf.Algorithm)
+}
+
+func decryptAES(scheme *pkix.AlgorithmIdentifier, key, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher (cause: %w)", err)
+	}
+	switch {
+	case scheme.Algorithm.Equal(oidAES128CBC), scheme.Algorithm.Equal(oidAES192CBC), scheme.Algorithm.Equal(oidAES256CBC):
+		var iv []byte
+		_, err := asn1.Unmarshal(scheme.Parameters.FullBytes, &iv)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode cipher parameters (cause: %w)", err)
+		}
+		if len(iv) != aes.BlockSize || len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+			return nil, errors.New("invalid cipher parameters")
+		}
+		plaintext := make([]byte, len(ciphertext))
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+		padding := int(plaintext[len(plaintext)-1])
+		if padding == 0 || padding > aes.BlockSize {
+			return nil, errors.New("invalid padding (wrong passphrase?)")
+		}
+		for _, paddingByte := range plaintext[len(plaintext)-padding:] {
+			if int(paddingByte) != padding {
+				return nil, errors.New("invalid padding (wrong passphrase?)")
+			}
+		}
+		return plaintext[:len(plaintext)-padding], nil
+	default:
+		params := &gcmParams{}
+		_, err := asn1.Unmarshal(scheme.Parameters.FullBytes, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode cipher parameters (cause: %w)", err)
+		}
+		aead, err := cipher.NewGCMWithNonceSize(block, len(params.Nonce))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cipher (cause: %w)", err)
+		}
+		if params.ICVLen != aead.Overhead() {
+			return nil, fmt.Errorf("unsupported GCM tag length: %d", params.ICVLen)
+		}
+		plaintext, err := aead.Open(nil, params.Nonce, ciphertext, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt (wrong passphrase?) (cause: %w)", err)
+		}
+		return plaintext, nil
+	}
+}
@@ -0,0 +1,132 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf"
+)
+
+func TestWriteEncryptedCertificate(t *testing.T) {
+	encryptions := []tlsconf.KeyEncryption{
+		tlsconf.KeyEncryptionPBKDF2AES256CBC,
+		tlsconf.KeyEncryptionPBKDF2AES256GCM,
+		tlsconf.KeyEncryptionScryptAES256CBC,
+		tlsconf.KeyEncryptionScryptAES256GCM,
+	}
+	certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	for _, encryption := range encryptions {
+		t.Run(string(encryption), func(t *testing.T) {
+			certFile, keyFile, err := tlsconf.WriteEncryptedCertificate(certificate, t.TempDir(), "test", tlsconf.StaticPassphrase("secret"), encryption)
+			require.NoError(t, err)
+			_, err = tls.LoadX509KeyPair(certFile, keyFile)
+			require.Error(t, err)
+			reloadedCertificate, err := tlsconf.LoadCertificate(certFile, keyFile, tlsconf.StaticPassphrase("secret"))
+			require.NoError(t, err)
+			require.Equal(t, certificate, reloadedCertificate)
+			_, err = tlsconf.LoadCertificate(certFile, keyFile, tlsconf.StaticPassphrase("wrong"))
+			require.Error(t, err)
+			_, err = tlsconf.LoadCertificate(certFile, keyFile, nil)
+			require.ErrorIs(t, err, tlsconf.ErrPassphraseRequired)
+		})
+	}
+}
+
+func TestLoadCertificateUnencrypted(t *testing.T) {
+	certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	certFile, keyFile, err := tlsconf.WriteCertificate(certificate, t.TempDir(), "test")
+	require.NoError(t, err)
+	reloadedCertificate, err := tlsconf.LoadCertificate(certFile, keyFile, nil)
+	require.NoError(t, err)
+	require.Equal(t, certificate, reloadedCertificate)
+}
+
+func TestLoadCertificateLegacyEncrypted(t *testing.T) {
+	certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmRSA2048, time.Hour)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	certFile, keyFile, err := tlsconf.WriteCertificate(certificate, dir, "test")
+	require.NoError(t, err)
+	//lint:ignore SA1019 legacy encryption is required to test legacy decryption
+	keyBlock, err := x509.EncryptPEMBlock(rand.Reader, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(certificate.PrivateKey.(*rsa.PrivateKey)), []byte("secret"), x509.PEMCipherAES256)
+	require.NoError(t, err)
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(keyBlock), 0600)
+	require.NoError(t, err)
+	passphraseFile := filepath.Join(dir, "passphrase")
+	err = os.WriteFile(passphraseFile, []byte("secret\n"), 0600)
+	require.NoError(t, err)
+	reloadedCertificate, err := tlsconf.LoadCertificate(certFile, keyFile, tlsconf.PassphraseFromFile(passphraseFile))
+	require.NoError(t, err)
+	require.Equal(t, certificate.PrivateKey, reloadedCertificate.PrivateKey)
+}
+
+func TestPassphraseFromEnv(t *testing.T) {
+	t.Setenv("TLSCONF_TEST_PASSPHRASE", "secret")
+	passphrase, err := tlsconf.PassphraseFromEnv("TLSCONF_TEST_PASSPHRASE")()
+	require.NoError(t, err)
+	require.Equal(t, []byte("secret"), passphrase)
+	_, err = tlsconf.PassphraseFromEnv("TLSCONF_TEST_PASSPHRASE_UNDEFINED")()
+	require.Error(t, err)
+}
+
+func TestDecodePrivateKeyOpenSSL(t *testing.T) {
+	plainKey, err := tlsconf.DecodePrivateKey(readKeyBlock(t, "testdata/pkcs8/key.pem"), nil)
+	require.NoError(t, err)
+	keyFiles := []string{
+		"pbkdf2-sha1-aes-256-cbc.pem",
+		"pbkdf2-sha256-aes-128-cbc.pem",
+		"pbkdf2-sha256-aes-192-cbc.pem",
+		"pbkdf2-sha256-aes-256-cbc.pem",
+		"pbkdf2-sha512-aes-256-cbc.pem",
+		"scrypt-aes-256-cbc.pem",
+	}
+	for _, keyFile := range keyFiles {
+		t.Run(keyFile, func(t *testing.T) {
+			keyBlock := readKeyBlock(t, filepath.Join("testdata/pkcs8", keyFile))
+			key, err := tlsconf.DecodePrivateKey(keyBlock, tlsconf.StaticPassphrase("secret"))
+			require.NoError(t, err)
+			require.Equal(t, plainKey, key)
+			_, err = tlsconf.DecodePrivateKey(keyBlock, tlsconf.StaticPassphrase("wrong"))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestDecodePrivateKeyExcessiveParameters(t *testing.T) {
+	keyFiles := map[string]string{
+		"pbkdf2-excessive-iterations.pem":      "unsupported PBKDF2 iteration count",
+		"scrypt-excessive-block-size.pem":      "unsupported scrypt block size",
+		"scrypt-excessive-parallelization.pem": "unsupported scrypt parallelization parameter",
+	}
+	for keyFile, expectedErr := range keyFiles {
+		t.Run(keyFile, func(t *testing.T) {
+			keyBlock := readKeyBlock(t, filepath.Join("testdata/pkcs8", keyFile))
+			_, err := tlsconf.DecodePrivateKey(keyBlock, tlsconf.StaticPassphrase("secret"))
+			require.ErrorContains(t, err, expectedErr)
+		})
+	}
+}
+
+func readKeyBlock(t *testing.T, keyFile string) *pem.Block {
+	keyData, err := os.ReadFile(keyFile)
+	require.NoError(t, err)
+	keyBlock, _ := pem.Decode(keyData)
+	require.NotNil(t, keyBlock)
+	return keyBlock
+}
@@ -0,0 +1,243 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// PolicyRule identifies the rule of a [Policy] violated by a setting.
+type PolicyRule string
+
+const (
+	PolicyRuleCertificateAlgorithm PolicyRule = "certificate-algorithm" // Certificate key algorithm not approved
+	PolicyRuleVersion              PolicyRule = "version"               // TLS version below approved minimum version
+	PolicyRuleCipherSuite          PolicyRule = "cipher-suite"          // Cipher suite not approved
+	PolicyRuleCurve                PolicyRule = "curve"                 // Curve not approved
+)
+
+// PolicyViolationError is returned, if a setting violates a [Policy].
+type PolicyViolationError struct {
+	Policy string
+	Rule   PolicyRule
+	Value  string
+}
+
+func (err *PolicyViolationError) Error() string {
+	return fmt.Sprintf("policy '%s' violated: %s '%s' not approved", err.Policy, err.Rule, err.Value)
+}
+
+// Policy restricts the algorithms used for certificate generation and TLS configuration
+// to an approved set.
+//
+// Empty attributes do not restrict the corresponding setting. [CertificateAlgorithmDefault]
+// and [CertificateAlgorithmECDSA256] are treated as the same algorithm.
+type Policy struct {
+	// Name is used to identify the policy in error messages.
+	Name string
+	// CertificateAlgorithms contains the approved certificate key algorithms.
+	CertificateAlgorithms []CertificateAlgorithm
+	// MinVersion defines the minimum approved TLS version.
+	MinVersion uint16
+	// CipherSuites contains the approved TLS 1.0-1.2 cipher suites.
+	CipherSuites []uint16
+	// Curves contains the approved key exchange curves.
+	Curves []tls.CurveID
+}
+
+// CheckCertificateAlgorithm checks whether the given certificate algorithm is approved.
+func (policy *Policy) CheckCertificateAlgorithm(algorithm CertificateAlgorithm) error {
+	if len(policy.CertificateAlgorithms) == 0 {
+		return nil
+	}
+	if !slices.ContainsFunc(policy.CertificateAlgorithms, func(approved CertificateAlgorithm) bool {
+		return normalizeCertificateAlgorithm(approved) == normalizeCertificateAlgorithm(algorithm)
+	}) {
+		return policy.violation(PolicyRuleCertificateAlgorithm, string(algorithm))
+	}
+	return nil
+}
+
+// CheckPublicKey checks whether the given certificate public key has been generated using an
+// approved certificate algorithm.
+func (policy *Policy) CheckPublicKey(publicKey crypto.PublicKey) error {
+	if len(policy.CertificateAlgorithms) == 0 {
+		return nil
+	}
+	algorithm, ok := publicKeyCertificateAlgorithm(publicKey)
+	if !ok {
+		return policy.violation(PolicyRuleCertificateAlgorithm, fmt.Sprintf("%T", publicKey))
+	}
+	return policy.CheckCertificateAlgorithm(algorithm)
+}
+
+// Enforce restricts the given [tls.Config] to the approved settings.
+//
+// Unset attributes (MinVersion, CipherSuites, CurvePreferences) are set to the approved values.
+// Explicitly set attributes as well as the certificates in the Certificates attribute are
+// checked and a [PolicyViolationError] is returned for the first unapproved setting.
+func (policy *Policy) Enforce(config *tls.Config) error {
+	if policy.MinVersion != 0 {
+		if config.MinVersion == 0 && policy.MinVersion > tls.VersionTLS12 {
+			config.MinVersion = policy.MinVersion
+		} else if config.MinVersion != 0 && config.MinVersion < policy.MinVersion {
+			return policy.violation(PolicyRuleVersion, tls.VersionName(config.MinVersion))
+		}
+		if config.MaxVersion != 0 && config.MaxVersion < policy.MinVersion {
+			return policy.violation(PolicyRuleVersion, tls.VersionName(config.MaxVersion))
+		}
+	}
+	if len(policy.CipherSuites) > 0 {
+		if config.CipherSuites == nil {
+			config.CipherSuites = slices.Clone(policy.CipherSuites)
+		}
+		for _, cipherSuite := range config.CipherSuites {
+			if !slices.Contains(policy.CipherSuites, cipherSuite) {
+				return policy.violation(PolicyRuleCipherSuite, tls.CipherSuiteName(cipherSuite))
+			}
+		}
+	}
+	if len(policy.Curves) > 0 {
+		if config.CurvePreferences == nil {
+			config.CurvePreferences = slices.Clone(policy.Curves)
+		}
+		for _, curve := range config.CurvePreferences {
+			if !slices.Contains(policy.Curves, curve) {
+				return policy.violation(PolicyRuleCurve, curve.String())
+			}
+		}
+	}
+	for _, certificate := range config.Certificates {
+		if len(certificate.Certificate) == 0 {
+			continue
+		}
+		leaf := certificate.Leaf
+		if leaf == nil {
+			parsed, err := x509.ParseCertificate(certificate.Certificate[0])
+			if err != nil {
+				return fmt.Errorf("failed to parse certificate (cause: %w)", err)
+			}
+			leaf = parsed
+		}
+		err := policy.CheckPublicKey(leaf.PublicKey)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (policy *Policy) violation(rule PolicyRule, value string) error {
+	return &PolicyViolationError{
+		Policy: policy.Name,
+		Rule:   rule,
+		Value:  value,
+	}
+}
+
+func normalizeCertificateAlgorithm(algorithm CertificateAlgorithm) CertificateAlgorithm {
+	if algorithm == CertificateAlgorithmDefault {
+		return CertificateAlgorithmECDSA256
+	}
+	return algorithm
+}
+
+func publicKeyCertificateAlgorithm(publicKey crypto.PublicKey) (CertificateAlgorithm, bool) {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		switch key.N.BitLen() {
+		case 2048:
+			return CertificateAlgorithmRSA2048, true
+		case 3072:
+			return CertificateAlgorithmRSA3072, true
+		case 4096:
+			return CertificateAlgorithmRSA4096, true
+		case 8192:
+			return CertificateAlgorithmRSA8192, true
+		}
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P224():
+			return CertificateAlgorithmECDSA224, true
+		case elliptic.P256():
+			return CertificateAlgorithmECDSA256, true
+		case elliptic.P384():
+			return CertificateAlgorithmECDSA384, true
+		case elliptic.P521():
+			return CertificateAlgorithmECDSA521, true
+		}
+	case ed25519.PublicKey:
+		return CertificateAlgorithmED25519, true
+	}
+	return "", false
+}
+
+var globalPolicy *Policy
+var policyLock sync.RWMutex = sync.RWMutex{}
+
+// SetPolicy sets the global [Policy].
+//
+// While set, the policy is enforced for all certificate key generations and certificate
+// creations as well as by the tlsserver and tlsclient packages' SetOptions functions.
+// Setting a nil policy removes any restriction.
+func SetPolicy(policy *Policy) {
+	policyLock.Lock()
+	defer policyLock.Unlock()
+	globalPolicy = policy
+}
+
+// GetPolicy gets the global [Policy] or nil, if no policy has been set.
+func GetPolicy() *Policy {
+	policyLock.RLock()
+	defer policyLock.RUnlock()
+	return globalPolicy
+}
+
+// EnforcePolicy enforces the given [Policy] on the [tls.Config].
+//
+// This allows a per-config policy in addition to the global one (see [SetPolicy]). As the
+// policy checks the settings applied so far, this option should be applied last.
+// See [Policy.Enforce] for details.
+func EnforcePolicy(policy *Policy) TLSConfigOption {
+	return policy.Enforce
+}
+
+// EnforceGlobalPolicy enforces the global [Policy] (if set) on the [tls.Config].
+//
+// See [Policy.Enforce] for details.
+func EnforceGlobalPolicy(config *tls.Config) error {
+	currentPolicy := GetPolicy()
+	if currentPolicy == nil {
+		return nil
+	}
+	return currentPolicy.Enforce(config)
+}
+
+func checkPolicyCertificateAlgorithm(algorithm CertificateAlgorithm) error {
+	currentPolicy := GetPolicy()
+	if currentPolicy == nil {
+		return nil
+	}
+	return currentPolicy.CheckCertificateAlgorithm(algorithm)
+}
+
+func checkPolicyPublicKey(publicKey crypto.PublicKey) error {
+	currentPolicy := GetPolicy()
+	if currentPolicy == nil {
+		return nil
+	}
+	return currentPolicy.CheckPublicKey(publicKey)
+}
@@ -0,0 +1,95 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf_test
+
+import (
+	"crypto"
+	"crypto/tls"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf"
+)
+
+var testPolicy = &tlsconf.Policy{
+	Name:                  "compliance",
+	CertificateAlgorithms: []tlsconf.CertificateAlgorithm{tlsconf.CertificateAlgorithmDefault, tlsconf.CertificateAlgorithmRSA3072},
+	MinVersion:            tls.VersionTLS12,
+	CipherSuites:          []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384},
+	Curves:                []tls.CurveID{tls.CurveP384},
+}
+
+func TestPolicyCertificateAlgorithm(t *testing.T) {
+	tlsconf.SetPolicy(testPolicy)
+	defer tlsconf.SetPolicy(nil)
+	require.Equal(t, testPolicy, tlsconf.GetPolicy())
+
+	_, _, err := tlsconf.CertificateAlgorithmECDSA256.GenerateCertificateKey()
+	require.NoError(t, err)
+	_, _, err = tlsconf.CertificateAlgorithmECDSA224.GenerateCertificateKey()
+	requirePolicyViolation(t, err, tlsconf.PolicyRuleCertificateAlgorithm, "ecdsa224")
+	_, err = tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmRSA2048, time.Hour)
+	requirePolicyViolation(t, err, tlsconf.PolicyRuleCertificateAlgorithm, "rsa2048")
+	require.EqualError(t, err, "policy 'compliance' violated: certificate-algorithm 'rsa2048' not approved")
+
+	ca, err := tlsconf.NewLocalCA(tlsconf.NewMemoryKeyStore(), "ca", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	_, err = ca.IssueServerCertificate("localhost", tlsconf.CertificateAlgorithmED25519, time.Hour)
+	requirePolicyViolation(t, err, tlsconf.PolicyRuleCertificateAlgorithm, "ed25519")
+
+	tlsconf.SetPolicy(nil)
+	_, privateKey, err := tlsconf.CertificateAlgorithmED25519.GenerateCertificateKey()
+	require.NoError(t, err)
+	tlsconf.SetPolicy(testPolicy)
+	_, err = ca.IssueServerSignerCertificate("localhost", privateKey.(crypto.Signer), time.Hour)
+	requirePolicyViolation(t, err, tlsconf.PolicyRuleCertificateAlgorithm, "ed25519")
+}
+
+func TestPolicyEnforce(t *testing.T) {
+	config := &tls.Config{}
+	err := tlsconf.EnforcePolicy(testPolicy)(config)
+	require.NoError(t, err)
+	require.Equal(t, uint16(0), config.MinVersion)
+	require.Equal(t, testPolicy.CipherSuites, config.CipherSuites)
+	require.Equal(t, testPolicy.Curves, config.CurvePreferences)
+
+	config = &tls.Config{MinVersion: tls.VersionTLS11}
+	err = tlsconf.EnforcePolicy(testPolicy)(config)
+	requirePolicyViolation(t, err, tlsconf.PolicyRuleVersion, "TLS 1.1")
+
+	config = &tls.Config{CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA}}
+	err = tlsconf.EnforcePolicy(testPolicy)(config)
+	requirePolicyViolation(t, err, tlsconf.PolicyRuleCipherSuite, "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA")
+
+	config = &tls.Config{CurvePreferences: []tls.CurveID{tls.X25519}}
+	err = tlsconf.EnforcePolicy(testPolicy)(config)
+	requirePolicyViolation(t, err, tlsconf.PolicyRuleCurve, "X25519")
+
+	certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmECDSA384, time.Hour)
+	require.NoError(t, err)
+	config = &tls.Config{Certificates: []tls.Certificate{*certificate}}
+	err = tlsconf.EnforcePolicy(testPolicy)(config)
+	requirePolicyViolation(t, err, tlsconf.PolicyRuleCertificateAlgorithm, "ecdsa384")
+
+	tls13Policy := &tlsconf.Policy{Name: "tls13", MinVersion: tls.VersionTLS13}
+	config = &tls.Config{}
+	err = tlsconf.EnforcePolicy(tls13Policy)(config)
+	require.NoError(t, err)
+	require.Equal(t, uint16(tls.VersionTLS13), config.MinVersion)
+	config = &tls.Config{MaxVersion: tls.VersionTLS12}
+	err = tlsconf.EnforcePolicy(tls13Policy)(config)
+	requirePolicyViolation(t, err, tlsconf.PolicyRuleVersion, "TLS 1.2")
+}
+
+func requirePolicyViolation(t *testing.T, err error, rule tlsconf.PolicyRule, value string) {
+	var violation *tlsconf.PolicyViolationError
+	require.True(t, errors.As(err, &violation), "err: %v", err)
+	require.Equal(t, rule, violation.Rule)
+	require.Equal(t, value, violation.Value)
+}
@@ -0,0 +1,275 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RemoteSigner is a [crypto.Signer] delegating all signing operations to a remote signing
+// service (e.g. a KMS) via HTTP.
+//
+// The signing service is addressed by a key URL and must provide the following endpoints:
+//   - GET <key URL>/public-key: Returns a [RemoteSignerPublicKey] JSON object
+//   - POST <key URL>/sign: Signs the [RemoteSignerRequest] JSON object and returns a
+//     [RemoteSignerResponse] JSON object
+//
+// See [NewRemoteSignerHandler] for a reference implementation of the signing service.
+type RemoteSigner struct {
+	client    *http.Client
+	keyURL    string
+	publicKey crypto.PublicKey
+}
+
+// RemoteSignerPublicKey defines the response of the signing service's public key endpoint.
+type RemoteSignerPublicKey struct {
+	// PublicKey contains the DER encoded PKIX public key.
+	PublicKey []byte `json:"public_key"`
+}
+
+// RemoteSignerRequest defines the request of the signing service's sign endpoint.
+type RemoteSignerRequest struct {
+	// Digest contains the digest to sign (respectively the message for Ed25519 keys).
+	Digest []byte `json:"digest"`
+	// Hash contains the name of the hash function used to create the digest (e.g. SHA-256).
+	// An empty name indicates an unhashed message.
+	Hash string `json:"hash,omitempty"`
+	// PSSSaltLength is set for RSA-PSS signatures and contains the salt length to use.
+	PSSSaltLength *int `json:"pss_salt_length,omitempty"`
+}
+
+// RemoteSignerResponse defines the response of the signing service's sign endpoint.
+type RemoteSignerResponse struct {
+	// Signature contains the created signature.
+	Signature []byte `json:"signature"`
+}
+
+const (
+	remoteSignerPublicKeyPath = "/public-key"
+	remoteSignerSignPath      = "/sign"
+)
+
+// DefaultRemoteSignerTimeout defines the time limit for a single call to the signing service,
+// if the [http.Client] used by a [RemoteSigner] does not define a timeout.
+const DefaultRemoteSignerTimeout = 30 * time.Second
+
+// NewRemoteSigner creates a [RemoteSigner] for the given key URL.
+//
+// The given [http.Client] is used to access the signing service. If nil, a client with a timeout of
+// [DefaultRemoteSignerTimeout] is used. Every call to the signing service is limited by the client's
+// timeout (respectively [DefaultRemoteSignerTimeout], if the client has no timeout), hence an unresponsive
+// signing service does not block TLS handshakes or certificate issuing indefinitely.
+// The key's public key is retrieved during creation, hence the signing service must be reachable.
+func NewRemoteSigner(client *http.Client, keyURL string) (*RemoteSigner, error) {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultRemoteSignerTimeout}
+	}
+	_, err := url.Parse(keyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key URL '%s' (cause: %w)", keyURL, err)
+	}
+	signer := &RemoteSigner{
+		client: client,
+		keyURL: strings.TrimSuffix(keyURL, "/"),
+	}
+	response := &RemoteSignerPublicKey{}
+	err = signer.call(http.MethodGet, remoteSignerPublicKeyPath, nil, response)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(response.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key of '%s' (cause: %w)", keyURL, err)
+	}
+	signer.publicKey = publicKey
+	return signer, nil
+}
+
+// Public gets the signer's public key.
+func (signer *RemoteSigner) Public() crypto.PublicKey {
+	return signer.publicKey
+}
+
+// Sign signs the given digest by invoking the signing service.
+//
+// The rand parameter is ignored, as random data is provided by the signing service.
+func (signer *RemoteSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	request := &RemoteSignerRequest{
+		Digest: digest,
+	}
+	if hash := opts.HashFunc(); hash != 0 {
+		request.Hash = hash.String()
+	}
+	if pssOpts, ok := opts.(*rsa.PSSOptions); ok {
+		saltLength := pssOpts.SaltLength
+		request.PSSSaltLength = &saltLength
+	}
+	response := &RemoteSignerResponse{}
+	err := signer.call(http.MethodPost, remoteSignerSignPath, request, response)
+	if err != nil {
+		return nil, err
+	}
+	return response.Signature, nil
+}
+
+func (signer *RemoteSigner) call(method, path string, request, response any) error {
+	requestURL := signer.keyURL + path
+	var body io.Reader
+	if request != nil {
+		requestBytes, err := json.Marshal(request)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request (cause: %w)", err)
+		}
+		body = bytes.NewReader(requestBytes)
+	}
+	timeout := signer.client.Timeout
+	if timeout <= 0 {
+		timeout = DefaultRemoteSignerTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	httpRequest, err := http.NewRequestWithContext(ctx, method, requestURL, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request for '%s' (cause: %w)", requestURL, err)
+	}
+	if body != nil {
+		httpRequest.Header.Set("Content-Type", "application/json")
+	}
+	httpResponse, err := signer.client.Do(httpRequest)
+	if err != nil {
+		return fmt.Errorf("failed to call signing service '%s' (cause: %w)", requestURL, err)
+	}
+	defer httpResponse.Body.Close()
+	if httpResponse.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(httpResponse.Body, 1024))
+		return fmt.Errorf("signing service '%s' failed with status %d: %s", requestURL, httpResponse.StatusCode, strings.TrimSpace(string(message)))
+	}
+	err = json.NewDecoder(httpResponse.Body).Decode(response)
+	if err != nil {
+		return fmt.Errorf("failed to decode response of '%s' (cause: %w)", requestURL, err)
+	}
+	return nil
+}
+
+// NewRemoteSignerHandler creates a [http.Handler] implementing the signing service protocol
+// used by [RemoteSigner] for the private keys of the given [KeyStore].
+//
+// The handler serves the key URLs /<name> for every [KeyStore] entry. Combined with a
+// [http.ServeMux] and [http.StripPrefix] the keys can be served below any path. The handler
+// does not perform any authentication and is primarily meant as a local stand-in for testing.
+func NewRemoteSignerHandler(store KeyStore) http.Handler {
+	handler := &remoteSignerHandler{store: store}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{name}"+remoteSignerPublicKeyPath, handler.servePublicKey)
+	mux.HandleFunc("POST /{name}"+remoteSignerSignPath, handler.serveSign)
+	return mux
+}
+
+type remoteSignerHandler struct {
+	store KeyStore
+}
+
+func (handler *remoteSignerHandler) servePublicKey(w http.ResponseWriter, r *http.Request) {
+	signer, status := handler.lookupSigner(r.PathValue("name"))
+	if signer == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		slog.Error("failed to marshal public key", slog.String("name", r.PathValue("name")), slog.Any("err", err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	writeRemoteSignerResponse(w, &RemoteSignerPublicKey{PublicKey: publicKey})
+}
+
+func (handler *remoteSignerHandler) serveSign(w http.ResponseWriter, r *http.Request) {
+	signer, status := handler.lookupSigner(r.PathValue("name"))
+	if signer == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	request := &RemoteSignerRequest{}
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024)).Decode(request)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid request (cause: %v)", err), http.StatusBadRequest)
+		return
+	}
+	opts, err := remoteSignerOpts(request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	signature, err := signer.Sign(rand.Reader, request.Digest, opts)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to sign (cause: %v)", err), http.StatusBadRequest)
+		return
+	}
+	writeRemoteSignerResponse(w, &RemoteSignerResponse{Signature: signature})
+}
+
+func (handler *remoteSignerHandler) lookupSigner(name string) (crypto.Signer, int) {
+	certificate, err := handler.store.Get(name)
+	if errors.Is(err, ErrKeyStoreEntryNotFound) {
+		return nil, http.StatusNotFound
+	} else if err != nil {
+		slog.Error("failed to get key store entry", slog.String("name", name), slog.Any("err", err))
+		return nil, http.StatusInternalServerError
+	}
+	signer, ok := certificate.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, http.StatusNotFound
+	}
+	return signer, http.StatusOK
+}
+
+var remoteSignerHashes = []crypto.Hash{crypto.SHA1, crypto.SHA224, crypto.SHA256, crypto.SHA384, crypto.SHA512}
+
+func remoteSignerOpts(request *RemoteSignerRequest) (crypto.SignerOpts, error) {
+	hash := crypto.Hash(0)
+	if request.Hash != "" {
+		for _, candidate := range remoteSignerHashes {
+			if candidate.String() == request.Hash {
+				hash = candidate
+				break
+			}
+		}
+		if hash == 0 {
+			return nil, fmt.Errorf("unsupported hash: '%s'", request.Hash)
+		}
+	}
+	if request.PSSSaltLength != nil {
+		return &rsa.PSSOptions{SaltLength: *request.PSSSaltLength, Hash: hash}, nil
+	}
+	return hash, nil
+}
+
+func writeRemoteSignerResponse(w http.ResponseWriter, response any) {
+	body, err := json.Marshal(response)
+	if err != nil {
+		slog.Error("failed to marshal signing service response", slog.Any("err", err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
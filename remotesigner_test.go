@@ -0,0 +1,124 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf_test
+
+import (
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf"
+	"github.com/tdrn-org/go-tlsconf/tlstest"
+)
+
+func TestRemoteSigner(t *testing.T) {
+	store := tlsconf.NewMemoryKeyStore()
+	server := startRemoteSignerServer(t, store)
+	for _, algorithm := range []tlsconf.CertificateAlgorithm{tlsconf.CertificateAlgorithmRSA2048, tlsconf.CertificateAlgorithmECDSA256, tlsconf.CertificateAlgorithmED25519} {
+		certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", algorithm, time.Hour)
+		require.NoError(t, err)
+		err = store.Put(string(algorithm), certificate)
+		require.NoError(t, err)
+		signer, err := tlsconf.NewRemoteSigner(server.Client(), server.URL+"/keys/"+string(algorithm))
+		require.NoError(t, err)
+		remoteCertificate, err := tlsconf.NewSignerCertificate(certificate.Certificate, signer)
+		require.NoError(t, err)
+		for _, version := range []uint16{tls.VersionTLS12, tls.VersionTLS13} {
+			serverConfig := &tls.Config{Certificates: []tls.Certificate{*remoteCertificate}, MaxVersion: version}
+			rootCAs := x509.NewCertPool()
+			rootCAs.AddCert(certificate.Leaf)
+			clientConfig := &tls.Config{RootCAs: rootCAs, ServerName: "localhost"}
+			result := tlstest.Handshake(t.Context(), clientConfig, serverConfig, "")
+			require.NoError(t, result.Err())
+		}
+	}
+}
+
+func TestRemoteSignerLocalCA(t *testing.T) {
+	store := tlsconf.NewMemoryKeyStore()
+	_, err := tlsconf.NewLocalCA(store, "ca", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	storedCA, err := store.Get("ca")
+	require.NoError(t, err)
+	server := startRemoteSignerServer(t, store)
+	signer, err := tlsconf.NewRemoteSigner(server.Client(), server.URL+"/keys/ca")
+	require.NoError(t, err)
+	remoteCACertificate, err := tlsconf.NewSignerCertificate(storedCA.Certificate, signer)
+	require.NoError(t, err)
+	ca, err := tlsconf.NewLocalCAFromCertificate(remoteCACertificate)
+	require.NoError(t, err)
+	certificate, err := ca.IssueServerCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(storedCA.Leaf)
+	_, err = certificate.Leaf.Verify(x509.VerifyOptions{
+		DNSName: "localhost",
+		Roots:   roots,
+	})
+	require.NoError(t, err)
+}
+
+func TestRemoteSignerFailure(t *testing.T) {
+	store := tlsconf.NewMemoryKeyStore()
+	server := startRemoteSignerServer(t, store)
+	_, err := tlsconf.NewRemoteSigner(server.Client(), server.URL+"/keys/unknown")
+	require.ErrorContains(t, err, "status 404")
+	certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	err = store.Put("key", certificate)
+	require.NoError(t, err)
+	signer, err := tlsconf.NewRemoteSigner(server.Client(), server.URL+"/keys/key")
+	require.NoError(t, err)
+	err = store.Delete("key")
+	require.NoError(t, err)
+	_, err = tlsconf.NewSignerCertificate(certificate.Certificate, signer)
+	require.NoError(t, err)
+	_, err = signer.Sign(nil, make([]byte, 32), crypto.SHA256)
+	require.ErrorContains(t, err, "status 404")
+}
+
+func TestRemoteSignerTimeout(t *testing.T) {
+	store := tlsconf.NewMemoryKeyStore()
+	certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	err = store.Put("key", certificate)
+	require.NoError(t, err)
+	release := make(chan struct{})
+	signerHandler := http.StripPrefix("/keys", tlsconf.NewRemoteSignerHandler(store))
+	mux := http.NewServeMux()
+	mux.HandleFunc("/keys/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/sign") {
+			<-release
+		}
+		signerHandler.ServeHTTP(w, r)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+	client := server.Client()
+	client.Timeout = 100 * time.Millisecond
+	signer, err := tlsconf.NewRemoteSigner(client, server.URL+"/keys/key")
+	require.NoError(t, err)
+	start := time.Now()
+	_, err = signer.Sign(nil, make([]byte, 32), crypto.SHA256)
+	require.Error(t, err)
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
+func startRemoteSignerServer(t *testing.T, store tlsconf.KeyStore) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/keys/", http.StripPrefix("/keys", tlsconf.NewRemoteSignerHandler(store)))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
@@ -0,0 +1,123 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// IsExportableKey checks whether the given private key is an in-memory key which can be
+// serialized (e.g. via [x509.MarshalPKCS8PrivateKey]).
+//
+// Opaque keys (like [crypto.Signer] implementations backed by a HSM, TPM or KMS) are not exportable.
+// Writers like [WriteCertificate] skip the key file for such keys.
+func IsExportableKey(privateKey crypto.PrivateKey) bool {
+	switch privateKey.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey, *ecdh.PrivateKey:
+		return true
+	}
+	return false
+}
+
+// NewSignerCertificate creates a [tls.Certificate] for the given certificate chain and signer.
+//
+// The signer's public key must match the public key of the chain's first certificate.
+func NewSignerCertificate(chain [][]byte, signer crypto.Signer) (*tls.Certificate, error) {
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("empty certificate chain")
+	}
+	certificate, err := newTLSCertificate(chain, signer)
+	if err != nil {
+		return nil, err
+	}
+	if !publicKeysEqual(certificate.Leaf.PublicKey, signer.Public()) {
+		return nil, fmt.Errorf("signer does not match certificate public key")
+	}
+	return certificate, nil
+}
+
+func publicKeysEqual(publicKey1, publicKey2 crypto.PublicKey) bool {
+	comparable, ok := publicKey1.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && comparable.Equal(publicKey2)
+}
+
+// LoadSignerCertificate loads the certificate chain from the given file and combines it
+// with the given signer into a [tls.Certificate].
+//
+// The certificate file may be in any format supported by [DecodeCertificates].
+func LoadSignerCertificate(certFile string, signer crypto.Signer) (*tls.Certificate, error) {
+	certData, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate file '%s' (cause: %w)", certFile, err)
+	}
+	certificates, err := DecodeCertificates(certData, DecodeStrict)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode certificate file '%s' (cause: %w)", certFile, err)
+	}
+	chain := make([][]byte, 0, len(certificates))
+	for _, certificate := range certificates {
+		chain = append(chain, certificate.Raw)
+	}
+	return NewSignerCertificate(chain, signer)
+}
+
+// GenerateEphemeralSignerCertificate generates a dummy server certificate for the given signer
+// suitable for testing purposes.
+//
+// See [GenerateEphemeralCertificate] for details.
+func GenerateEphemeralSignerCertificate(address string, signer crypto.Signer, lifetime time.Duration) (*tls.Certificate, error) {
+	slog.Info("generating ephemeral signer certificate", slog.String("address", address))
+	host, err := addressHost(address)
+	if err != nil {
+		return nil, err
+	}
+	x509Block, err := createEphemeralCertificateX509(host, signer.Public(), signer, lifetime)
+	if err != nil {
+		return nil, err
+	}
+	return NewSignerCertificate([][]byte{x509Block.Bytes}, signer)
+}
+
+// CreateCertificateRequest creates a PEM encoded certificate signing request for the given address
+// signed by the given signer.
+//
+// The address may be a host name or IP address optionally followed by a port (which is ignored).
+func CreateCertificateRequest(address string, signer crypto.Signer) ([]byte, error) {
+	host, err := addressHost(address)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: host},
+	}
+	certificateTemplate := &x509.Certificate{}
+	setCertificateHost(certificateTemplate, host)
+	template.DNSNames = certificateTemplate.DNSNames
+	template.IPAddresses = certificateTemplate.IPAddresses
+	csrBytes, err := x509.CreateCertificateRequest(rand.Reader, template, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate request (cause: %w)", err)
+	}
+	csrBlock := &pem.Block{
+		Type:  "CERTIFICATE REQUEST",
+		Bytes: csrBytes,
+	}
+	return pem.EncodeToMemory(csrBlock), nil
+}
@@ -0,0 +1,159 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf_test
+
+import (
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf"
+	"github.com/tdrn-org/go-tlsconf/tlstest"
+)
+
+// opaqueSigner hides the wrapped key, like a HSM, TPM or KMS backed signer.
+type opaqueSigner struct {
+	signer crypto.Signer
+}
+
+func (signer *opaqueSigner) Public() crypto.PublicKey {
+	return signer.signer.Public()
+}
+
+func (signer *opaqueSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return signer.signer.Sign(rand, digest, opts)
+}
+
+func newOpaqueSigner(t *testing.T, algorithm tlsconf.CertificateAlgorithm) crypto.Signer {
+	_, privateKey, err := algorithm.GenerateCertificateKey()
+	require.NoError(t, err)
+	return &opaqueSigner{signer: privateKey.(crypto.Signer)}
+}
+
+func TestIsExportableKey(t *testing.T) {
+	for _, algorithm := range []tlsconf.CertificateAlgorithm{tlsconf.CertificateAlgorithmRSA2048, tlsconf.CertificateAlgorithmECDSA256, tlsconf.CertificateAlgorithmED25519} {
+		_, privateKey, err := algorithm.GenerateCertificateKey()
+		require.NoError(t, err)
+		require.True(t, tlsconf.IsExportableKey(privateKey))
+	}
+	require.False(t, tlsconf.IsExportableKey(newOpaqueSigner(t, tlsconf.CertificateAlgorithmDefault)))
+}
+
+func TestGenerateEphemeralSignerCertificate(t *testing.T) {
+	for _, algorithm := range []tlsconf.CertificateAlgorithm{tlsconf.CertificateAlgorithmRSA2048, tlsconf.CertificateAlgorithmECDSA256, tlsconf.CertificateAlgorithmED25519} {
+		signer := newOpaqueSigner(t, algorithm)
+		certificate, err := tlsconf.GenerateEphemeralSignerCertificate("localhost", signer, time.Hour)
+		require.NoError(t, err)
+		require.Equal(t, signer, certificate.PrivateKey)
+		serverConfig := &tls.Config{Certificates: []tls.Certificate{*certificate}}
+		rootCAs := x509.NewCertPool()
+		rootCAs.AddCert(certificate.Leaf)
+		clientConfig := &tls.Config{RootCAs: rootCAs, ServerName: "localhost"}
+		result := tlstest.Handshake(t.Context(), clientConfig, serverConfig, "")
+		require.NoError(t, result.Err())
+	}
+}
+
+func TestNewSignerCertificateMismatch(t *testing.T) {
+	certificate, err := tlsconf.GenerateEphemeralSignerCertificate("localhost", newOpaqueSigner(t, tlsconf.CertificateAlgorithmDefault), time.Hour)
+	require.NoError(t, err)
+	_, err = tlsconf.NewSignerCertificate(certificate.Certificate, newOpaqueSigner(t, tlsconf.CertificateAlgorithmDefault))
+	require.Error(t, err)
+	_, err = tlsconf.NewSignerCertificate(nil, newOpaqueSigner(t, tlsconf.CertificateAlgorithmDefault))
+	require.Error(t, err)
+}
+
+func TestWriteSignerCertificate(t *testing.T) {
+	dir := t.TempDir()
+	signer := newOpaqueSigner(t, tlsconf.CertificateAlgorithmDefault)
+	certificate, err := tlsconf.GenerateEphemeralSignerCertificate("localhost", signer, time.Hour)
+	require.NoError(t, err)
+	certFile, keyFile, err := tlsconf.WriteCertificate(certificate, dir, "signer")
+	require.NoError(t, err)
+	require.Empty(t, keyFile)
+	require.NoFileExists(t, filepath.Join(dir, "signer.key"))
+	loaded, err := tlsconf.LoadSignerCertificate(certFile, signer)
+	require.NoError(t, err)
+	require.Equal(t, certificate.Certificate, loaded.Certificate)
+	_, err = tlsconf.EncodePKCS12(certificate, "secret", tlsconf.PKCS12EncryptionModern)
+	require.Error(t, err)
+}
+
+func TestWriteSignerCertificateRemovesStaleKey(t *testing.T) {
+	dir := t.TempDir()
+	exportable, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	_, keyFile, err := tlsconf.WriteCertificate(exportable, dir, "signer")
+	require.NoError(t, err)
+	require.FileExists(t, keyFile)
+	certificate, err := tlsconf.GenerateEphemeralSignerCertificate("localhost", newOpaqueSigner(t, tlsconf.CertificateAlgorithmDefault), time.Hour)
+	require.NoError(t, err)
+	_, keyFile, err = tlsconf.WriteCertificate(certificate, dir, "signer")
+	require.NoError(t, err)
+	require.Empty(t, keyFile)
+	require.NoFileExists(t, filepath.Join(dir, "signer.key"))
+	_, _, err = tlsconf.WriteCertificate(&tls.Certificate{Certificate: certificate.Certificate}, dir, "signer")
+	require.ErrorIs(t, err, tlsconf.ErrNoPrivateKey)
+}
+
+func TestLocalCASigner(t *testing.T) {
+	caCertificate, err := tlsconf.GenerateLocalCACertificate("ca", newOpaqueSigner(t, tlsconf.CertificateAlgorithmDefault), time.Hour)
+	require.NoError(t, err)
+	ca, err := tlsconf.NewLocalCAFromCertificate(caCertificate)
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Certificate())
+
+	signer := newOpaqueSigner(t, tlsconf.CertificateAlgorithmDefault)
+	serverCertificate, err := ca.IssueServerSignerCertificate("localhost", signer, time.Hour)
+	require.NoError(t, err)
+	serverConfig := &tls.Config{Certificates: []tls.Certificate{*serverCertificate}}
+	clientConfig := &tls.Config{RootCAs: roots, ServerName: "localhost"}
+	result := tlstest.Handshake(t.Context(), clientConfig, serverConfig, "")
+	require.NoError(t, result.Err())
+
+	_, err = ca.IssueClientSignerCertificate("client", signer, time.Hour)
+	require.NoError(t, err)
+
+	_, err = tlsconf.NewLocalCAFromCertificate(serverCertificate)
+	require.Error(t, err)
+}
+
+func TestSignCertificateRequest(t *testing.T) {
+	ca, err := tlsconf.NewLocalCA(tlsconf.NewMemoryKeyStore(), "ca", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Certificate())
+
+	signer := newOpaqueSigner(t, tlsconf.CertificateAlgorithmECDSA256)
+	csr, err := tlsconf.CreateCertificateRequest("127.0.0.1:443", signer)
+	require.NoError(t, err)
+	chain, err := ca.SignCertificateRequest(csr, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}, time.Hour)
+	require.NoError(t, err)
+	require.Len(t, chain, 1)
+	_, err = chain[0].Verify(x509.VerifyOptions{
+		DNSName:   "127.0.0.1",
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	require.NoError(t, err)
+
+	certFile := filepath.Join(t.TempDir(), "server.crt")
+	err = tlsconf.WriteCertificates(chain, certFile, tlsconf.CertificateFormatPEM)
+	require.NoError(t, err)
+	certificate, err := tlsconf.LoadSignerCertificate(certFile, signer)
+	require.NoError(t, err)
+	require.Equal(t, chain[0].Raw, certificate.Certificate[0])
+
+	_, err = ca.SignCertificateRequest([]byte("invalid"), nil, time.Hour)
+	require.Error(t, err)
+}
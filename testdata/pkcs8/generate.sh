@@ -0,0 +1,16 @@
+#!/bin/sh
+#
+# Generates the OpenSSL encrypted PKCS#8 key fixtures (passphrase: secret).
+# OpenSSL's pkcs8 command does not support AEAD ciphers, hence there are no GCM fixtures.
+set -e
+cd "$(dirname "$0")"
+openssl genpkey -algorithm EC -pkeyopt ec_paramgen_curve:P-256 -out key.pem
+for cipher in aes-128-cbc aes-192-cbc aes-256-cbc; do
+	openssl pkcs8 -topk8 -in key.pem -v2 $cipher -v2prf hmacWithSHA256 -passout pass:secret -out pbkdf2-sha256-$cipher.pem
+done
+openssl pkcs8 -topk8 -in key.pem -v2 aes-256-cbc -v2prf hmacWithSHA1 -passout pass:secret -out pbkdf2-sha1-aes-256-cbc.pem
+openssl pkcs8 -topk8 -in key.pem -v2 aes-256-cbc -v2prf hmacWithSHA512 -passout pass:secret -out pbkdf2-sha512-aes-256-cbc.pem
+openssl pkcs8 -topk8 -in key.pem -v2 aes-256-cbc -scrypt -passout pass:secret -out scrypt-aes-256-cbc.pem
+openssl pkcs8 -topk8 -in key.pem -v2 aes-256-cbc -iter 20000000 -passout pass:secret -out pbkdf2-excessive-iterations.pem
+openssl pkcs8 -topk8 -in key.pem -v2 aes-256-cbc -scrypt -scrypt_N 1024 -scrypt_r 64 -scrypt_p 1 -passout pass:secret -out scrypt-excessive-block-size.pem
+openssl pkcs8 -topk8 -in key.pem -v2 aes-256-cbc -scrypt -scrypt_N 16384 -scrypt_r 8 -scrypt_p 32 -passout pass:secret -out scrypt-excessive-parallelization.pem
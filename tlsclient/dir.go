@@ -0,0 +1,279 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/tdrn-org/go-conf"
+	"github.com/tdrn-org/go-tlsconf"
+)
+
+// SkippedFile describes a file skipped by [LoadCertificatesFromDir].
+type SkippedFile struct {
+	Path   string
+	Reason error
+}
+
+func (skippedFile *SkippedFile) String() string {
+	return fmt.Sprintf("%s: %v", skippedFile.Path, skippedFile.Reason)
+}
+
+// ErrNoCertificates indicates a file which does not contain any certificate.
+// It is the same error as [tlsconf.ErrNoCertificates].
+var ErrNoCertificates = tlsconf.ErrNoCertificates
+
+// ErrDuplicateLink indicates a file which is a link to an already loaded file.
+var ErrDuplicateLink = errors.New("link to already loaded file")
+
+// ErrNotRegularFile indicates a directory entry which is not a regular file.
+var ErrNotRegularFile = errors.New("not a regular file")
+
+var rehashCRLLinkPattern = regexp.MustCompile(`^[0-9a-f]{8}\.r[0-9]+$`)
+
+// LoadCertificatesFromDir loads all certificates from the files in the given directory.
+//
+// Certificate files may be in any format supported by [tlsconf.DecodeCertificates]. Sub directories are ignored. Links are resolved
+// and every file is loaded only once, hence a directory in the OpenSSL hashed layout
+// (as created by c_rehash) is supported. CRL links of this layout are ignored. All files
+// not loaded are returned together with the reason of skipping them.
+func LoadCertificatesFromDir(dir string) ([]*x509.Certificate, []*SkippedFile, error) {
+	certificates, _, skippedFiles, err := loadCertificatesFromDir(dir)
+	return certificates, skippedFiles, err
+}
+
+// loadCertificatesFromDir loads all certificates from the files in the given directory and
+// additionally returns the names of the loaded files.
+func loadCertificatesFromDir(dir string) ([]*x509.Certificate, []string, []*SkippedFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to read certificate directory '%s' (cause: %w)", dir, err)
+	}
+	certificates := make([]*x509.Certificate, 0)
+	certFiles := make([]string, 0)
+	skippedFiles := make([]*SkippedFile, 0)
+	loadedFiles := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.IsDir() || rehashCRLLinkPattern.MatchString(entry.Name()) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		resolvedPath, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			skippedFiles = append(skippedFiles, &SkippedFile{Path: path, Reason: err})
+			continue
+		}
+		resolvedPath, err = filepath.Abs(resolvedPath)
+		if err != nil {
+			skippedFiles = append(skippedFiles, &SkippedFile{Path: path, Reason: err})
+			continue
+		}
+		if loadedFiles[resolvedPath] {
+			skippedFiles = append(skippedFiles, &SkippedFile{Path: path, Reason: ErrDuplicateLink})
+			continue
+		}
+		fileInfo, err := os.Stat(resolvedPath)
+		if err != nil {
+			skippedFiles = append(skippedFiles, &SkippedFile{Path: path, Reason: err})
+			continue
+		}
+		if fileInfo.IsDir() {
+			continue
+		}
+		if !fileInfo.Mode().IsRegular() {
+			skippedFiles = append(skippedFiles, &SkippedFile{Path: path, Reason: ErrNotRegularFile})
+			continue
+		}
+		fileCertificates, err := readCertificateFile(resolvedPath)
+		if err != nil {
+			skippedFiles = append(skippedFiles, &SkippedFile{Path: path, Reason: err})
+			continue
+		}
+		loadedFiles[resolvedPath] = true
+		certificates = append(certificates, fileCertificates...)
+		certFiles = append(certFiles, path)
+	}
+	return certificates, certFiles, skippedFiles, nil
+}
+
+func readCertificateFile(certFile string) ([]*x509.Certificate, error) {
+	certData, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+	return tlsconf.DecodeCertificates(certData, tlsconf.DecodeStrict)
+}
+
+// AddCertificatesFromDir adds the certificates from the files in the given directory
+// to the client [tls.Config]'s RootCA pool.
+//
+// See [LoadCertificatesFromDir] for the supported directory layout. Skipped files
+// are logged. If the current config's RootCA pool is nil, the result pool is based on the system CAs.
+func AddCertificatesFromDir(dir string) tlsconf.TLSConfigOption {
+	return func(config *tls.Config) error {
+		rootCAs, err := configRootCAs(config)
+		if err != nil {
+			return err
+		}
+		certificates, certFiles, skippedFiles, err := loadCertificatesFromDir(dir)
+		if err != nil {
+			return err
+		}
+		logSkippedFiles(skippedFiles)
+		for _, certificate := range certificates {
+			rootCAs.AddCert(certificate)
+		}
+		config.RootCAs = rootCAs
+		recordCertificateFiles(config, certFiles...)
+		return nil
+	}
+}
+
+// WatchCertificatesDir adds the certificates from the files in the given directory
+// to the client [tls.Config]'s RootCA pool and rebuilds the client [tls.Config] instance
+// whenever files are added to, changed in or removed from the directory.
+//
+// The directory is polled using the given interval until the given context is done or the
+// client [tls.Config] instance is replaced by another call to [SetOptions]. As a [tls.Config]'s
+// RootCA pool cannot be exchanged while in use, a directory change causes all options of the
+// original [SetOptions] call to be applied again and the resulting [tls.Config] instance to be
+// set as the new client [tls.Config] instance. Hence this option is only supported via [SetOptions]
+// and only affects connections using the [tls.Config] instance returned by [GetConfig] at the time
+// they are established. Especially [net/http.Client]s set up via [ApplyConfig] hold a copy of the
+// [tls.Config] instance at the time of the call and do not see any changes in the directory
+// afterwards. The interval must be positive.
+func WatchCertificatesDir(ctx context.Context, dir string, interval time.Duration) tlsconf.TLSConfigOption {
+	watcher := &dirWatcher{
+		ctx:      ctx,
+		dir:      dir,
+		interval: interval,
+	}
+	return watcher.apply
+}
+
+// dirWatcher state is guarded by setOptionsLock.
+type dirWatcher struct {
+	ctx      context.Context
+	dir      string
+	interval time.Duration
+	start    sync.Once
+	config   *Config
+	snapshot []string
+}
+
+func (watcher *dirWatcher) apply(config *tls.Config) error {
+	if watcher.interval <= 0 {
+		return fmt.Errorf("invalid certificate directory watch interval: %s", watcher.interval)
+	}
+	wrapper := applyingConfig.Load()
+	if wrapper == nil || &wrapper.Config != config {
+		return fmt.Errorf("failed to watch certificate directory '%s' (cause: option requires SetOptions)", watcher.dir)
+	}
+	rootCAs, err := configRootCAs(config)
+	if err != nil {
+		return err
+	}
+	snapshot, err := watcher.takeSnapshot()
+	if err != nil {
+		return fmt.Errorf("failed to read certificate directory '%s' (cause: %w)", watcher.dir, err)
+	}
+	certificates, certFiles, skippedFiles, err := loadCertificatesFromDir(watcher.dir)
+	if err != nil {
+		return err
+	}
+	logSkippedFiles(skippedFiles)
+	for _, certificate := range certificates {
+		rootCAs.AddCert(certificate)
+	}
+	config.RootCAs = rootCAs
+	recordCertificateFiles(config, certFiles...)
+	watcher.config = wrapper
+	watcher.snapshot = snapshot
+	watcher.start.Do(func() {
+		go watcher.watch()
+	})
+	return nil
+}
+
+func (watcher *dirWatcher) watch() {
+	ticker := time.NewTicker(watcher.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-watcher.ctx.Done():
+			return
+		case <-ticker.C:
+			snapshot, err := watcher.takeSnapshot()
+			if err != nil {
+				slog.Warn("failed to check certificate directory", slog.String("dir", watcher.dir), slog.Any("err", err))
+				continue
+			}
+			if !watcher.reload(snapshot) {
+				return
+			}
+		}
+	}
+}
+
+func (watcher *dirWatcher) takeSnapshot() ([]string, error) {
+	entries, err := os.ReadDir(watcher.dir)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		fileInfo, err := os.Stat(filepath.Join(watcher.dir, entry.Name()))
+		if err != nil {
+			snapshot = append(snapshot, entry.Name())
+			continue
+		}
+		snapshot = append(snapshot, fmt.Sprintf("%s:%d:%d", entry.Name(), fileInfo.Size(), fileInfo.ModTime().UnixNano()))
+	}
+	return snapshot, nil
+}
+
+// reload rebuilds and sets the client [tls.Config] instance, if the given snapshot differs from the
+// one taken during the last build. False is returned, if the client [tls.Config] instance has been
+// replaced in the meantime and watching is no longer needed.
+func (watcher *dirWatcher) reload(snapshot []string) bool {
+	setOptionsLock.Lock()
+	defer setOptionsLock.Unlock()
+	current, _ := conf.LookupConfiguration[*Config]()
+	if current != watcher.config {
+		return false
+	}
+	if slices.Equal(watcher.snapshot, snapshot) {
+		return true
+	}
+	slog.Info("certificate directory changed; reloading certificates", slog.String("dir", watcher.dir))
+	config, err := newConfig(current.options)
+	if err != nil {
+		slog.Warn("failed to reload certificate directory", slog.String("dir", watcher.dir), slog.Any("err", err))
+		watcher.config = current
+		watcher.snapshot = nil
+		return true
+	}
+	config.Bind()
+	return true
+}
+
+func logSkippedFiles(skippedFiles []*SkippedFile) {
+	for _, skippedFile := range skippedFiles {
+		slog.Warn("skipping certificate file", slog.String("file", skippedFile.Path), slog.Any("reason", skippedFile.Reason))
+	}
+}
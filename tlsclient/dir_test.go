@@ -0,0 +1,119 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsclient_test
+
+import (
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf"
+	"github.com/tdrn-org/go-tlsconf/tlsclient"
+	"github.com/tdrn-org/go-tlsconf/tlsserver"
+)
+
+func TestLoadCertificatesFromDir(t *testing.T) {
+	dir := t.TempDir()
+	certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	_, keyFile, err := tlsconf.WriteCertificate(certificate, dir, "pem")
+	require.NoError(t, err)
+	err = os.Symlink("pem.crt", filepath.Join(dir, "12345678.0"))
+	require.NoError(t, err)
+	err = os.Symlink("missing.crl", filepath.Join(dir, "12345678.r0"))
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(dir, "der.cer"), certificate.Certificate[0], 0644)
+	require.NoError(t, err)
+	err = os.Mkdir(filepath.Join(dir, "subdir"), 0755)
+	require.NoError(t, err)
+
+	certificates, skippedFiles, err := tlsclient.LoadCertificatesFromDir(dir)
+	require.NoError(t, err)
+	require.Len(t, certificates, 2)
+	require.Len(t, skippedFiles, 2)
+	skippedFileReasons := make(map[string]error)
+	for _, skippedFile := range skippedFiles {
+		skippedFileReasons[skippedFile.Path] = skippedFile.Reason
+	}
+	require.ErrorIs(t, skippedFileReasons[keyFile], tlsconf.ErrNoCertificates)
+	require.ErrorIs(t, skippedFileReasons[keyFile], tlsclient.ErrNoCertificates)
+	require.ErrorIs(t, skippedFileReasons[filepath.Join(dir, "pem.crt")], tlsclient.ErrDuplicateLink)
+}
+
+func TestClientWithAddCertificatesFromDir(t *testing.T) {
+	err := tlsserver.SetOptions(tlsserver.UseEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour))
+	require.NoError(t, err)
+	dir := t.TempDir()
+	certFile, _, err := tlsconf.WriteCertificate(&tlsserver.GetConfig().Certificates[0], dir, "localhost")
+	require.NoError(t, err)
+
+	err = tlsclient.SetOptions(tlsclient.IgnoreSystemCerts(), tlsclient.AddCertificatesFromDir(dir))
+	require.NoError(t, err)
+	require.Equal(t, []string{certFile}, tlsclient.CertificateFiles())
+	result := testHandshake(t)
+	require.NoError(t, result.Err())
+}
+
+func TestClientWithWatchCertificatesDir(t *testing.T) {
+	err := tlsserver.SetOptions(tlsserver.UseEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour))
+	require.NoError(t, err)
+	dir := t.TempDir()
+
+	err = tlsclient.SetOptions(tlsclient.IgnoreSystemCerts(), tlsclient.WatchCertificatesDir(t.Context(), dir, 10*time.Millisecond))
+	require.NoError(t, err)
+	require.False(t, tlsclient.GetConfig().InsecureSkipVerify)
+	require.NotContains(t, lintIDs(tlsconf.Lint(tlsclient.GetConfig())), tlsconf.LintInsecureSkipVerify)
+	require.Empty(t, tlsclient.CertificateFiles())
+	result := testHandshake(t)
+	require.Error(t, result.ClientErr)
+
+	certFile, _, err := tlsconf.WriteCertificate(&tlsserver.GetConfig().Certificates[0], dir, "localhost")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return testHandshake(t).Err() == nil
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{certFile}, tlsclient.CertificateFiles())
+
+	err = os.Remove(certFile)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return testHandshake(t).Err() != nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestClientWithWatchCertificatesDirReplaced(t *testing.T) {
+	err := tlsserver.SetOptions(tlsserver.UseEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour))
+	require.NoError(t, err)
+	dir := t.TempDir()
+
+	err = tlsclient.WatchCertificatesDir(t.Context(), dir, 10*time.Millisecond)(&tls.Config{})
+	require.Error(t, err)
+	err = tlsclient.SetOptions(tlsclient.WatchCertificatesDir(t.Context(), dir, 0))
+	require.ErrorContains(t, err, "invalid certificate directory watch interval")
+
+	err = tlsclient.SetOptions(tlsclient.IgnoreSystemCerts(), tlsclient.WatchCertificatesDir(t.Context(), dir, 10*time.Millisecond))
+	require.NoError(t, err)
+	err = tlsclient.SetOptions(tlsclient.IgnoreSystemCerts())
+	require.NoError(t, err)
+	config := tlsclient.GetConfig()
+	_, _, err = tlsconf.WriteCertificate(&tlsserver.GetConfig().Certificates[0], dir, "localhost")
+	require.NoError(t, err)
+	require.Never(t, func() bool {
+		return tlsclient.GetConfig() != config
+	}, 100*time.Millisecond, 10*time.Millisecond)
+}
+
+func lintIDs(findings tlsconf.LintFindings) []tlsconf.LintID {
+	ids := make([]tlsconf.LintID, 0, len(findings))
+	for _, finding := range findings {
+		ids = append(ids, finding.ID)
+	}
+	return ids
+}
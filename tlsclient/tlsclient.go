@@ -12,6 +12,8 @@ import (
 	"log/slog"
 	"net/http"
 	"reflect"
+	"slices"
+	"sync"
 
 	"github.com/tdrn-org/go-conf"
 	"github.com/tdrn-org/go-tlsconf"
@@ -20,6 +22,8 @@ import (
 // Config defines the bindable configuration object holding the client [tls.Config] instance.
 type Config struct {
 	tls.Config
+	options          []tlsconf.TLSConfigOption
+	certificateFiles []string
 }
 
 func (c *Config) Type() reflect.Type {
@@ -30,17 +34,38 @@ func (c *Config) Bind() {
 	conf.BindConfiguration(c)
 }
 
+var setOptionsLock sync.Mutex = sync.Mutex{}
+
 // SetOptions applies the given options to the client [tls.Config] instance.
+//
+// If a global [tlsconf.Policy] has been set, it is enforced after applying the options.
 func SetOptions(options ...tlsconf.TLSConfigOption) error {
-	config := &Config{}
+	setOptionsLock.Lock()
+	defer setOptionsLock.Unlock()
+	config, err := newConfig(options)
+	if err != nil {
+		return err
+	}
+	config.Bind()
+	return nil
+}
+
+// newConfig creates a new [Config] by applying the given options. The caller must hold setOptionsLock.
+func newConfig(options []tlsconf.TLSConfigOption) (*Config, error) {
+	config := &Config{options: options}
+	applyingConfig.Store(config)
+	defer applyingConfig.Store(nil)
 	for _, option := range options {
 		err := option(&config.Config)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
-	config.Bind()
-	return nil
+	err := tlsconf.EnforceGlobalPolicy(&config.Config)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
 }
 
 // GetConfig returns the client [tls.Config] instance.
@@ -49,6 +74,14 @@ func GetConfig() *tls.Config {
 	return &tlsClientConfig.Config
 }
 
+// CertificateFiles returns the names of the certificate files added to the client
+// [tls.Config] instance via [AddCertificatesFromFile], [AddCertificatesFromDir] or
+// [WatchCertificatesDir].
+func CertificateFiles() []string {
+	tlsClientConfig, _ := conf.LookupConfiguration[*Config]()
+	return slices.Clone(tlsClientConfig.certificateFiles)
+}
+
 // ApplyConfig applies the client [tls.Config] instance to the given [http.Client].
 //
 // If the given [http.Client]'s Transport is already configured, the [http.Client]
@@ -67,6 +100,13 @@ func ApplyConfig(client *http.Client) *http.Client {
 	return client
 }
 
+// EnableHandshakeLogging logs every TLS handshake of the client [tls.Config] and invokes the given hooks.
+//
+// See [tlsconf.EnableHandshakeLoggingWithTimeout] for details.
+func EnableHandshakeLogging(logger *slog.Logger, sampler tlsconf.HandshakeSampler, hooks ...tlsconf.HandshakeHook) tlsconf.TLSConfigOption {
+	return tlsconf.EnableHandshakeLogging(tlsconf.HandshakeSideClient, logger, sampler, hooks...)
+}
+
 func init() {
 	(&Config{}).Bind()
 }
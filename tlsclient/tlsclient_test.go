@@ -7,6 +7,7 @@
 package tlsclient_test
 
 import (
+	"crypto/tls"
 	"net"
 	"net/http"
 	"testing"
@@ -17,6 +18,7 @@ import (
 	"github.com/tdrn-org/go-tlsconf"
 	"github.com/tdrn-org/go-tlsconf/tlsclient"
 	"github.com/tdrn-org/go-tlsconf/tlsserver"
+	"github.com/tdrn-org/go-tlsconf/tlstest"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -38,65 +40,131 @@ func TestClientWithIgnoreSystemCerts(t *testing.T) {
 func TestClientWithoutAddServerCertificates(t *testing.T) {
 	err := tlsserver.SetOptions(tlsserver.UseEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour))
 	require.NoError(t, err)
-	serverURL, server := startTestServer(t)
-
-	testTLSFailure(t, serverURL)
-
-	server.Shutdown(t.Context())
-	server.Close()
+	result := testHandshake(t)
+	require.Error(t, result.ClientErr)
 }
 
 func TestClientWithAddServerConfigCertificates(t *testing.T) {
 	err := tlsserver.SetOptions(tlsserver.UseEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour))
 	require.NoError(t, err)
-	serverURL, server := startTestServer(t)
-
 	err = tlsclient.SetOptions(tlsclient.AddServerConfigCertificates())
 	require.NoError(t, err)
-	testTLSSuccess(t, serverURL)
-
-	server.Shutdown(t.Context())
-	server.Close()
+	result := testHandshake(t)
+	require.NoError(t, result.Err())
 }
 
 func TestClientWithAddCertificatesFromFile(t *testing.T) {
 	err := tlsserver.SetOptions(tlsserver.UseEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour))
 	require.NoError(t, err)
-	serverURL, server := startTestServer(t)
 	dir := t.TempDir()
-	certFile, _, err := tlsconf.WriteCertificate(&server.TLSConfig.Certificates[0], dir, "localhost")
+	certFile, _, err := tlsconf.WriteCertificate(&tlsserver.GetConfig().Certificates[0], dir, "localhost")
 	require.NoError(t, err)
 
 	err = tlsclient.SetOptions(tlsclient.AddCertificatesFromFile(certFile))
 	require.NoError(t, err)
-	testTLSSuccess(t, serverURL)
+	require.Equal(t, []string{certFile}, tlsclient.CertificateFiles())
+	result := testHandshake(t)
+	require.NoError(t, result.Err())
 
-	server.Shutdown(t.Context())
-	server.Close()
+	config := &tls.Config{}
+	err = tlsclient.AddCertificatesFromFile(certFile)(config)
+	require.NoError(t, err)
+	err = tlsclient.SetOptions()
+	require.NoError(t, err)
+	require.Empty(t, tlsclient.CertificateFiles())
 }
 
-func testTLSSuccess(t *testing.T, url string) {
+func TestClientWithAddCertificatesFromFileWithoutCertificates(t *testing.T) {
+	certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	_, keyFile, err := tlsconf.WriteCertificate(certificate, t.TempDir(), "localhost")
+	require.NoError(t, err)
+
+	err = tlsclient.SetOptions(tlsclient.AddCertificatesFromFile(keyFile))
+	require.ErrorIs(t, err, tlsconf.ErrNoCertificates)
+	err = tlsclient.SetOptions(tlsclient.AddCertificatesFromFileWithMode(keyFile, tlsconf.DecodeLenient))
+	require.ErrorIs(t, err, tlsconf.ErrNoCertificates)
+}
+
+func TestClientWithUsePKCS12ClientCertificate(t *testing.T) {
+	certificate, err := tlsconf.GenerateEphemeralCertificate("client", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	pfxFile, err := tlsconf.WritePKCS12(certificate, t.TempDir(), "client", "secret", tlsconf.PKCS12EncryptionLegacy)
+	require.NoError(t, err)
+	err = tlsclient.SetOptions(tlsclient.UsePKCS12ClientCertificate(pfxFile, "secret"))
+	require.NoError(t, err)
+	require.Equal(t, certificate.Certificate, tlsclient.GetConfig().Certificates[0].Certificate)
+}
+
+func TestClientWithAddLocalCACertificate(t *testing.T) {
+	store := tlsconf.NewMemoryKeyStore()
+	ca, err := tlsconf.NewLocalCA(store, "ca", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	serverCertificate, err := ca.IssueServerCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	err = store.Put("localhost", serverCertificate)
+	require.NoError(t, err)
+	err = tlsserver.SetOptions(tlsserver.UseKeyStoreCertificate(store, "localhost"))
+	require.NoError(t, err)
+	err = tlsclient.SetOptions(tlsclient.IgnoreSystemCerts(), tlsclient.AddLocalCACertificate(ca))
+	require.NoError(t, err)
+	result := testHandshake(t)
+	require.NoError(t, result.Err())
+}
+
+func TestClientWithHandshakeLogging(t *testing.T) {
+	serverEvents := make(chan *tlsconf.HandshakeEvent, 1)
+	err := tlsserver.SetOptions(tlsserver.UseEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour), tlsserver.EnableHandshakeLogging(nil, nil, func(event *tlsconf.HandshakeEvent) {
+		serverEvents <- event
+	}))
+	require.NoError(t, err)
+	clientEvents := make(chan *tlsconf.HandshakeEvent, 1)
+	err = tlsclient.SetOptions(tlsclient.AddServerConfigCertificates(), tlsclient.EnableHandshakeLogging(nil, nil, func(event *tlsconf.HandshakeEvent) {
+		clientEvents <- event
+	}))
+	require.NoError(t, err)
+	inner, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	server := tlsserver.ApplyConfig(&http.Server{Handler: http.NotFoundHandler(), ReadHeaderTimeout: time.Second})
+	go server.ServeTLS(inner, "", "")
+	defer server.Close()
+
 	client := tlsclient.ApplyConfig(&http.Client{})
-	_, err := client.Get(url)
+	_, port, err := net.SplitHostPort(inner.Addr().String())
+	require.NoError(t, err)
+	rsp, err := client.Get("https://localhost:" + port)
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+	require.Equal(t, http.StatusNotFound, rsp.StatusCode)
+	clientEvent := <-clientEvents
+	require.NoError(t, clientEvent.Err)
+	require.Equal(t, tlsconf.HandshakeSideClient, clientEvent.Side)
+	require.Equal(t, "CN=localhost", clientEvent.PeerSubject)
+	serverEvent := <-serverEvents
+	require.NoError(t, serverEvent.Err)
+	require.Equal(t, tlsconf.HandshakeSideServer, serverEvent.Side)
+	require.Equal(t, "localhost", serverEvent.ServerName)
+}
+
+func testTLSSuccess(t *testing.T, url string) {
+	err := testTLS(url)
 	require.NoError(t, err)
 }
 
 func testTLSFailure(t *testing.T, url string) {
-	client := tlsclient.ApplyConfig(&http.Client{})
-	_, err := client.Get(url)
+	err := testTLS(url)
 	require.Error(t, err)
 }
 
-func startTestServer(t *testing.T) (string, *http.Server) {
-	listener, err := net.Listen("tcp", "localhost:0")
-	require.NoError(t, err)
-	server := tlsserver.ApplyConfig(&http.Server{})
-	go func() {
-		err := server.ServeTLS(listener, "", "")
-		require.ErrorIs(t, err, http.ErrServerClosed)
-	}()
-	_, port, err := net.SplitHostPort(listener.Addr().String())
-	require.NoError(t, err)
-	serverURL := "https://localhost:" + port
-	return serverURL, server
+func testTLS(url string) error {
+	client := tlsclient.ApplyConfig(&http.Client{})
+	rsp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	return rsp.Body.Close()
+}
+
+func testHandshake(t *testing.T) *tlstest.HandshakeResult {
+	return tlstest.Handshake(t.Context(), tlsclient.GetConfig(), tlsserver.GetConfig(), "localhost")
 }
@@ -7,11 +7,13 @@
 package tlsclient
 
 import (
+	"crypto"
 	"crypto/tls"
 	"crypto/x509"
-	"encoding/pem"
 	"fmt"
+	"log/slog"
 	"os"
+	"sync/atomic"
 
 	"github.com/tdrn-org/go-conf"
 	"github.com/tdrn-org/go-tlsconf"
@@ -27,6 +29,62 @@ func IgnoreSystemCerts() tlsconf.TLSConfigOption {
 	}
 }
 
+// UseClientCertificate loads the certificate from the given certificate and key files and sets
+// it as the client [tls.Config]'s client certificate.
+//
+// See [tlsconf.LoadCertificate] for the supported file formats.
+func UseClientCertificate(certFile, keyFile string, passphrase tlsconf.PassphraseFunc) tlsconf.TLSConfigOption {
+	return func(config *tls.Config) error {
+		certificate, err := tlsconf.LoadCertificate(certFile, keyFile, passphrase)
+		if err != nil {
+			return err
+		}
+		config.Certificates = []tls.Certificate{*certificate}
+		return nil
+	}
+}
+
+// UseSignerClientCertificate loads the certificate chain from the given file and sets it
+// together with the given signer as the client [tls.Config]'s client certificate.
+//
+// See [tlsconf.LoadSignerCertificate] for details.
+func UseSignerClientCertificate(certFile string, signer crypto.Signer) tlsconf.TLSConfigOption {
+	return func(config *tls.Config) error {
+		certificate, err := tlsconf.LoadSignerCertificate(certFile, signer)
+		if err != nil {
+			return err
+		}
+		config.Certificates = []tls.Certificate{*certificate}
+		return nil
+	}
+}
+
+// UseKeyStoreClientCertificate retrieves the named certificate from the given [tlsconf.KeyStore]
+// and sets it as the client [tls.Config]'s client certificate.
+func UseKeyStoreClientCertificate(store tlsconf.KeyStore, name string) tlsconf.TLSConfigOption {
+	return func(config *tls.Config) error {
+		certificate, err := store.Get(name)
+		if err != nil {
+			return err
+		}
+		config.Certificates = []tls.Certificate{*certificate}
+		return nil
+	}
+}
+
+// UsePKCS12ClientCertificate loads the certificate from the given PKCS#12 file and sets
+// it as the client [tls.Config]'s client certificate.
+func UsePKCS12ClientCertificate(pfxFile, password string) tlsconf.TLSConfigOption {
+	return func(config *tls.Config) error {
+		certificate, err := tlsconf.LoadPKCS12(pfxFile, password)
+		if err != nil {
+			return err
+		}
+		config.Certificates = []tls.Certificate{*certificate}
+		return nil
+	}
+}
+
 // AddServerConfigCertificates retrieves the certificates defined in the server [tls.Config]
 // and adds them to the client [tls.Config]'s RootCA pool.
 //
@@ -48,11 +106,40 @@ func AddServerConfigCertificates() tlsconf.TLSConfigOption {
 	}
 }
 
+// AddLocalCACertificate adds the CA certificate of the given [tlsconf.LocalCA] to the
+// client [tls.Config]'s RootCA pool.
+//
+// If the current config's RootCA pool is nil, the result pool is based on the system CAs.
+func AddLocalCACertificate(ca *tlsconf.LocalCA) tlsconf.TLSConfigOption {
+	return func(config *tls.Config) error {
+		rootCAs, err := configRootCAs(config)
+		if err != nil {
+			return err
+		}
+		rootCAs.AddCert(ca.Certificate())
+		config.RootCAs = rootCAs
+		return nil
+	}
+}
+
 // AddCertificatesFromFile adds the certificates from the given file to the client
 // [tls.Config]'s RootCA pool.
 //
+// The file format (PEM, DER or PKCS#7) is detected automatically (see [tlsconf.DecodeCertificates]).
+// The file is decoded in [tlsconf.DecodeStrict] mode, hence a file containing malformed
+// blocks or no certificates at all is rejected.
 // If the current config's RootCA pool is nil, the result pool is based on the system CAs.
 func AddCertificatesFromFile(certFile string) tlsconf.TLSConfigOption {
+	return AddCertificatesFromFileWithMode(certFile, tlsconf.DecodeStrict)
+}
+
+// AddCertificatesFromFileWithMode adds the certificates from the given file to the client
+// [tls.Config]'s RootCA pool using the given [tlsconf.DecodeMode].
+//
+// In [tlsconf.DecodeLenient] mode malformed blocks are logged and skipped. A file without
+// any certificate is rejected in both modes.
+// If the current config's RootCA pool is nil, the result pool is based on the system CAs.
+func AddCertificatesFromFileWithMode(certFile string, mode tlsconf.DecodeMode) tlsconf.TLSConfigOption {
 	return func(config *tls.Config) error {
 		rootCAs, err := configRootCAs(config)
 		if err != nil {
@@ -62,30 +149,31 @@ func AddCertificatesFromFile(certFile string) tlsconf.TLSConfigOption {
 		if err != nil {
 			return fmt.Errorf("failed to read certificate file '%s' (cause: %w)", certFile, err)
 		}
-		decodeCertificates(rootCAs, certData)
+		certificates, err := tlsconf.DecodeCertificates(certData, mode)
+		if len(certificates) == 0 {
+			return fmt.Errorf("failed to decode certificate file '%s' (cause: %w)", certFile, err)
+		}
+		if err != nil {
+			slog.Warn("skipping malformed blocks in certificate file", slog.String("file", certFile), slog.Any("err", err))
+		}
+		for _, certificate := range certificates {
+			rootCAs.AddCert(certificate)
+		}
 		config.RootCAs = rootCAs
+		recordCertificateFiles(config, certFile)
 		return nil
 	}
 }
 
-func decodeCertificates(pool *x509.CertPool, certData []byte) error {
-	rest := certData
-	for {
-		if len(rest) == 0 {
-			return nil
-		}
-		var pemBlock *pem.Block
-		pemBlock, rest = pem.Decode(rest)
-		if pemBlock == nil {
-			return fmt.Errorf("failed to decode PEM block")
-		}
-		if pemBlock.Type == "CERTIFICATE" {
-			cert, err := x509.ParseCertificate(pemBlock.Bytes)
-			if err != nil {
-				return fmt.Errorf("failed to parse X.509 certificate (cause: %w)", err)
-			}
-			pool.AddCert(cert)
-		}
+// applyingConfig refers to the [Config] wrapper [SetOptions] is currently applying its options to.
+var applyingConfig atomic.Pointer[Config]
+
+// recordCertificateFiles records the given certificate files in the [Config] wrapper of the given
+// [tls.Config]. Configs not being set up via [SetOptions] are not tracked.
+func recordCertificateFiles(config *tls.Config, certFiles ...string) {
+	wrapper := applyingConfig.Load()
+	if wrapper != nil && &wrapper.Config == config {
+		wrapper.certificateFiles = append(wrapper.certificateFiles, certFiles...)
 	}
 }
 
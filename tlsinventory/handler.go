@@ -0,0 +1,78 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsinventory
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// Handler returns a [http.Handler] reporting the result of [Collect] as a JSON array.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		body, err := json.Marshal(Collect())
+		if err != nil {
+			slog.Error("failed to marshal certificate inventory", slog.Any("err", err))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	})
+}
+
+// MetricsContentType defines the content type of the metrics reported by [MetricsHandler].
+const MetricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// MetricsHandler returns a [http.Handler] reporting the result of [Collect] in
+// Prometheus text exposition format.
+//
+// The following gauges are reported per certificate:
+//   - tlsconf_certificate_not_before_seconds: Start of the validity period (Unix time)
+//   - tlsconf_certificate_not_after_seconds: End of the validity period (Unix time)
+//   - tlsconf_certificate_days_remaining: Days remaining until the certificate expires
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", MetricsContentType)
+		w.Write(FormatMetrics(Collect()))
+	})
+}
+
+// FormatMetrics formats the given [CertificateRecord]s in Prometheus text exposition format.
+func FormatMetrics(records []*CertificateRecord) []byte {
+	buffer := &bytes.Buffer{}
+	writeMetric(buffer, "tlsconf_certificate_not_before_seconds", "Start of the certificate validity period in seconds since the epoch.", records, func(record *CertificateRecord) int64 {
+		return record.NotBefore.Unix()
+	})
+	writeMetric(buffer, "tlsconf_certificate_not_after_seconds", "End of the certificate validity period in seconds since the epoch.", records, func(record *CertificateRecord) int64 {
+		return record.NotAfter.Unix()
+	})
+	writeMetric(buffer, "tlsconf_certificate_days_remaining", "Days remaining until the certificate expires.", records, func(record *CertificateRecord) int64 {
+		return int64(record.DaysRemaining)
+	})
+	return buffer.Bytes()
+}
+
+func writeMetric(buffer *bytes.Buffer, name, help string, records []*CertificateRecord, value func(*CertificateRecord) int64) {
+	fmt.Fprintf(buffer, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(buffer, "# TYPE %s gauge\n", name)
+	for _, record := range records {
+		fmt.Fprintf(buffer, "%s{source=\"%s\",subject=\"%s\",issuer=\"%s\",serial=\"%s\"} %d\n", name,
+			escapeLabelValue(record.Source), escapeLabelValue(record.Subject), escapeLabelValue(record.Issuer), escapeLabelValue(record.SerialNumber),
+			value(record))
+	}
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
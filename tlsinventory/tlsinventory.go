@@ -0,0 +1,147 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+// Package tlsinventory provides functions to collect and report the certificates in use.
+package tlsinventory
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"time"
+
+	"github.com/tdrn-org/go-tlsconf"
+	"github.com/tdrn-org/go-tlsconf/tlsclient"
+	"github.com/tdrn-org/go-tlsconf/tlsserver"
+)
+
+const (
+	SourceServer   string = "server" // Certificate from the server [tls.Config]
+	SourceClient   string = "client" // Certificate from the client [tls.Config]
+	SourceCAPrefix string = "ca:"    // Prefix for certificates loaded from a CA file (followed by the file name)
+)
+
+// CertificateRecord contains the inventory information of a single certificate.
+type CertificateRecord struct {
+	Source         string    `json:"source"`
+	Subject        string    `json:"subject"`
+	DNSNames       []string  `json:"dnsNames,omitempty"`
+	IPAddresses    []string  `json:"ipAddresses,omitempty"`
+	EmailAddresses []string  `json:"emailAddresses,omitempty"`
+	URIs           []string  `json:"uris,omitempty"`
+	Issuer         string    `json:"issuer"`
+	SerialNumber   string    `json:"serialNumber"`
+	NotBefore      time.Time `json:"notBefore"`
+	NotAfter       time.Time `json:"notAfter"`
+	DaysRemaining  int       `json:"daysRemaining"`
+	KeyAlgorithm   string    `json:"keyAlgorithm"`
+	KeySize        int       `json:"keySize"`
+	IsCA           bool      `json:"isCA"`
+}
+
+// NewCertificateRecord creates the [CertificateRecord] for the given certificate.
+//
+// The remaining days are calculated relative to the given time. Already expired
+// certificates have a negative number of remaining days.
+func NewCertificateRecord(source string, certificate *x509.Certificate, now time.Time) *CertificateRecord {
+	record := &CertificateRecord{
+		Source:         source,
+		Subject:        certificate.Subject.String(),
+		DNSNames:       certificate.DNSNames,
+		EmailAddresses: certificate.EmailAddresses,
+		Issuer:         certificate.Issuer.String(),
+		SerialNumber:   certificate.SerialNumber.Text(16),
+		NotBefore:      certificate.NotBefore,
+		NotAfter:       certificate.NotAfter,
+		DaysRemaining:  int(math.Floor(certificate.NotAfter.Sub(now).Hours() / 24)),
+		IsCA:           certificate.IsCA,
+	}
+	for _, ipAddress := range certificate.IPAddresses {
+		record.IPAddresses = append(record.IPAddresses, ipAddress.String())
+	}
+	for _, uri := range certificate.URIs {
+		record.URIs = append(record.URIs, uri.String())
+	}
+	record.KeyAlgorithm, record.KeySize = keyAlgorithmAndSize(certificate)
+	return record
+}
+
+func keyAlgorithmAndSize(certificate *x509.Certificate) (string, int) {
+	switch publicKey := certificate.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return "RSA", publicKey.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA", publicKey.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", ed25519.PublicKeySize * 8
+	}
+	return certificate.PublicKeyAlgorithm.String(), 0
+}
+
+// CertificateRecords creates the [CertificateRecord]s for all certificates in the
+// given [tls.Certificate]'s chain.
+func CertificateRecords(source string, certificate *tls.Certificate, now time.Time) ([]*CertificateRecord, error) {
+	records := make([]*CertificateRecord, 0, len(certificate.Certificate))
+	for _, certificateBytes := range certificate.Certificate {
+		x509Certificate, err := x509.ParseCertificate(certificateBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse X.509 certificate (cause: %w)", err)
+		}
+		records = append(records, NewCertificateRecord(source, x509Certificate, now))
+	}
+	return records, nil
+}
+
+// Collect collects the [CertificateRecord]s for all certificates currently in use.
+//
+// The collected certificates are the ones of the server [tls.Config], the client [tls.Config]
+// as well as the CA certificates added to the client [tls.Config] via [tlsclient.AddCertificatesFromFile],
+// [tlsclient.AddCertificatesFromDir] or [tlsclient.WatchCertificatesDir] (see [tlsclient.CertificateFiles]).
+// CA files are re-read during collection, hence reflecting their current content. Certificates or files failing to decode are skipped and logged.
+func Collect() []*CertificateRecord {
+	now := time.Now()
+	records := make([]*CertificateRecord, 0)
+	records = appendTLSConfigRecords(records, SourceServer, tlsserver.GetConfig(), now)
+	records = appendTLSConfigRecords(records, SourceClient, tlsclient.GetConfig(), now)
+	for _, certFile := range tlsclient.CertificateFiles() {
+		records = appendCertificateFileRecords(records, certFile, now)
+	}
+	return records
+}
+
+func appendTLSConfigRecords(records []*CertificateRecord, source string, config *tls.Config, now time.Time) []*CertificateRecord {
+	for _, certificate := range config.Certificates {
+		certificateRecords, err := CertificateRecords(source, &certificate, now)
+		if err != nil {
+			slog.Warn("failed to collect certificate", slog.String("source", source), slog.Any("err", err))
+			continue
+		}
+		records = append(records, certificateRecords...)
+	}
+	return records
+}
+
+func appendCertificateFileRecords(records []*CertificateRecord, certFile string, now time.Time) []*CertificateRecord {
+	certData, err := os.ReadFile(certFile)
+	if err != nil {
+		slog.Warn("failed to read certificate file", slog.String("file", certFile), slog.Any("err", err))
+		return records
+	}
+	certificates, err := tlsconf.DecodeCertificates(certData, tlsconf.DecodeLenient)
+	if err != nil {
+		slog.Warn("failed to decode certificate file", slog.String("file", certFile), slog.Any("err", err))
+	}
+	for _, certificate := range certificates {
+		records = append(records, NewCertificateRecord(SourceCAPrefix+certFile, certificate, now))
+	}
+	return records
+}
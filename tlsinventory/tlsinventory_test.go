@@ -0,0 +1,99 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsinventory_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf"
+	"github.com/tdrn-org/go-tlsconf/tlsclient"
+	"github.com/tdrn-org/go-tlsconf/tlsinventory"
+	"github.com/tdrn-org/go-tlsconf/tlsserver"
+)
+
+func TestCollect(t *testing.T) {
+	certFile := setupTestConfig(t)
+	records := tlsinventory.Collect()
+	require.Len(t, records, 2)
+	require.Equal(t, tlsinventory.SourceServer, records[0].Source)
+	require.Equal(t, "CN=localhost", records[0].Subject)
+	require.Equal(t, []string{"localhost"}, records[0].DNSNames)
+	require.Equal(t, "ECDSA", records[0].KeyAlgorithm)
+	require.Equal(t, 256, records[0].KeySize)
+	require.InDelta(t, 30, records[0].DaysRemaining, 1)
+	require.Equal(t, tlsinventory.SourceCAPrefix+certFile, records[1].Source)
+	require.Equal(t, records[0].SerialNumber, records[1].SerialNumber)
+}
+
+func TestCollectCertificatesDir(t *testing.T) {
+	err := tlsserver.SetOptions(tlsserver.UseEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, 30*24*time.Hour+time.Hour))
+	require.NoError(t, err)
+	addDir := t.TempDir()
+	addCertFile, _, err := tlsconf.WriteCertificate(&tlsserver.GetConfig().Certificates[0], addDir, "localhost")
+	require.NoError(t, err)
+	watchDir := t.TempDir()
+	watchCertFile, _, err := tlsconf.WriteCertificate(&tlsserver.GetConfig().Certificates[0], watchDir, "localhost")
+	require.NoError(t, err)
+	err = tlsclient.SetOptions(tlsclient.IgnoreSystemCerts(), tlsclient.AddCertificatesFromDir(addDir), tlsclient.WatchCertificatesDir(t.Context(), watchDir, time.Hour))
+	require.NoError(t, err)
+	records := tlsinventory.Collect()
+	require.Len(t, records, 3)
+	require.Equal(t, tlsinventory.SourceServer, records[0].Source)
+	require.Equal(t, tlsinventory.SourceCAPrefix+addCertFile, records[1].Source)
+	require.Equal(t, records[0].SerialNumber, records[1].SerialNumber)
+	require.Equal(t, tlsinventory.SourceCAPrefix+watchCertFile, records[2].Source)
+	require.Equal(t, records[0].SerialNumber, records[2].SerialNumber)
+}
+
+func TestHandler(t *testing.T) {
+	setupTestConfig(t)
+	rsp := httptest.NewRecorder()
+	tlsinventory.Handler().ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, rsp.Code)
+	require.Equal(t, "application/json", rsp.Header().Get("Content-Type"))
+	records := make([]*tlsinventory.CertificateRecord, 0)
+	err := json.Unmarshal(rsp.Body.Bytes(), &records)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+}
+
+func TestMetricsHandler(t *testing.T) {
+	setupTestConfig(t)
+	rsp := httptest.NewRecorder()
+	tlsinventory.MetricsHandler().ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rsp.Code)
+	require.Equal(t, tlsinventory.MetricsContentType, rsp.Header().Get("Content-Type"))
+	metrics := rsp.Body.String()
+	require.Contains(t, metrics, "# TYPE tlsconf_certificate_not_after_seconds gauge\n")
+	require.Contains(t, metrics, "tlsconf_certificate_days_remaining{source=\"server\",subject=\"CN=localhost\"")
+	require.Equal(t, 3*(2+2), strings.Count(metrics, "\n"))
+}
+
+func TestFormatMetricsEscaping(t *testing.T) {
+	records := []*tlsinventory.CertificateRecord{{
+		Source:  "ca:C:\\certs\\ca.crt",
+		Subject: "CN=\"quoted\"",
+	}}
+	metrics := string(tlsinventory.FormatMetrics(records))
+	require.Contains(t, metrics, `source="ca:C:\\certs\\ca.crt",subject="CN=\"quoted\""`)
+}
+
+func setupTestConfig(t *testing.T) string {
+	err := tlsserver.SetOptions(tlsserver.UseEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, 30*24*time.Hour+time.Hour))
+	require.NoError(t, err)
+	certFile, _, err := tlsconf.WriteCertificate(&tlsserver.GetConfig().Certificates[0], t.TempDir(), "localhost")
+	require.NoError(t, err)
+	err = tlsclient.SetOptions(tlsclient.IgnoreSystemCerts(), tlsclient.AddCertificatesFromFile(certFile))
+	require.NoError(t, err)
+	return certFile
+}
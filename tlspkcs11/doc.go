@@ -0,0 +1,14 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+// Package tlspkcs11 provides access to private keys stored on PKCS#11 tokens (e.g. HSMs).
+//
+// Keys never leave the token. Instead they are exposed as [crypto.Signer] and can be used
+// wherever the tlsconf packages accept a signer (e.g. [tlsconf.NewSignerCertificate] or
+// [tlsconf.NewLocalCAFromCertificate]). SoftHSM can be used as a local stand-in for a HSM.
+//
+// This package requires cgo. Without cgo the package is empty.
+package tlspkcs11
@@ -0,0 +1,278 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+//go:build cgo
+
+package tlspkcs11
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/miekg/pkcs11"
+	"github.com/tdrn-org/go-tlsconf"
+)
+
+// PKCS#11 v3.0 constants not defined by the pkcs11 package
+const (
+	ckkECEdwards              = 0x00000040
+	ckmECEdwardsKeyPairGen    = 0x00001055
+	ckmEdDSA                  = 0x00001057
+	rsaDefaultPublicExponent  = 65537
+	ecdsaSignatureMaxByteSize = 132
+)
+
+var (
+	oidNamedCurveP224 = asn1.ObjectIdentifier{1, 3, 132, 0, 33}
+	oidNamedCurveP256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}
+	oidNamedCurveP384 = asn1.ObjectIdentifier{1, 3, 132, 0, 34}
+	oidNamedCurveP521 = asn1.ObjectIdentifier{1, 3, 132, 0, 35}
+	oidEd25519        = asn1.ObjectIdentifier{1, 3, 101, 112}
+)
+
+var namedCurves = []struct {
+	oid   asn1.ObjectIdentifier
+	curve elliptic.Curve
+}{
+	{oidNamedCurveP224, elliptic.P224()},
+	{oidNamedCurveP256, elliptic.P256()},
+	{oidNamedCurveP384, elliptic.P384()},
+	{oidNamedCurveP521, elliptic.P521()},
+}
+
+func keyGenerationTemplate(algorithm tlsconf.CertificateAlgorithm) (uint, []*pkcs11.Attribute, error) {
+	rsaTemplate := func(bits int) []*pkcs11.Attribute {
+		return []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_RSA),
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS_BITS, bits),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, big.NewInt(rsaDefaultPublicExponent).Bytes()),
+		}
+	}
+	ecTemplate := func(keyType uint, oid asn1.ObjectIdentifier) []*pkcs11.Attribute {
+		params, _ := asn1.Marshal(oid)
+		return []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, keyType),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, params),
+		}
+	}
+	switch algorithm {
+	case tlsconf.CertificateAlgorithmRSA2048:
+		return pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN, rsaTemplate(2048), nil
+	case tlsconf.CertificateAlgorithmRSA3072:
+		return pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN, rsaTemplate(3072), nil
+	case tlsconf.CertificateAlgorithmRSA4096:
+		return pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN, rsaTemplate(4096), nil
+	case tlsconf.CertificateAlgorithmRSA8192:
+		return pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN, rsaTemplate(8192), nil
+	case tlsconf.CertificateAlgorithmECDSA224:
+		return pkcs11.CKM_EC_KEY_PAIR_GEN, ecTemplate(pkcs11.CKK_EC, oidNamedCurveP224), nil
+	case tlsconf.CertificateAlgorithmECDSA256, tlsconf.CertificateAlgorithmDefault:
+		return pkcs11.CKM_EC_KEY_PAIR_GEN, ecTemplate(pkcs11.CKK_EC, oidNamedCurveP256), nil
+	case tlsconf.CertificateAlgorithmECDSA384:
+		return pkcs11.CKM_EC_KEY_PAIR_GEN, ecTemplate(pkcs11.CKK_EC, oidNamedCurveP384), nil
+	case tlsconf.CertificateAlgorithmECDSA521:
+		return pkcs11.CKM_EC_KEY_PAIR_GEN, ecTemplate(pkcs11.CKK_EC, oidNamedCurveP521), nil
+	case tlsconf.CertificateAlgorithmED25519:
+		return ckmECEdwardsKeyPairGen, ecTemplate(ckkECEdwards, oidEd25519), nil
+	}
+	return 0, nil, fmt.Errorf("unrecognized certificate algorithm: %s", algorithm)
+}
+
+func (token *Token) decodePublicKey(publicKey pkcs11.ObjectHandle) (crypto.PublicKey, error) {
+	ctx := token.module.ctx
+	attributes, err := ctx.GetAttributeValue(token.session, publicKey, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, nil)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PKCS#11 public key type (cause: %w)", err)
+	}
+	keyType := decodeULong(attributes[0].Value)
+	switch keyType {
+	case pkcs11.CKK_RSA:
+		attributes, err := ctx.GetAttributeValue(token.session, publicKey, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, nil),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read PKCS#11 RSA public key (cause: %w)", err)
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(attributes[0].Value),
+			E: int(new(big.Int).SetBytes(attributes[1].Value).Int64()),
+		}, nil
+	case pkcs11.CKK_EC, ckkECEdwards:
+		attributes, err := ctx.GetAttributeValue(token.session, publicKey, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read PKCS#11 EC public key (cause: %w)", err)
+		}
+		return decodeECPublicKey(attributes[0].Value, attributes[1].Value)
+	}
+	return nil, fmt.Errorf("unsupported PKCS#11 key type: 0x%x", keyType)
+}
+
+func decodeECPublicKey(params, point []byte) (crypto.PublicKey, error) {
+	// CKA_EC_POINT is a DER encoded OCTET STRING (some tokens omit the encoding)
+	var rawPoint []byte
+	if rest, err := asn1.Unmarshal(point, &rawPoint); err != nil || len(rest) != 0 {
+		rawPoint = point
+	}
+	var oid asn1.ObjectIdentifier
+	_, err := asn1.Unmarshal(params, &oid)
+	if err != nil {
+		// CKA_EC_PARAMS may also be a printable curve name like "edwards25519"
+		var name string
+		if _, err := asn1.Unmarshal(params, &name); err != nil || name != "edwards25519" {
+			return nil, fmt.Errorf("unsupported PKCS#11 EC parameters (cause: %w)", err)
+		}
+		oid = oidEd25519
+	}
+	if oid.Equal(oidEd25519) {
+		if len(rawPoint) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid PKCS#11 Ed25519 public key size: %d", len(rawPoint))
+		}
+		return ed25519.PublicKey(rawPoint), nil
+	}
+	for _, namedCurve := range namedCurves {
+		if oid.Equal(namedCurve.oid) {
+			//lint:ignore SA1019 elliptic.Unmarshal is the only way to decode P-224 points
+			x, y := elliptic.Unmarshal(namedCurve.curve, rawPoint)
+			if x == nil {
+				return nil, fmt.Errorf("invalid PKCS#11 EC point")
+			}
+			return &ecdsa.PublicKey{Curve: namedCurve.curve, X: x, Y: y}, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported PKCS#11 EC curve: %s", oid)
+}
+
+func decodeULong(value []byte) uint {
+	// CK_ULONG values are returned in native byte order
+	decoded := uint(0)
+	for i := len(value) - 1; i >= 0; i-- {
+		decoded = decoded<<8 | uint(value[i])
+	}
+	return decoded
+}
+
+type signer struct {
+	token      *Token
+	privateKey pkcs11.ObjectHandle
+	publicKey  crypto.PublicKey
+}
+
+func newSigner(token *Token, privateKey pkcs11.ObjectHandle, publicKey crypto.PublicKey) (crypto.Signer, error) {
+	switch publicKey.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return &signer{token: token, privateKey: privateKey, publicKey: publicKey}, nil
+	}
+	return nil, fmt.Errorf("unsupported PKCS#11 public key type: %T", publicKey)
+}
+
+func (signer *signer) Public() crypto.PublicKey {
+	return signer.publicKey
+}
+
+func (signer *signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	switch publicKey := signer.publicKey.(type) {
+	case *rsa.PublicKey:
+		if pssOpts, ok := opts.(*rsa.PSSOptions); ok {
+			return signer.signRSAPSS(publicKey, digest, pssOpts)
+		}
+		return signer.signRSAPKCS1v15(digest, opts)
+	case *ecdsa.PublicKey:
+		signature, err := signer.sign(pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil), digest)
+		if err != nil {
+			return nil, err
+		}
+		return encodeECDSASignature(signature)
+	case ed25519.PublicKey:
+		if opts.HashFunc() != crypto.Hash(0) {
+			return nil, fmt.Errorf("unsupported Ed25519 hash: %s", opts.HashFunc())
+		}
+		return signer.sign(pkcs11.NewMechanism(ckmEdDSA, nil), digest)
+	}
+	return nil, fmt.Errorf("unsupported PKCS#11 public key type: %T", signer.publicKey)
+}
+
+// DigestInfo prefixes as defined in RFC 8017 section 9.2
+var rsaDigestInfoPrefixes = map[crypto.Hash][]byte{
+	crypto.SHA1:   {0x30, 0x21, 0x30, 0x09, 0x06, 0x05, 0x2b, 0x0e, 0x03, 0x02, 0x1a, 0x05, 0x00, 0x04, 0x14},
+	crypto.SHA224: {0x30, 0x2d, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x04, 0x05, 0x00, 0x04, 0x1c},
+	crypto.SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384: {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+	crypto.SHA512: {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+}
+
+func (signer *signer) signRSAPKCS1v15(digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	prefix, ok := rsaDigestInfoPrefixes[opts.HashFunc()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported RSA hash: %s", opts.HashFunc())
+	}
+	return signer.sign(pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS, nil), append(prefix, digest...))
+}
+
+var rsaPSSMechanisms = map[crypto.Hash][2]uint{
+	crypto.SHA1:   {pkcs11.CKM_SHA_1, pkcs11.CKG_MGF1_SHA1},
+	crypto.SHA224: {pkcs11.CKM_SHA224, pkcs11.CKG_MGF1_SHA224},
+	crypto.SHA256: {pkcs11.CKM_SHA256, pkcs11.CKG_MGF1_SHA256},
+	crypto.SHA384: {pkcs11.CKM_SHA384, pkcs11.CKG_MGF1_SHA384},
+	crypto.SHA512: {pkcs11.CKM_SHA512, pkcs11.CKG_MGF1_SHA512},
+}
+
+func (signer *signer) signRSAPSS(publicKey *rsa.PublicKey, digest []byte, opts *rsa.PSSOptions) ([]byte, error) {
+	hash := opts.HashFunc()
+	mechanisms, ok := rsaPSSMechanisms[hash]
+	if !ok {
+		return nil, fmt.Errorf("unsupported RSA-PSS hash: %s", hash)
+	}
+	saltLength := opts.SaltLength
+	switch saltLength {
+	case rsa.PSSSaltLengthEqualsHash:
+		saltLength = hash.Size()
+	case rsa.PSSSaltLengthAuto:
+		saltLength = (publicKey.N.BitLen()-1+7)/8 - 2 - hash.Size()
+	}
+	params := pkcs11.NewPSSParams(mechanisms[0], mechanisms[1], uint(saltLength))
+	return signer.sign(pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_PSS, params), digest)
+}
+
+func (signer *signer) sign(mechanism *pkcs11.Mechanism, data []byte) ([]byte, error) {
+	token := signer.token
+	token.mutex.Lock()
+	defer token.mutex.Unlock()
+	err := token.module.ctx.SignInit(token.session, []*pkcs11.Mechanism{mechanism}, signer.privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize PKCS#11 signing (cause: %w)", err)
+	}
+	signature, err := token.module.ctx.Sign(token.session, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign using PKCS#11 token '%s' (cause: %w)", token.label, err)
+	}
+	return signature, nil
+}
+
+// encodeECDSASignature converts the raw r||s signature returned by PKCS#11 into the ASN.1
+// encoding expected by crypto.Signer users.
+func encodeECDSASignature(signature []byte) ([]byte, error) {
+	if len(signature) == 0 || len(signature)%2 != 0 || len(signature) > ecdsaSignatureMaxByteSize {
+		return nil, fmt.Errorf("invalid PKCS#11 ECDSA signature size: %d", len(signature))
+	}
+	half := len(signature) / 2
+	return asn1.Marshal(struct {
+		R, S *big.Int
+	}{
+		R: new(big.Int).SetBytes(signature[:half]),
+		S: new(big.Int).SetBytes(signature[half:]),
+	})
+}
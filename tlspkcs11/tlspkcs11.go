@@ -0,0 +1,386 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+//go:build cgo
+
+package tlspkcs11
+
+import (
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/miekg/pkcs11"
+	"github.com/tdrn-org/go-tlsconf"
+)
+
+// ErrKeyNotFound indicates a non-existing key on a PKCS#11 token.
+var ErrKeyNotFound = errors.New("PKCS#11 key not found")
+
+// KeySelector identifies a key on a PKCS#11 token by label and/or ID (CKA_LABEL and CKA_ID).
+//
+// If both are set, both must match.
+type KeySelector struct {
+	Label string
+	ID    []byte
+}
+
+func (key KeySelector) String() string {
+	if len(key.ID) == 0 {
+		return fmt.Sprintf("label=%s", key.Label)
+	}
+	if key.Label == "" {
+		return fmt.Sprintf("id=%s", hex.EncodeToString(key.ID))
+	}
+	return fmt.Sprintf("label=%s,id=%s", key.Label, hex.EncodeToString(key.ID))
+}
+
+func (key KeySelector) attributes(class uint) []*pkcs11.Attribute {
+	attributes := []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_CLASS, class)}
+	if key.Label != "" {
+		attributes = append(attributes, pkcs11.NewAttribute(pkcs11.CKA_LABEL, key.Label))
+	}
+	if len(key.ID) != 0 {
+		attributes = append(attributes, pkcs11.NewAttribute(pkcs11.CKA_ID, key.ID))
+	}
+	return attributes
+}
+
+// Token provides access to the keys of a single PKCS#11 token.
+//
+// All operations on a token share a single logged in session and are serialized.
+type Token struct {
+	module  *module
+	label   string
+	session pkcs11.SessionHandle
+	mutex   sync.Mutex
+}
+
+type module struct {
+	path string
+	ctx  *pkcs11.Ctx
+	refs int
+}
+
+var modules map[string]*module = make(map[string]*module)
+var modulesLock sync.Mutex = sync.Mutex{}
+
+func openModule(path string) (*module, error) {
+	modulesLock.Lock()
+	defer modulesLock.Unlock()
+	loadedModule := modules[path]
+	if loadedModule == nil {
+		ctx := pkcs11.New(path)
+		if ctx == nil {
+			return nil, fmt.Errorf("failed to load PKCS#11 module '%s'", path)
+		}
+		err := ctx.Initialize()
+		if err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED)) {
+			ctx.Destroy()
+			return nil, fmt.Errorf("failed to initialize PKCS#11 module '%s' (cause: %w)", path, err)
+		}
+		loadedModule = &module{path: path, ctx: ctx}
+		modules[path] = loadedModule
+	}
+	loadedModule.refs++
+	return loadedModule, nil
+}
+
+func (module *module) close() error {
+	modulesLock.Lock()
+	defer modulesLock.Unlock()
+	module.refs--
+	if module.refs > 0 {
+		return nil
+	}
+	delete(modules, module.path)
+	err := module.ctx.Finalize()
+	module.ctx.Destroy()
+	if err != nil {
+		return fmt.Errorf("failed to finalize PKCS#11 module '%s' (cause: %w)", module.path, err)
+	}
+	return nil
+}
+
+// OpenToken opens the token with the given label using the given PKCS#11 module and logs
+// in using the PIN returned by the given passphrase function.
+//
+// The returned [Token] must be closed, if it is no longer needed. Signers created by the token
+// become unusable after the token has been closed.
+func OpenToken(modulePath, tokenLabel string, pin tlsconf.PassphraseFunc) (*Token, error) {
+	module, err := openModule(modulePath)
+	if err != nil {
+		return nil, err
+	}
+	token, err := openToken(module, tokenLabel, pin)
+	if err != nil {
+		return nil, errors.Join(err, module.close())
+	}
+	return token, nil
+}
+
+func openToken(module *module, tokenLabel string, pin tlsconf.PassphraseFunc) (*Token, error) {
+	slot, err := findTokenSlot(module, tokenLabel)
+	if err != nil {
+		return nil, err
+	}
+	session, err := module.ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session for PKCS#11 token '%s' (cause: %w)", tokenLabel, err)
+	}
+	pinBytes, err := pin()
+	if err == nil {
+		err = module.ctx.Login(session, pkcs11.CKU_USER, string(pinBytes))
+		if errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+			err = nil
+		}
+	}
+	if err != nil {
+		module.ctx.CloseSession(session)
+		return nil, fmt.Errorf("failed to login to PKCS#11 token '%s' (cause: %w)", tokenLabel, err)
+	}
+	return &Token{module: module, label: tokenLabel, session: session}, nil
+}
+
+func findTokenSlot(module *module, tokenLabel string) (uint, error) {
+	slots, err := module.ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get PKCS#11 slots of '%s' (cause: %w)", module.path, err)
+	}
+	for _, slot := range slots {
+		tokenInfo, err := module.ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get PKCS#11 token info of '%s' (cause: %w)", module.path, err)
+		}
+		if tokenInfo.Label == tokenLabel {
+			return slot, nil
+		}
+	}
+	return 0, fmt.Errorf("PKCS#11 token '%s' not found in '%s'", tokenLabel, module.path)
+}
+
+// Label gets the token's label.
+func (token *Token) Label() string {
+	return token.label
+}
+
+// Close closes the token's session.
+func (token *Token) Close() error {
+	token.mutex.Lock()
+	defer token.mutex.Unlock()
+	err := token.module.ctx.CloseSession(token.session)
+	if err != nil {
+		err = fmt.Errorf("failed to close session for PKCS#11 token '%s' (cause: %w)", token.label, err)
+	}
+	return errors.Join(err, token.module.close())
+}
+
+// FindSigner looks up the selected private key on the token and returns a [crypto.Signer] for it.
+//
+// If the key does not exist, an error wrapping [ErrKeyNotFound] is returned. RSA, ECDSA (P-224 to P-521)
+// and Ed25519 keys are supported.
+func (token *Token) FindSigner(key KeySelector) (crypto.Signer, error) {
+	token.mutex.Lock()
+	defer token.mutex.Unlock()
+	privateKey, err := token.findObject(key.attributes(pkcs11.CKO_PRIVATE_KEY))
+	if err != nil {
+		return nil, err
+	} else if privateKey == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+	}
+	publicKey, err := token.readPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return newSigner(token, privateKey, publicKey)
+}
+
+// LoadCertificate combines the selected private key with its certificate into a [tls.Certificate].
+//
+// If the given certificate file is empty, the certificate is read from the token (using the
+// same key selector). Otherwise the certificate chain is loaded from the given file (see
+// [tlsconf.LoadSignerCertificate]).
+func (token *Token) LoadCertificate(key KeySelector, certFile string) (*tls.Certificate, error) {
+	signer, err := token.FindSigner(key)
+	if err != nil {
+		return nil, err
+	}
+	if certFile != "" {
+		return tlsconf.LoadSignerCertificate(certFile, signer)
+	}
+	token.mutex.Lock()
+	certificate, err := token.readCertificate(key)
+	token.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return tlsconf.NewSignerCertificate([][]byte{certificate.Raw}, signer)
+}
+
+// GenerateKey generates a new non-extractable key pair for the given algorithm on the token
+// and returns a [crypto.Signer] for it.
+//
+// The key is labeled with the selector's label and ID.
+func (token *Token) GenerateKey(key KeySelector, algorithm tlsconf.CertificateAlgorithm) (crypto.Signer, error) {
+	slog.Info("generating PKCS#11 key", slog.String("token", token.label), slog.String("key", key.String()), slog.String("algorithm", string(algorithm)))
+	mechanism, publicTemplate, err := keyGenerationTemplate(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	publicTemplate = append(publicTemplate, pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true), pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true))
+	privateTemplate := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+	}
+	for _, attribute := range key.attributes(0)[1:] {
+		publicTemplate = append(publicTemplate, attribute)
+		privateTemplate = append(privateTemplate, attribute)
+	}
+	token.mutex.Lock()
+	_, _, err = token.module.ctx.GenerateKeyPair(token.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(mechanism, nil)}, publicTemplate, privateTemplate)
+	token.mutex.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate PKCS#11 key %s (cause: %w)", key, err)
+	}
+	return token.FindSigner(key)
+}
+
+func (token *Token) findObject(template []*pkcs11.Attribute) (pkcs11.ObjectHandle, error) {
+	ctx := token.module.ctx
+	err := ctx.FindObjectsInit(token.session, template)
+	if err != nil {
+		return 0, fmt.Errorf("failed to search PKCS#11 token '%s' (cause: %w)", token.label, err)
+	}
+	objects, _, err := ctx.FindObjects(token.session, 2)
+	finalErr := ctx.FindObjectsFinal(token.session)
+	if err != nil || finalErr != nil {
+		return 0, fmt.Errorf("failed to search PKCS#11 token '%s' (cause: %w)", token.label, errors.Join(err, finalErr))
+	}
+	switch len(objects) {
+	case 0:
+		return 0, nil
+	case 1:
+		return objects[0], nil
+	}
+	return 0, fmt.Errorf("ambiguous PKCS#11 object selection in token '%s'", token.label)
+}
+
+func (token *Token) readPublicKey(key KeySelector) (crypto.PublicKey, error) {
+	publicKey, err := token.findObject(key.attributes(pkcs11.CKO_PUBLIC_KEY))
+	if err != nil {
+		return nil, err
+	}
+	if publicKey != 0 {
+		return token.decodePublicKey(publicKey)
+	}
+	certificate, err := token.readCertificate(key)
+	if err != nil {
+		return nil, err
+	}
+	return certificate.PublicKey, nil
+}
+
+func (token *Token) readCertificate(key KeySelector) (*x509.Certificate, error) {
+	certificateObject, err := token.findObject(key.attributes(pkcs11.CKO_CERTIFICATE))
+	if err != nil {
+		return nil, err
+	} else if certificateObject == 0 {
+		return nil, fmt.Errorf("no PKCS#11 public key or certificate found for %s", key)
+	}
+	attributes, err := token.module.ctx.GetAttributeValue(token.session, certificateObject, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_VALUE, nil)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PKCS#11 certificate %s (cause: %w)", key, err)
+	}
+	certificate, err := x509.ParseCertificate(attributes[0].Value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PKCS#11 certificate %s (cause: %w)", key, err)
+	}
+	return certificate, nil
+}
+
+// UseServerCertificate loads the selected key and certificate from the given token and adds
+// it to the server [tls.Config].
+//
+// See [Token.LoadCertificate] for how the certificate file is used.
+func UseServerCertificate(token *Token, key KeySelector, certFile string) tlsconf.TLSConfigOption {
+	return func(config *tls.Config) error {
+		certificate, err := token.LoadCertificate(key, certFile)
+		if err != nil {
+			return err
+		}
+		config.Certificates = []tls.Certificate{*certificate}
+		return nil
+	}
+}
+
+// UseLocalCACertificate issues a server certificate for the given address using the given
+// [tlsconf.LocalCA] and the selected token key and adds it to the server [tls.Config].
+//
+// The key is generated using the given algorithm, if it does not yet exist.
+func UseLocalCACertificate(ca *tlsconf.LocalCA, token *Token, key KeySelector, algorithm tlsconf.CertificateAlgorithm, address string, lifetime time.Duration) tlsconf.TLSConfigOption {
+	return func(config *tls.Config) error {
+		signer, err := token.findOrGenerateKey(key, algorithm)
+		if err != nil {
+			return err
+		}
+		certificate, err := ca.IssueServerSignerCertificate(address, signer, lifetime)
+		if err != nil {
+			return err
+		}
+		config.Certificates = []tls.Certificate{*certificate}
+		return nil
+	}
+}
+
+// NewLocalCA creates a [tlsconf.LocalCA] using the selected token key.
+//
+// The CA certificate is loaded from the given certificate file. If the file does not exist,
+// a new self-signed CA certificate is generated and written to the file. The key is generated
+// using the given algorithm, if it does not yet exist.
+func NewLocalCA(token *Token, key KeySelector, certFile string, name string, algorithm tlsconf.CertificateAlgorithm, lifetime time.Duration) (*tlsconf.LocalCA, error) {
+	signer, err := token.findOrGenerateKey(key, algorithm)
+	if err != nil {
+		return nil, err
+	}
+	_, err = os.Stat(certFile)
+	if err == nil {
+		certificate, err := tlsconf.LoadSignerCertificate(certFile, signer)
+		if err != nil {
+			return nil, err
+		}
+		return tlsconf.NewLocalCAFromCertificate(certificate)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to access CA certificate file '%s' (cause: %w)", certFile, err)
+	}
+	certificate, err := tlsconf.GenerateLocalCACertificate(name, signer, lifetime)
+	if err != nil {
+		return nil, err
+	}
+	err = tlsconf.WriteCertificates([]*x509.Certificate{certificate.Leaf}, certFile, tlsconf.CertificateFormatPEM)
+	if err != nil {
+		return nil, err
+	}
+	return tlsconf.NewLocalCAFromCertificate(certificate)
+}
+
+func (token *Token) findOrGenerateKey(key KeySelector, algorithm tlsconf.CertificateAlgorithm) (crypto.Signer, error) {
+	signer, err := token.FindSigner(key)
+	if errors.Is(err, ErrKeyNotFound) {
+		return token.GenerateKey(key, algorithm)
+	}
+	return signer, err
+}
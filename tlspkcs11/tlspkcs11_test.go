@@ -0,0 +1,176 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+//go:build cgo
+
+package tlspkcs11_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/pkcs11"
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-conf"
+	"github.com/tdrn-org/go-tlsconf"
+	"github.com/tdrn-org/go-tlsconf/tlspkcs11"
+	"github.com/tdrn-org/go-tlsconf/tlsserver"
+)
+
+const (
+	testTokenLabel = "tlsconf"
+	testTokenPIN   = "1234"
+)
+
+// softHSMModules lists the common SoftHSM module locations (overridable via SOFTHSM2_MODULE)
+var softHSMModules = []string{
+	"/usr/lib/softhsm/libsofthsm2.so",
+	"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+	"/usr/lib64/pkcs11/libsofthsm2.so",
+	"/usr/local/lib/softhsm/libsofthsm2.so",
+	"/opt/homebrew/lib/softhsm/libsofthsm2.so",
+}
+
+func TestGenerateKey(t *testing.T) {
+	token := openTestToken(t)
+	for _, algorithm := range []tlsconf.CertificateAlgorithm{tlsconf.CertificateAlgorithmRSA2048, tlsconf.CertificateAlgorithmECDSA256, tlsconf.CertificateAlgorithmECDSA384, tlsconf.CertificateAlgorithmED25519} {
+		key := tlspkcs11.KeySelector{Label: string(algorithm), ID: []byte(algorithm)}
+		_, err := token.FindSigner(key)
+		require.ErrorIs(t, err, tlspkcs11.ErrKeyNotFound)
+		signer, err := token.GenerateKey(key, algorithm)
+		require.NoError(t, err)
+		require.False(t, tlsconf.IsExportableKey(signer))
+		certificate, err := tlsconf.GenerateEphemeralSignerCertificate("localhost", signer, time.Hour)
+		require.NoError(t, err)
+		for _, version := range []uint16{tls.VersionTLS12, tls.VersionTLS13} {
+			testHandshake(t, certificate, version)
+		}
+		signer, err = token.FindSigner(tlspkcs11.KeySelector{ID: []byte(algorithm)})
+		require.NoError(t, err)
+		require.Equal(t, certificate.Leaf.PublicKey, signer.Public())
+	}
+}
+
+func TestLocalCA(t *testing.T) {
+	token := openTestToken(t)
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	caKey := tlspkcs11.KeySelector{Label: "ca"}
+	ca1, err := tlspkcs11.NewLocalCA(token, caKey, caFile, "ca", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	require.FileExists(t, caFile)
+	ca2, err := tlspkcs11.NewLocalCA(token, caKey, caFile, "ca", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, ca1.Certificate(), ca2.Certificate())
+
+	err = tlsserver.SetOptions(tlspkcs11.UseLocalCACertificate(ca2, token, tlspkcs11.KeySelector{Label: "server"}, tlsconf.CertificateAlgorithmDefault, "localhost", time.Hour))
+	require.NoError(t, err)
+	tlsServerConfig, _ := conf.LookupConfiguration[*tlsserver.Config]()
+	certificate := tlsServerConfig.Certificates[0]
+	roots := x509.NewCertPool()
+	roots.AddCert(ca1.Certificate())
+	_, err = certificate.Leaf.Verify(x509.VerifyOptions{
+		DNSName: "localhost",
+		Roots:   roots,
+	})
+	require.NoError(t, err)
+
+	certFile, _, err := tlsconf.WriteCertificate(&certificate, t.TempDir(), "server")
+	require.NoError(t, err)
+	err = tlsserver.SetOptions(tlspkcs11.UseServerCertificate(token, tlspkcs11.KeySelector{Label: "server"}, certFile))
+	require.NoError(t, err)
+}
+
+func testHandshake(t *testing.T, certificate *tls.Certificate, version uint16) {
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(certificate.Leaf)
+	serverConfig := &tls.Config{Certificates: []tls.Certificate{*certificate}, MaxVersion: version}
+	clientConfig := &tls.Config{RootCAs: rootCAs, ServerName: "localhost"}
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+	var serverErr error
+	wait := sync.WaitGroup{}
+	wait.Go(func() {
+		serverErr = tls.Server(serverConn, serverConfig).Handshake()
+		serverConn.Close()
+	})
+	clientErr := tls.Client(clientConn, clientConfig).Handshake()
+	clientConn.Close()
+	wait.Wait()
+	require.NoError(t, clientErr)
+	require.NoError(t, serverErr)
+}
+
+func openTestToken(t *testing.T) *tlspkcs11.Token {
+	module := findSoftHSMModule(t)
+	dir := t.TempDir()
+	tokenDir := filepath.Join(dir, "tokens")
+	require.NoError(t, os.Mkdir(tokenDir, 0700))
+	confFile := filepath.Join(dir, "softhsm2.conf")
+	require.NoError(t, os.WriteFile(confFile, []byte("directories.tokendir = "+tokenDir+"\nobjectstore.backend = file\n"), 0600))
+	t.Setenv("SOFTHSM2_CONF", confFile)
+	initTestToken(t, module)
+	token, err := tlspkcs11.OpenToken(module, testTokenLabel, tlsconf.StaticPassphrase(testTokenPIN))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, token.Close())
+	})
+	return token
+}
+
+func findSoftHSMModule(t *testing.T) string {
+	modules := softHSMModules
+	if module := os.Getenv("SOFTHSM2_MODULE"); module != "" {
+		modules = []string{module}
+	}
+	for _, module := range modules {
+		if _, err := os.Stat(module); err == nil {
+			return module
+		}
+	}
+	if os.Getenv("CI") != "" {
+		// the CI build installs SoftHSM, hence the tests must not be skipped silently
+		t.Fatal("SoftHSM module not available")
+	}
+	t.Skip("SoftHSM module not available (set SOFTHSM2_MODULE to run PKCS#11 tests)")
+	return ""
+}
+
+func initTestToken(t *testing.T, module string) {
+	ctx := pkcs11.New(module)
+	require.NotNil(t, ctx)
+	defer ctx.Destroy()
+	require.NoError(t, ctx.Initialize())
+	defer ctx.Finalize()
+	slots, err := ctx.GetSlotList(false)
+	require.NoError(t, err)
+	require.NotEmpty(t, slots)
+	require.NoError(t, ctx.InitToken(slots[0], testTokenPIN, testTokenLabel))
+	// SoftHSM reassigns the slot after the token has been initialized
+	slots, err = ctx.GetSlotList(true)
+	require.NoError(t, err)
+	for _, slot := range slots {
+		tokenInfo, err := ctx.GetTokenInfo(slot)
+		require.NoError(t, err)
+		if tokenInfo.Label != testTokenLabel {
+			continue
+		}
+		session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+		require.NoError(t, err)
+		defer ctx.CloseSession(session)
+		require.NoError(t, ctx.Login(session, pkcs11.CKU_SO, testTokenPIN))
+		require.NoError(t, ctx.InitPIN(session, testTokenPIN))
+		require.NoError(t, ctx.Logout(session))
+		return
+	}
+	require.Fail(t, "initialized token not found")
+}
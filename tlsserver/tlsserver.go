@@ -8,7 +8,9 @@
 package tlsserver
 
 import (
+	"crypto"
 	"crypto/tls"
+	"crypto/x509"
 	"log/slog"
 	"net/http"
 	"reflect"
@@ -32,6 +34,8 @@ func (c *Config) Bind() {
 }
 
 // SetOptions applies the given options to the server [tls.Config] instance.
+//
+// If a global [tlsconf.Policy] has been set, it is enforced after applying the options.
 func SetOptions(options ...tlsconf.TLSConfigOption) error {
 	config := &Config{}
 	for _, option := range options {
@@ -40,6 +44,10 @@ func SetOptions(options ...tlsconf.TLSConfigOption) error {
 			return err
 		}
 	}
+	err := tlsconf.EnforceGlobalPolicy(&config.Config)
+	if err != nil {
+		return err
+	}
 	config.Bind()
 	return nil
 }
@@ -57,6 +65,90 @@ func UseEphemeralCertificate(address string, algorithm tlsconf.CertificateAlgori
 	}
 }
 
+// UseCertificate loads the certificate from the given certificate and key files and adds it
+// to the server [tls.Config].
+//
+// See [tlsconf.LoadCertificate] for the supported file formats.
+func UseCertificate(certFile, keyFile string, passphrase tlsconf.PassphraseFunc) tlsconf.TLSConfigOption {
+	return func(config *tls.Config) error {
+		certificate, err := tlsconf.LoadCertificate(certFile, keyFile, passphrase)
+		if err != nil {
+			return err
+		}
+		config.Certificates = []tls.Certificate{*certificate}
+		return nil
+	}
+}
+
+// UseSignerCertificate loads the certificate chain from the given file and adds it together
+// with the given signer to the server [tls.Config].
+//
+// Use this option for private keys which cannot be exported (e.g. HSM, TPM or KMS backed keys).
+// See [tlsconf.LoadSignerCertificate] for details.
+func UseSignerCertificate(certFile string, signer crypto.Signer) tlsconf.TLSConfigOption {
+	return func(config *tls.Config) error {
+		certificate, err := tlsconf.LoadSignerCertificate(certFile, signer)
+		if err != nil {
+			return err
+		}
+		config.Certificates = []tls.Certificate{*certificate}
+		return nil
+	}
+}
+
+// UseKeyStoreCertificate retrieves the named certificate from the given [tlsconf.KeyStore]
+// and adds it to the server [tls.Config].
+func UseKeyStoreCertificate(store tlsconf.KeyStore, name string) tlsconf.TLSConfigOption {
+	return func(config *tls.Config) error {
+		certificate, err := store.Get(name)
+		if err != nil {
+			return err
+		}
+		config.Certificates = []tls.Certificate{*certificate}
+		return nil
+	}
+}
+
+// UseLocalCACertificate issues a server certificate for the given address using the given
+// [tlsconf.LocalCA] and adds it to the server [tls.Config].
+func UseLocalCACertificate(ca *tlsconf.LocalCA, address string, algorithm tlsconf.CertificateAlgorithm, lifetime time.Duration) tlsconf.TLSConfigOption {
+	return func(config *tls.Config) error {
+		certificate, err := ca.IssueServerCertificate(address, algorithm, lifetime)
+		if err != nil {
+			return err
+		}
+		config.Certificates = []tls.Certificate{*certificate}
+		return nil
+	}
+}
+
+// UsePKCS12Certificate loads the certificate from the given PKCS#12 file and adds it
+// to the server [tls.Config].
+func UsePKCS12Certificate(pfxFile, password string) tlsconf.TLSConfigOption {
+	return func(config *tls.Config) error {
+		certificate, err := tlsconf.LoadPKCS12(pfxFile, password)
+		if err != nil {
+			return err
+		}
+		config.Certificates = []tls.Certificate{*certificate}
+		return nil
+	}
+}
+
+// RequireClientCertificate requires clients to present a certificate issued by one of the
+// given CA certificates (mutual TLS).
+func RequireClientCertificate(caCertificates ...*x509.Certificate) tlsconf.TLSConfigOption {
+	return func(config *tls.Config) error {
+		clientCAs := x509.NewCertPool()
+		for _, caCertificate := range caCertificates {
+			clientCAs.AddCert(caCertificate)
+		}
+		config.ClientCAs = clientCAs
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		return nil
+	}
+}
+
 // GetConfig returns the server [tls.Config] instance.
 func GetConfig() *tls.Config {
 	tlsServerConfig, _ := conf.LookupConfiguration[*Config]()
@@ -77,6 +169,21 @@ func ApplyConfig(server *http.Server) *http.Server {
 	return server
 }
 
+// EnableHandshakeLogging logs every TLS handshake of the server [tls.Config] and invokes the given hooks.
+//
+// See [tlsconf.EnableHandshakeLoggingWithTimeout] for details.
+func EnableHandshakeLogging(logger *slog.Logger, sampler tlsconf.HandshakeSampler, hooks ...tlsconf.HandshakeHook) tlsconf.TLSConfigOption {
+	return tlsconf.EnableHandshakeLogging(tlsconf.HandshakeSideServer, logger, sampler, hooks...)
+}
+
+// EnableHandshakeLoggingWithTimeout logs every TLS handshake of the server [tls.Config] and invokes the given
+// hooks. Handshakes not completing within the given timeout are aborted.
+//
+// See [tlsconf.EnableHandshakeLoggingWithTimeout] for details.
+func EnableHandshakeLoggingWithTimeout(timeout time.Duration, logger *slog.Logger, sampler tlsconf.HandshakeSampler, hooks ...tlsconf.HandshakeHook) tlsconf.TLSConfigOption {
+	return tlsconf.EnableHandshakeLoggingWithTimeout(tlsconf.HandshakeSideServer, timeout, logger, sampler, hooks...)
+}
+
 func init() {
 	(&Config{}).Bind()
 }
@@ -7,11 +7,18 @@
 package tlsserver_test
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/tdrn-org/go-conf"
+	"github.com/tdrn-org/go-tlsconf"
 	"github.com/tdrn-org/go-tlsconf/tlsserver"
+	"github.com/tdrn-org/go-tlsconf/tlstest"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -19,3 +26,82 @@ func TestDefaultConfig(t *testing.T) {
 	require.True(t, ok)
 	require.NotNil(t, tlsServerConfig)
 }
+
+func TestUsePKCS12Certificate(t *testing.T) {
+	certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	pfxFile, err := tlsconf.WritePKCS12(certificate, t.TempDir(), "localhost", "secret", tlsconf.PKCS12EncryptionModern)
+	require.NoError(t, err)
+	err = tlsserver.SetOptions(tlsserver.UsePKCS12Certificate(pfxFile, "secret"))
+	require.NoError(t, err)
+	require.Equal(t, certificate.Certificate, tlsserver.GetConfig().Certificates[0].Certificate)
+}
+
+func TestUseSignerCertificate(t *testing.T) {
+	store := tlsconf.NewMemoryKeyStore()
+	certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	err = store.Put("localhost", certificate)
+	require.NoError(t, err)
+	signerServer := httptest.NewServer(tlsconf.NewRemoteSignerHandler(store))
+	defer signerServer.Close()
+	signer, err := tlsconf.NewRemoteSigner(signerServer.Client(), signerServer.URL+"/localhost")
+	require.NoError(t, err)
+	certFile, _, err := tlsconf.WriteCertificate(certificate, t.TempDir(), "localhost")
+	require.NoError(t, err)
+	err = tlsserver.SetOptions(tlsserver.UseSignerCertificate(certFile, signer))
+	require.NoError(t, err)
+	require.Equal(t, signer, tlsserver.GetConfig().Certificates[0].PrivateKey)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = tlsserver.GetConfig()
+	server.StartTLS()
+	defer server.Close()
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(certificate.Leaf)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: rootCAs, ServerName: "localhost"}}}
+	response, err := client.Get(server.URL)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestRequireClientCertificate(t *testing.T) {
+	ca, err := tlsconf.NewLocalCA(tlsconf.NewMemoryKeyStore(), "ca", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	err = tlsserver.SetOptions(tlsserver.UseLocalCACertificate(ca, "localhost", tlsconf.CertificateAlgorithmDefault, time.Hour), tlsserver.RequireClientCertificate(ca.Certificate()))
+	require.NoError(t, err)
+	require.Equal(t, tls.RequireAndVerifyClientCert, tlsserver.GetConfig().ClientAuth)
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(ca.Certificate())
+	clientConfig := &tls.Config{RootCAs: rootCAs}
+	result := tlstest.Handshake(t.Context(), clientConfig, tlsserver.GetConfig(), "localhost")
+	require.Error(t, result.Err())
+
+	clientCertificate, err := ca.IssueClientCertificate("client", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	clientConfig.Certificates = []tls.Certificate{*clientCertificate}
+	result = tlstest.Handshake(t.Context(), clientConfig, tlsserver.GetConfig(), "localhost")
+	require.NoError(t, result.Err())
+	require.Equal(t, "CN=client", result.ServerState.PeerCertificates[0].Subject.String())
+}
+
+func TestSetOptionsWithPolicy(t *testing.T) {
+	tlsconf.SetPolicy(&tlsconf.Policy{
+		Name:                  "test",
+		CertificateAlgorithms: []tlsconf.CertificateAlgorithm{tlsconf.CertificateAlgorithmRSA3072},
+		MinVersion:            tls.VersionTLS13,
+	})
+	defer tlsconf.SetPolicy(nil)
+	err := tlsserver.SetOptions(tlsserver.UseEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour))
+	var violation *tlsconf.PolicyViolationError
+	require.ErrorAs(t, err, &violation)
+	require.Equal(t, tlsconf.PolicyRuleCertificateAlgorithm, violation.Rule)
+
+	err = tlsserver.SetOptions(tlsserver.UseEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmRSA3072, time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, uint16(tls.VersionTLS13), tlsserver.GetConfig().MinVersion)
+}
@@ -0,0 +1,347 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlstest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tdrn-org/go-tlsconf"
+)
+
+// BrokenCertificateFlavor defines how a certificate generated by a [BrokenCertificateFactory]
+// is broken.
+type BrokenCertificateFlavor string
+
+const (
+	BrokenExpired             BrokenCertificateFlavor = "expired"              // Validity period has ended
+	BrokenNotYetValid         BrokenCertificateFlavor = "not-yet-valid"        // Validity period has not yet started
+	BrokenWrongSAN            BrokenCertificateFlavor = "wrong-san"            // Subject alternative names do not match the host
+	BrokenWrongEKU            BrokenCertificateFlavor = "wrong-eku"            // Client instead of server extended key usage
+	BrokenSelfSignedLeaf      BrokenCertificateFlavor = "self-signed-leaf"     // Self-signed leaf certificate
+	BrokenUntrustedIssuer     BrokenCertificateFlavor = "untrusted-issuer"     // Issued by a CA not contained in the roots
+	BrokenMissingIntermediate BrokenCertificateFlavor = "missing-intermediate" // Issued by an intermediate CA not contained in the chain
+	BrokenRevoked             BrokenCertificateFlavor = "revoked"              // Listed in the factory's CRL
+	BrokenWeakKey             BrokenCertificateFlavor = "weak-key"             // RSA 1024 bit key
+	BrokenSHA1Signature       BrokenCertificateFlavor = "sha1-signature"       // Signed using SHA-1
+	BrokenNameConstraint      BrokenCertificateFlavor = "name-constraint"      // Violates the issuing CA's name constraints
+)
+
+// BrokenCertificateFlavors lists all supported [BrokenCertificateFlavor]s.
+var BrokenCertificateFlavors = []BrokenCertificateFlavor{
+	BrokenExpired,
+	BrokenNotYetValid,
+	BrokenWrongSAN,
+	BrokenWrongEKU,
+	BrokenSelfSignedLeaf,
+	BrokenUntrustedIssuer,
+	BrokenMissingIntermediate,
+	BrokenRevoked,
+	BrokenWeakKey,
+	BrokenSHA1Signature,
+	BrokenNameConstraint,
+}
+
+// ErrCertificateRevoked indicates a certificate listed in a CRL.
+//
+// crypto/x509 does not check revocation, hence this error is only reported by [BrokenCertificate.Verify].
+var ErrCertificateRevoked = errors.New("certificate has been revoked")
+
+// ErrWeakKey indicates a certificate key considered too weak (RSA keys below 2048 bits).
+//
+// crypto/x509 accepts such keys, hence this error is only reported by [BrokenCertificate.Verify].
+var ErrWeakKey = errors.New("certificate key is too weak")
+
+// BrokenCertificate contains a deliberately broken server certificate and everything needed
+// to verify it.
+type BrokenCertificate struct {
+	// Flavor defines how the certificate is broken.
+	Flavor BrokenCertificateFlavor
+	// Host is the host name or IP address the certificate is expected to be valid for.
+	Host string
+	// Certificate contains the certificate chain as presented by a server.
+	Certificate *tls.Certificate
+	// Roots contains the trust anchors a client should use.
+	Roots *x509.CertPool
+	// CRL contains the issuing CA's revocation list.
+	CRL *x509.RevocationList
+	// ExpectedError contains a prototype of the error reported during verification. Use
+	// [BrokenCertificate.Matches] to check an actual error against it.
+	ExpectedError error
+}
+
+// ClientConfig creates a client [tls.Config] using the certificate's roots and host.
+func (broken *BrokenCertificate) ClientConfig() *tls.Config {
+	return &tls.Config{
+		RootCAs:    broken.Roots,
+		ServerName: broken.Host,
+	}
+}
+
+// ServerConfig creates a server [tls.Config] presenting the broken certificate.
+func (broken *BrokenCertificate) ServerConfig() *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{*broken.Certificate},
+	}
+}
+
+// Verify verifies the certificate like a TLS client would do and additionally checks for
+// revocation and weak keys.
+func (broken *BrokenCertificate) Verify() error {
+	intermediates := x509.NewCertPool()
+	for _, certificateBytes := range broken.Certificate.Certificate[1:] {
+		certificate, err := x509.ParseCertificate(certificateBytes)
+		if err != nil {
+			return err
+		}
+		intermediates.AddCert(certificate)
+	}
+	leaf := broken.Certificate.Leaf
+	_, err := leaf.Verify(x509.VerifyOptions{
+		DNSName:       broken.Host,
+		Roots:         broken.Roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		return err
+	}
+	if broken.CRL != nil {
+		for _, entry := range broken.CRL.RevokedCertificateEntries {
+			if entry.SerialNumber.Cmp(leaf.SerialNumber) == 0 {
+				return ErrCertificateRevoked
+			}
+		}
+	}
+	if publicKey, ok := leaf.PublicKey.(*rsa.PublicKey); ok && publicKey.N.BitLen() < 2048 {
+		return ErrWeakKey
+	}
+	return nil
+}
+
+// Matches checks whether the given error matches the expected error.
+//
+// The error may be wrapped (e.g. in a [tls.CertificateVerificationError]). For
+// [x509.CertificateInvalidError]s the reason must match as well. As crypto/x509 reports
+// SHA-1 signatures as [x509.UnknownAuthorityError], the error message must additionally
+// contain the insecure algorithm hint for [BrokenSHA1Signature].
+func (broken *BrokenCertificate) Matches(err error) bool {
+	switch expected := broken.ExpectedError.(type) {
+	case x509.CertificateInvalidError:
+		var actual x509.CertificateInvalidError
+		return errors.As(err, &actual) && actual.Reason == expected.Reason
+	case x509.HostnameError:
+		var actual x509.HostnameError
+		return errors.As(err, &actual)
+	case x509.UnknownAuthorityError:
+		var actual x509.UnknownAuthorityError
+		if !errors.As(err, &actual) {
+			return false
+		}
+		// SHA-1 signatures are reported as an unknown authority with an insecure algorithm hint
+		return broken.Flavor != BrokenSHA1Signature || strings.Contains(actual.Error(), "insecure algorithm")
+	}
+	return errors.Is(err, broken.ExpectedError)
+}
+
+// BrokenCertificateFactory generates deliberately broken server certificates for testing
+// verification failures.
+//
+// The factory uses its own RSA root CA (required to create SHA-1 signatures), which is contained
+// in the roots of every generated [BrokenCertificate].
+type BrokenCertificateFactory struct {
+	t         testing.TB
+	algorithm tlsconf.CertificateAlgorithm
+	root      *tls.Certificate
+	roots     *x509.CertPool
+	serial    atomic.Int64
+}
+
+// NewBrokenCertificateFactory creates a new [BrokenCertificateFactory] for the given test using
+// the given algorithm for the leaf keys.
+func NewBrokenCertificateFactory(t testing.TB, algorithm tlsconf.CertificateAlgorithm) *BrokenCertificateFactory {
+	t.Helper()
+	factory := &BrokenCertificateFactory{
+		t:         t,
+		algorithm: algorithm,
+	}
+	factory.root = factory.newCA("tlstest broken root", nil, nil)
+	factory.roots = x509.NewCertPool()
+	factory.roots.AddCert(factory.root.Leaf)
+	return factory
+}
+
+// Roots gets a [x509.CertPool] containing the factory's root CA.
+func (factory *BrokenCertificateFactory) Roots() *x509.CertPool {
+	return factory.roots.Clone()
+}
+
+// Generate generates a server certificate for the given host broken as defined by the given flavor.
+func (factory *BrokenCertificateFactory) Generate(flavor BrokenCertificateFlavor, host string) *BrokenCertificate {
+	factory.t.Helper()
+	broken := &BrokenCertificate{
+		Flavor: flavor,
+		Host:   host,
+		Roots:  factory.Roots(),
+	}
+	template := factory.leafTemplate(host)
+	issuer := factory.root
+	algorithm := factory.algorithm
+	switch flavor {
+	case BrokenExpired:
+		template.NotBefore = time.Now().Add(-48 * time.Hour)
+		template.NotAfter = time.Now().Add(-24 * time.Hour)
+		broken.ExpectedError = x509.CertificateInvalidError{Reason: x509.Expired}
+	case BrokenNotYetValid:
+		template.NotBefore = time.Now().Add(24 * time.Hour)
+		template.NotAfter = time.Now().Add(48 * time.Hour)
+		broken.ExpectedError = x509.CertificateInvalidError{Reason: x509.Expired}
+	case BrokenWrongSAN:
+		template.DNSNames = []string{"wrong.invalid"}
+		template.IPAddresses = nil
+		broken.ExpectedError = x509.HostnameError{}
+	case BrokenWrongEKU:
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		broken.ExpectedError = x509.CertificateInvalidError{Reason: x509.IncompatibleUsage}
+	case BrokenSelfSignedLeaf:
+		issuer = nil
+		broken.ExpectedError = x509.UnknownAuthorityError{}
+	case BrokenUntrustedIssuer:
+		issuer = factory.newCA("tlstest untrusted CA", nil, nil)
+		broken.ExpectedError = x509.UnknownAuthorityError{}
+	case BrokenMissingIntermediate:
+		issuer = factory.newCA("tlstest intermediate CA", factory.root, nil)
+		broken.ExpectedError = x509.UnknownAuthorityError{}
+	case BrokenRevoked:
+		broken.ExpectedError = ErrCertificateRevoked
+	case BrokenWeakKey:
+		algorithm = ""
+		broken.ExpectedError = ErrWeakKey
+	case BrokenSHA1Signature:
+		template.SignatureAlgorithm = x509.SHA1WithRSA
+		broken.ExpectedError = x509.UnknownAuthorityError{}
+	case BrokenNameConstraint:
+		constrainedRoot := factory.newCA("tlstest constrained root", nil, func(template *x509.Certificate) {
+			template.PermittedDNSDomainsCritical = true
+			template.PermittedDNSDomains = []string{"permitted.invalid"}
+			template.PermittedIPRanges = []*net.IPNet{{IP: net.IPv4(192, 0, 2, 0), Mask: net.CIDRMask(24, 32)}}
+		})
+		issuer = constrainedRoot
+		broken.Roots = x509.NewCertPool()
+		broken.Roots.AddCert(constrainedRoot.Leaf)
+		broken.ExpectedError = x509.CertificateInvalidError{Reason: x509.CANotAuthorizedForThisName}
+	default:
+		factory.t.Fatalf("unknown broken certificate flavor: %s", flavor)
+	}
+	broken.Certificate = factory.issue(template, algorithm, issuer)
+	if flavor == BrokenRevoked {
+		broken.CRL = factory.revoke(broken.Certificate.Leaf)
+	}
+	return broken
+}
+
+func (factory *BrokenCertificateFactory) leafTemplate(host string) *x509.Certificate {
+	now := time.Now()
+	template := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: host},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(DefaultLifetime),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{host}
+	}
+	return template
+}
+
+func (factory *BrokenCertificateFactory) newCA(name string, parent *tls.Certificate, customize func(*x509.Certificate)) *tls.Certificate {
+	factory.t.Helper()
+	now := time.Now()
+	template := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(DefaultLifetime),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	if customize != nil {
+		customize(template)
+	}
+	return factory.issue(template, tlsconf.CertificateAlgorithmRSA2048, parent)
+}
+
+// issue signs the given template using the given issuer (or self-signed, if the issuer is nil).
+// An empty algorithm generates a weak RSA 1024 bit key.
+func (factory *BrokenCertificateFactory) issue(template *x509.Certificate, algorithm tlsconf.CertificateAlgorithm, issuer *tls.Certificate) *tls.Certificate {
+	factory.t.Helper()
+	var privateKey crypto.PrivateKey
+	var err error
+	if algorithm != "" {
+		_, privateKey, err = algorithm.GenerateCertificateKey()
+	} else {
+		privateKey, err = rsa.GenerateKey(rand.Reader, 1024)
+	}
+	if err != nil {
+		factory.t.Fatalf("failed to generate key: %v", err)
+	}
+	signer := privateKey.(crypto.Signer)
+	template.SerialNumber = big.NewInt(factory.serial.Add(1))
+	parent := template
+	var parentKey crypto.Signer = signer
+	if issuer != nil {
+		parent = issuer.Leaf
+		parentKey = issuer.PrivateKey.(crypto.Signer)
+	}
+	certificateBytes, err := x509.CreateCertificate(rand.Reader, template, parent, signer.Public(), parentKey)
+	if err != nil {
+		factory.t.Fatalf("failed to create certificate: %v", err)
+	}
+	// intermediates are never added to the chain (see BrokenMissingIntermediate)
+	certificate, err := tlsconf.NewSignerCertificate([][]byte{certificateBytes}, signer)
+	if err != nil {
+		factory.t.Fatalf("failed to create certificate: %v", err)
+	}
+	return certificate
+}
+
+func (factory *BrokenCertificateFactory) revoke(certificate *x509.Certificate) *x509.RevocationList {
+	factory.t.Helper()
+	now := time.Now()
+	template := &x509.RevocationList{
+		Number:     big.NewInt(factory.serial.Add(1)),
+		ThisUpdate: now,
+		NextUpdate: now.Add(DefaultLifetime),
+		RevokedCertificateEntries: []x509.RevocationListEntry{
+			{SerialNumber: certificate.SerialNumber, RevocationTime: now},
+		},
+	}
+	crlBytes, err := x509.CreateRevocationList(rand.Reader, template, factory.root.Leaf, factory.root.PrivateKey.(crypto.Signer))
+	if err != nil {
+		factory.t.Fatalf("failed to create CRL: %v", err)
+	}
+	crl, err := x509.ParseRevocationList(crlBytes)
+	if err != nil {
+		factory.t.Fatalf("failed to parse CRL: %v", err)
+	}
+	return crl
+}
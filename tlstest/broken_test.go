@@ -0,0 +1,54 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlstest_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf"
+	"github.com/tdrn-org/go-tlsconf/tlstest"
+)
+
+func TestBrokenCertificateFactory(t *testing.T) {
+	t.Parallel()
+	factory := tlstest.NewBrokenCertificateFactory(t, tlsconf.CertificateAlgorithmDefault)
+	for _, flavor := range tlstest.BrokenCertificateFlavors {
+		for _, host := range []string{"localhost", "127.0.0.1"} {
+			broken := factory.Generate(flavor, host)
+			require.Equal(t, flavor, broken.Flavor)
+			err := broken.Verify()
+			require.Error(t, err, "flavor: %s, host: %s", flavor, host)
+			require.True(t, broken.Matches(err), "flavor: %s, host: %s, err: %v", flavor, host, err)
+		}
+	}
+}
+
+func TestBrokenCertificateHandshake(t *testing.T) {
+	t.Parallel()
+	factory := tlstest.NewBrokenCertificateFactory(t, tlsconf.CertificateAlgorithmDefault)
+	for _, flavor := range tlstest.BrokenCertificateFlavors {
+		broken := factory.Generate(flavor, "localhost")
+		result := tlstest.Handshake(t.Context(), broken.ClientConfig(), broken.ServerConfig(), "")
+		switch flavor {
+		case tlstest.BrokenRevoked, tlstest.BrokenWeakKey:
+			// not detected by crypto/tls
+			require.NoError(t, result.ClientErr, "flavor: %s", flavor)
+		default:
+			require.True(t, broken.Matches(result.ClientErr), "flavor: %s, err: %v", flavor, result.ClientErr)
+		}
+	}
+}
+
+func TestBrokenCertificateMatches(t *testing.T) {
+	t.Parallel()
+	factory := tlstest.NewBrokenCertificateFactory(t, tlsconf.CertificateAlgorithmDefault)
+	expired := factory.Generate(tlstest.BrokenExpired, "localhost")
+	wrongEKU := factory.Generate(tlstest.BrokenWrongEKU, "localhost")
+	require.False(t, wrongEKU.Matches(expired.Verify()))
+	require.False(t, expired.Matches(nil))
+}
@@ -0,0 +1,39 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+// Package tlstest provides utilities for testing TLS related code.
+//
+// The functions in this package are meant to be used in tests only.
+package tlstest
+
+import (
+	"testing"
+	"testing/cryptotest"
+	"time"
+
+	"github.com/tdrn-org/go-tlsconf"
+)
+
+// EnableDeterministicGeneration makes key and certificate generation reproducible for the
+// duration of the given test.
+//
+// The process wide cryptographic random source is seeded with the given seed and the given clock
+// is used for all validity periods and serial numbers (see [tlsconf.EnableDeterministicMode]).
+// Running the same generation steps with the same seed and clock produces byte-identical keys and
+// certificates. Enabled key pools (see [tlsconf.EnableKeyPool]) are paused for the duration of the
+// test. As both settings affect the whole process, the test must not run in parallel.
+func EnableDeterministicGeneration(t *testing.T, seed uint64, clock func() time.Time) {
+	// enable deterministic mode first, to pause the key pools before seeding the random source
+	t.Cleanup(tlsconf.EnableDeterministicMode(clock))
+	cryptotest.SetGlobalRandom(t, seed)
+}
+
+// FixedClock returns a clock always returning the given time.
+func FixedClock(now time.Time) func() time.Time {
+	return func() time.Time {
+		return now
+	}
+}
@@ -0,0 +1,68 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlstest_test
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf"
+	"github.com/tdrn-org/go-tlsconf/tlstest"
+)
+
+var deterministicTestTime = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func TestEnableDeterministicGeneration(t *testing.T) {
+	var generated [][][]byte
+	for _, seed := range []uint64{1, 1, 2} {
+		t.Run("", func(t *testing.T) {
+			tlstest.EnableDeterministicGeneration(t, seed, tlstest.FixedClock(deterministicTestTime))
+			generated = append(generated, generateDeterministicTestCertificates(t))
+		})
+	}
+	require.Equal(t, generated[0], generated[1])
+	require.NotEqual(t, generated[0], generated[2])
+}
+
+func TestEnableDeterministicGenerationKeyPool(t *testing.T) {
+	pool, err := tlsconf.EnableKeyPool(tlsconf.CertificateAlgorithmECDSA256, 1)
+	require.NoError(t, err)
+	defer tlsconf.DisableKeyPool(tlsconf.CertificateAlgorithmECDSA256)
+	require.NoError(t, pool.Warm(t.Context()))
+	var generated [][][]byte
+	for range 2 {
+		t.Run("", func(t *testing.T) {
+			tlstest.EnableDeterministicGeneration(t, 1, tlstest.FixedClock(deterministicTestTime))
+			certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmECDSA256, time.Hour)
+			require.NoError(t, err)
+			generated = append(generated, certificate.Certificate)
+		})
+	}
+	require.Equal(t, generated[0], generated[1])
+}
+
+func generateDeterministicTestCertificates(t *testing.T) [][]byte {
+	generated := make([][]byte, 0)
+	for _, algorithm := range []tlsconf.CertificateAlgorithm{tlsconf.CertificateAlgorithmRSA2048, tlsconf.CertificateAlgorithmECDSA256, tlsconf.CertificateAlgorithmED25519} {
+		certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", algorithm, time.Hour)
+		require.NoError(t, err)
+		require.Equal(t, deterministicTestTime, certificate.Leaf.NotBefore)
+		keyBytes, err := x509.MarshalPKCS8PrivateKey(certificate.PrivateKey)
+		require.NoError(t, err)
+		generated = append(generated, certificate.Certificate[0], keyBytes)
+	}
+	ca, err := tlsconf.NewLocalCA(tlsconf.NewMemoryKeyStore(), "ca", tlsconf.CertificateAlgorithmDefault, 24*time.Hour)
+	require.NoError(t, err)
+	serverCertificate, err := ca.IssueServerCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	clientCertificate, err := ca.IssueClientCertificate("client", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	require.NotEqual(t, serverCertificate.Leaf.SerialNumber, clientCertificate.Leaf.SerialNumber)
+	return append(generated, ca.Certificate().Raw, serverCertificate.Certificate[0], clientCertificate.Certificate[0])
+}
@@ -0,0 +1,124 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlstest
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// HandshakeResult contains the outcome of a TLS handshake run via [Handshake].
+type HandshakeResult struct {
+	ClientState tls.ConnectionState
+	ClientErr   error
+	ServerState tls.ConnectionState
+	ServerErr   error
+}
+
+// Err gets the combined client and server error or nil, if the handshake succeeded on both sides.
+func (result *HandshakeResult) Err() error {
+	var errs []error
+	if result.ClientErr != nil {
+		errs = append(errs, fmt.Errorf("client handshake failed (cause: %w)", result.ClientErr))
+	}
+	if result.ServerErr != nil {
+		errs = append(errs, fmt.Errorf("server handshake failed (cause: %w)", result.ServerErr))
+	}
+	return errors.Join(errs...)
+}
+
+// Handshake runs a full TLS handshake between the given client and server configs in memory
+// (via [net.Pipe]) and returns the connection states and errors of both sides.
+//
+// As there is no dialed address, the client config is cloned and its ServerName is set to
+// the given server name, if the latter is not empty. The given configs are not modified.
+// Both sides are run concurrently and the function returns as soon as both sides are done.
+//
+// In TLS 1.3 the client finishes its handshake before the server has verified a client
+// certificate. Hence a rejected client certificate is reported by the server error only.
+func Handshake(ctx context.Context, clientConfig, serverConfig *tls.Config, serverName string) *HandshakeResult {
+	if serverName != "" {
+		clientConfig = clientConfig.Clone()
+		clientConfig.ServerName = serverName
+	}
+	clientPipe, serverPipe := net.Pipe()
+	clientConn := tls.Client(newBufferedConn(clientPipe), clientConfig)
+	serverConn := tls.Server(newBufferedConn(serverPipe), serverConfig)
+	result := &HandshakeResult{}
+	wait := sync.WaitGroup{}
+	wait.Go(func() {
+		result.ClientErr = clientConn.HandshakeContext(ctx)
+		result.ClientState = clientConn.ConnectionState()
+		if result.ClientErr != nil {
+			clientConn.Close()
+		}
+	})
+	wait.Go(func() {
+		result.ServerErr = serverConn.HandshakeContext(ctx)
+		result.ServerState = serverConn.ConnectionState()
+		if result.ServerErr != nil {
+			serverConn.Close()
+		}
+	})
+	wait.Wait()
+	clientConn.Close()
+	serverConn.Close()
+	return result
+}
+
+// bufferedConn continuously drains the underlying connection into a buffer.
+//
+// net.Pipe is synchronous, hence a peer writing an alert while the other side is still
+// writing its handshake flight would otherwise block forever.
+type bufferedConn struct {
+	net.Conn
+	mutex    sync.Mutex
+	readable *sync.Cond
+	buffer   bytes.Buffer
+	readErr  error
+}
+
+func newBufferedConn(conn net.Conn) *bufferedConn {
+	buffered := &bufferedConn{Conn: conn}
+	buffered.readable = sync.NewCond(&buffered.mutex)
+	go buffered.drain()
+	return buffered
+}
+
+func (conn *bufferedConn) drain() {
+	chunk := make([]byte, 4096)
+	for {
+		n, err := conn.Conn.Read(chunk)
+		conn.mutex.Lock()
+		conn.buffer.Write(chunk[:n])
+		if err != nil {
+			conn.readErr = err
+		}
+		conn.readable.Broadcast()
+		conn.mutex.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (conn *bufferedConn) Read(b []byte) (int, error) {
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+	for conn.buffer.Len() == 0 && conn.readErr == nil {
+		conn.readable.Wait()
+	}
+	if conn.buffer.Len() > 0 {
+		return conn.buffer.Read(b)
+	}
+	return 0, conn.readErr
+}
@@ -0,0 +1,62 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlstest_test
+
+import (
+	"context"
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf/tlstest"
+)
+
+func TestHandshake(t *testing.T) {
+	t.Parallel()
+	pki := tlstest.NewPKI(t)
+	serverConfig, clientConfig := pki.MutualConfigPair("localhost:443", "client")
+	serverConfig.NextProtos = []string{"h2", "http/1.1"}
+	clientConfig.NextProtos = []string{"http/1.1"}
+	clientConfig.ServerName = ""
+	result := tlstest.Handshake(t.Context(), clientConfig, serverConfig, "localhost")
+	require.NoError(t, result.Err())
+	require.Empty(t, clientConfig.ServerName)
+	require.True(t, result.ClientState.HandshakeComplete)
+	require.True(t, result.ServerState.HandshakeComplete)
+	require.Equal(t, uint16(tls.VersionTLS13), result.ClientState.Version)
+	require.Equal(t, result.ClientState.CipherSuite, result.ServerState.CipherSuite)
+	require.Equal(t, "http/1.1", result.ServerState.NegotiatedProtocol)
+	require.Equal(t, "localhost", result.ServerState.ServerName)
+	require.Equal(t, "localhost", result.ClientState.PeerCertificates[0].Subject.CommonName)
+	require.Equal(t, "client", result.ServerState.PeerCertificates[0].Subject.CommonName)
+}
+
+func TestHandshakeFailure(t *testing.T) {
+	t.Parallel()
+	pki := tlstest.NewPKI(t)
+	serverConfig, clientConfig := pki.ConfigPair("localhost:443")
+	serverConfig.MinVersion = tls.VersionTLS13
+	clientConfig.MaxVersion = tls.VersionTLS12
+	result := tlstest.Handshake(t.Context(), clientConfig, serverConfig, "")
+	require.Error(t, result.ClientErr)
+	require.Error(t, result.ServerErr)
+	require.ErrorContains(t, result.Err(), "client handshake failed")
+	require.ErrorContains(t, result.Err(), "server handshake failed")
+	require.False(t, result.ClientState.HandshakeComplete)
+	require.False(t, result.ServerState.HandshakeComplete)
+}
+
+func TestHandshakeCanceled(t *testing.T) {
+	t.Parallel()
+	pki := tlstest.NewPKI(t)
+	serverConfig, clientConfig := pki.ConfigPair("localhost:443")
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	result := tlstest.Handshake(ctx, clientConfig, serverConfig, "")
+	require.ErrorIs(t, result.ClientErr, context.Canceled)
+	require.ErrorIs(t, result.ServerErr, context.Canceled)
+}
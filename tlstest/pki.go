@@ -0,0 +1,168 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlstest
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tdrn-org/go-tlsconf"
+)
+
+// DefaultLifetime defines the lifetime of the CA and certificates created by a [PKI].
+const DefaultLifetime = 24 * time.Hour
+
+// PKI provides an isolated CA for a single test and issues server and client certificates
+// as well as pre-wired [tls.Config] pairs and TLS servers.
+//
+// In contrast to the tlsserver and tlsclient packages, a PKI does not touch the global
+// configuration bindings, hence tests using their own PKI can run in parallel. All
+// failures are reported via the test's Fatal function.
+type PKI struct {
+	t         testing.TB
+	ca        *tlsconf.LocalCA
+	algorithm tlsconf.CertificateAlgorithm
+	roots     *x509.CertPool
+}
+
+// NewPKI creates a new [PKI] for the given test using [tlsconf.CertificateAlgorithmDefault]
+// for all keys.
+func NewPKI(t testing.TB) *PKI {
+	t.Helper()
+	return NewPKIWithAlgorithm(t, tlsconf.CertificateAlgorithmDefault)
+}
+
+// NewPKIWithAlgorithm creates a new [PKI] for the given test using the given algorithm
+// for all keys.
+func NewPKIWithAlgorithm(t testing.TB, algorithm tlsconf.CertificateAlgorithm) *PKI {
+	t.Helper()
+	ca, err := tlsconf.NewLocalCA(tlsconf.NewMemoryKeyStore(), "tlstest CA", algorithm, DefaultLifetime)
+	if err != nil {
+		t.Fatalf("failed to create test CA: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Certificate())
+	return &PKI{
+		t:         t,
+		ca:        ca,
+		algorithm: algorithm,
+		roots:     roots,
+	}
+}
+
+// CA gets the PKI's [tlsconf.LocalCA].
+func (pki *PKI) CA() *tlsconf.LocalCA {
+	return pki.ca
+}
+
+// CertPool gets a [x509.CertPool] containing the PKI's CA certificate only.
+func (pki *PKI) CertPool() *x509.CertPool {
+	return pki.roots.Clone()
+}
+
+// ServerCertificate issues a server certificate for the given address.
+func (pki *PKI) ServerCertificate(address string) *tls.Certificate {
+	pki.t.Helper()
+	certificate, err := pki.ca.IssueServerCertificate(address, pki.algorithm, DefaultLifetime)
+	if err != nil {
+		pki.t.Fatalf("failed to issue test server certificate for '%s': %v", address, err)
+	}
+	return certificate
+}
+
+// ClientCertificate issues a client certificate for the given name.
+func (pki *PKI) ClientCertificate(name string) *tls.Certificate {
+	pki.t.Helper()
+	certificate, err := pki.ca.IssueClientCertificate(name, pki.algorithm, DefaultLifetime)
+	if err != nil {
+		pki.t.Fatalf("failed to issue test client certificate for '%s': %v", name, err)
+	}
+	return certificate
+}
+
+// ServerConfig creates a server [tls.Config] using a server certificate for the given address.
+//
+// Client certificates issued by the PKI are verified, if presented.
+func (pki *PKI) ServerConfig(address string) *tls.Config {
+	pki.t.Helper()
+	return &tls.Config{
+		Certificates: []tls.Certificate{*pki.ServerCertificate(address)},
+		ClientCAs:    pki.CertPool(),
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+	}
+}
+
+// ClientConfig creates a client [tls.Config] trusting the PKI's CA only.
+//
+// The given server name may be empty, in which case it is derived from the dialed address.
+func (pki *PKI) ClientConfig(serverName string) *tls.Config {
+	return &tls.Config{
+		RootCAs:    pki.CertPool(),
+		ServerName: serverName,
+	}
+}
+
+// ConfigPair creates a matching server and client [tls.Config] pair for the given address.
+func (pki *PKI) ConfigPair(address string) (*tls.Config, *tls.Config) {
+	pki.t.Helper()
+	return pki.ServerConfig(address), pki.ClientConfig(addressServerName(address))
+}
+
+// MutualConfigPair creates a matching server and client [tls.Config] pair for the given address
+// requiring a client certificate. The client uses a client certificate for the given name.
+func (pki *PKI) MutualConfigPair(address, clientName string) (*tls.Config, *tls.Config) {
+	pki.t.Helper()
+	serverConfig, clientConfig := pki.ConfigPair(address)
+	serverConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	clientConfig.Certificates = []tls.Certificate{*pki.ClientCertificate(clientName)}
+	return serverConfig, clientConfig
+}
+
+// NewServer starts a [httptest.Server] using TLS and a server certificate issued by the PKI.
+//
+// The server's Client function returns a client trusting the PKI's CA. The server is closed
+// automatically when the test finishes.
+func (pki *PKI) NewServer(handler http.Handler) *httptest.Server {
+	pki.t.Helper()
+	return pki.startServer(handler, pki.ServerConfig(httptestAddress), pki.ClientConfig(""))
+}
+
+// NewMutualServer starts a [httptest.Server] like [PKI.NewServer], but requiring a client certificate.
+//
+// The server's Client function returns a client using a client certificate for the given name.
+func (pki *PKI) NewMutualServer(handler http.Handler, clientName string) *httptest.Server {
+	pki.t.Helper()
+	serverConfig, clientConfig := pki.MutualConfigPair(httptestAddress, clientName)
+	clientConfig.ServerName = ""
+	return pki.startServer(handler, serverConfig, clientConfig)
+}
+
+// httptest servers always listen on the IPv4 loopback address
+const httptestAddress = "127.0.0.1"
+
+func (pki *PKI) startServer(handler http.Handler, serverConfig, clientConfig *tls.Config) *httptest.Server {
+	server := httptest.NewUnstartedServer(handler)
+	server.TLS = serverConfig
+	server.StartTLS()
+	pki.t.Cleanup(server.Close)
+	server.Client().Transport.(*http.Transport).TLSClientConfig = clientConfig
+	return server
+}
+
+func addressServerName(address string) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return strings.Trim(address, "[]")
+	}
+	return host
+}
@@ -0,0 +1,94 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlstest_test
+
+import (
+	"crypto/x509"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf"
+	"github.com/tdrn-org/go-tlsconf/tlstest"
+)
+
+func TestPKICertificates(t *testing.T) {
+	t.Parallel()
+	pki := tlstest.NewPKIWithAlgorithm(t, tlsconf.CertificateAlgorithmED25519)
+	require.True(t, pki.CA().Certificate().IsCA)
+	serverCertificate := pki.ServerCertificate("localhost:443")
+	_, err := serverCertificate.Leaf.Verify(x509.VerifyOptions{
+		DNSName:   "localhost",
+		Roots:     pki.CertPool(),
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	require.NoError(t, err)
+	clientCertificate := pki.ClientCertificate("client")
+	_, err = clientCertificate.Leaf.Verify(x509.VerifyOptions{
+		Roots:     pki.CertPool(),
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	require.NoError(t, err)
+}
+
+func TestPKIConfigPair(t *testing.T) {
+	t.Parallel()
+	pki := tlstest.NewPKI(t)
+	serverConfig, clientConfig := pki.ConfigPair("localhost:443")
+	require.Equal(t, "localhost", clientConfig.ServerName)
+	result := tlstest.Handshake(t.Context(), clientConfig, serverConfig, "")
+	require.NoError(t, result.Err())
+
+	otherServerConfig, _ := tlstest.NewPKI(t).ConfigPair("localhost")
+	result = tlstest.Handshake(t.Context(), clientConfig, otherServerConfig, "")
+	require.Error(t, result.ClientErr)
+}
+
+func TestPKIMutualConfigPair(t *testing.T) {
+	t.Parallel()
+	pki := tlstest.NewPKI(t)
+	serverConfig, clientConfig := pki.MutualConfigPair("[::1]:443", "client")
+	require.Equal(t, "::1", clientConfig.ServerName)
+	result := tlstest.Handshake(t.Context(), clientConfig, serverConfig, "")
+	require.NoError(t, result.Err())
+
+	clientConfig.Certificates = nil
+	result = tlstest.Handshake(t.Context(), clientConfig, serverConfig, "")
+	require.Error(t, result.ServerErr)
+}
+
+func TestPKINewServer(t *testing.T) {
+	t.Parallel()
+	pki := tlstest.NewPKI(t)
+	server := pki.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "Hello")
+	}))
+	require.Equal(t, "Hello", testGet(t, server.Client(), server.URL))
+}
+
+func TestPKINewMutualServer(t *testing.T) {
+	t.Parallel()
+	pki := tlstest.NewPKI(t)
+	server := pki.NewMutualServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}), "client")
+	require.Equal(t, "client", testGet(t, server.Client(), server.URL))
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: pki.ClientConfig("")}}
+	_, err := client.Get(server.URL)
+	require.Error(t, err)
+}
+
+func testGet(t *testing.T, client *http.Client, url string) string {
+	response, err := client.Get(url)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	return string(body)
+}
@@ -0,0 +1,417 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// WriteOption functions are used to customize how certificate and key files are written.
+type WriteOption func(*writeOptions)
+
+type writeOptions struct {
+	certMode    fs.FileMode
+	keyMode     fs.FileMode
+	uid         int
+	gid         int
+	noOverwrite bool
+}
+
+func newWriteOptions(options []WriteOption) *writeOptions {
+	writeOptions := &writeOptions{
+		certMode: 0644,
+		keyMode:  0600,
+		uid:      -1,
+		gid:      -1,
+	}
+	for _, option := range options {
+		option(writeOptions)
+	}
+	return writeOptions
+}
+
+// WithCertificateMode sets the file mode used for certificate files (default: 0644).
+func WithCertificateMode(mode fs.FileMode) WriteOption {
+	return func(options *writeOptions) {
+		options.certMode = mode
+	}
+}
+
+// WithKeyMode sets the file mode used for files containing private keys (default: 0600).
+func WithKeyMode(mode fs.FileMode) WriteOption {
+	return func(options *writeOptions) {
+		options.keyMode = mode
+	}
+}
+
+// WithOwner sets the owner of the written files. A uid or gid of -1 keeps the
+// respective default.
+func WithOwner(uid, gid int) WriteOption {
+	return func(options *writeOptions) {
+		options.uid = uid
+		options.gid = gid
+	}
+}
+
+// WithNoOverwrite causes writing to fail with [fs.ErrExist], if any of the files already exists.
+func WithNoOverwrite() WriteOption {
+	return func(options *writeOptions) {
+		options.noOverwrite = true
+	}
+}
+
+type fileWrite struct {
+	path string
+	data []byte
+	mode fs.FileMode
+}
+
+// rename is used to install written files (replaceable for testing purposes).
+var rename = os.Rename
+
+// writeFile writes the given file atomically.
+//
+// The file is first written to a temporary file in the target directory and synced.
+// Afterwards the temporary file is renamed to the target name and the target directory
+// is synced to persist the rename.
+func writeFile(file *fileWrite, options *writeOptions) error {
+	dir := filepath.Dir(file.path)
+	if options.noOverwrite {
+		_, err := os.Lstat(file.path)
+		if err == nil {
+			return fmt.Errorf("failed to write file '%s' (cause: %w)", file.path, fs.ErrExist)
+		}
+	}
+	tempFile, err := os.CreateTemp(dir, "."+filepath.Base(file.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file for '%s' (cause: %w)", file.path, err)
+	}
+	temp := tempFile.Name()
+	defer os.Remove(temp)
+	err = writeAndSync(tempFile, file, options)
+	if err != nil {
+		return fmt.Errorf("failed to write temporary file '%s' (cause: %w)", temp, err)
+	}
+	if options.noOverwrite {
+		// linking fails if the target exists, hence we never overwrite a concurrently created file
+		err = os.Link(temp, file.path)
+	} else {
+		err = rename(temp, file.path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write file '%s' (cause: %w)", file.path, err)
+	}
+	return syncDir(dir)
+}
+
+// writeFileSet writes a set of related files (e.g. a certificate and its key) located in
+// the given directory atomically.
+//
+// The files are written into a new version directory (<dir>/.<name>.<random>) and the
+// version link (<dir>/.<name>.current) is switched to the new version directory via a single
+// rename. The target files themselves are symbolic links into the version link, hence the
+// set of files is always replaced as a whole and a failed or interrupted write never leaves
+// a mix of old and new files behind. Existing regular target files are moved into a version
+// directory of their own first. Files without data are absent in the new version.
+// Concurrent writers are serialized via an advisory lock on the directory (where supported),
+// which also allows to remove version directories left behind by interrupted writes.
+// On Windows, where symbolic links are not generally available, the files are replaced one
+// after another.
+func writeFileSet(dir, name string, files []*fileWrite, options *writeOptions) error {
+	if runtime.GOOS == "windows" {
+		return writeFilesSequentially(files, options)
+	}
+	unlock, err := lockDir(dir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if options.noOverwrite {
+		for _, file := range files {
+			_, err := os.Lstat(file.path)
+			if err == nil {
+				return fmt.Errorf("failed to write file '%s' (cause: %w)", file.path, fs.ErrExist)
+			}
+		}
+	}
+	set := &fileSet{dir: dir, name: name}
+	err = set.migrate(files, options)
+	if err != nil {
+		return err
+	}
+	version, err := set.createVersion(files, options, func(file *fileWrite, versionFile string) error {
+		if file.data == nil {
+			return nil
+		}
+		osFile, err := os.OpenFile(versionFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return err
+		}
+		return writeAndSync(osFile, file, options)
+	})
+	if err != nil {
+		return err
+	}
+	err = set.switchVersion(version, options)
+	if err != nil {
+		return err
+	}
+	return set.linkFiles(files, true, options)
+}
+
+// deleteFileSet deletes the given files as well as the version link and the version
+// directories of a set of files written via [writeFileSet].
+func deleteFileSet(dir, name string, files []string) error {
+	unlock, err := lockDir(dir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	set := &fileSet{dir: dir, name: name}
+	for _, file := range append(files, set.currentLink()) {
+		err := os.Remove(file)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to delete file '%s' (cause: %w)", file, err)
+		}
+	}
+	return set.removeVersions("")
+}
+
+type fileSet struct {
+	dir  string
+	name string
+}
+
+func (set *fileSet) currentLink() string {
+	return filepath.Join(set.dir, "."+set.name+".current")
+}
+
+// isVersion checks whether the given name is a version directory name of this set. Version
+// directory names consist of the set name followed by the random digits of [os.MkdirTemp],
+// hence the version directories of a set named "a.1" are not mistaken for ones of set "a".
+func (set *fileSet) isVersion(version string) bool {
+	random, ok := strings.CutPrefix(version, "."+set.name+".")
+	if !ok || random == "" {
+		return false
+	}
+	for _, c := range random {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// removeVersions removes all version directories of this set except the given one.
+func (set *fileSet) removeVersions(keep string) error {
+	entries, err := os.ReadDir(set.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read directory '%s' (cause: %w)", set.dir, err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == keep || !set.isVersion(entry.Name()) {
+			continue
+		}
+		version := filepath.Join(set.dir, entry.Name())
+		err = os.RemoveAll(version)
+		if err != nil {
+			return fmt.Errorf("failed to delete directory '%s' (cause: %w)", version, err)
+		}
+	}
+	return nil
+}
+
+// migrate moves existing regular target files into a version directory of their own. Afterwards
+// they are replaced by symbolic links one after another without changing what is read.
+func (set *fileSet) migrate(files []*fileWrite, options *writeOptions) error {
+	legacyFiles := make([]*fileWrite, 0, len(files))
+	for _, file := range files {
+		info, err := os.Lstat(file.path)
+		if err == nil && info.Mode().IsRegular() {
+			legacyFiles = append(legacyFiles, file)
+		}
+	}
+	if len(legacyFiles) == 0 {
+		return nil
+	}
+	version, err := set.createVersion(legacyFiles, options, func(file *fileWrite, versionFile string) error {
+		return os.Link(file.path, versionFile)
+	})
+	if err != nil {
+		return err
+	}
+	err = set.switchVersion(version, options)
+	if err != nil {
+		return err
+	}
+	return set.linkFiles(legacyFiles, false, options)
+}
+
+// createVersion creates a new version directory and populates it using the given function.
+func (set *fileSet) createVersion(files []*fileWrite, options *writeOptions, create func(*fileWrite, string) error) (string, error) {
+	version, err := os.MkdirTemp(set.dir, "."+set.name+".*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create version directory for '%s' (cause: %w)", filepath.Join(set.dir, set.name), err)
+	}
+	err = os.Chmod(version, 0755)
+	if err == nil && (options.uid != -1 || options.gid != -1) {
+		err = os.Chown(version, options.uid, options.gid)
+	}
+	if err != nil {
+		os.RemoveAll(version)
+		return "", fmt.Errorf("failed to set up version directory '%s' (cause: %w)", version, err)
+	}
+	for _, file := range files {
+		versionFile := filepath.Join(version, filepath.Base(file.path))
+		err = create(file, versionFile)
+		if err != nil {
+			os.RemoveAll(version)
+			return "", fmt.Errorf("failed to write file '%s' (cause: %w)", versionFile, err)
+		}
+	}
+	err = syncDir(version)
+	if err != nil {
+		os.RemoveAll(version)
+		return "", err
+	}
+	return version, nil
+}
+
+// switchVersion atomically points the version link to the given version directory and
+// removes all other version directories afterwards.
+func (set *fileSet) switchVersion(version string, options *writeOptions) error {
+	currentLink := set.currentLink()
+	err := replaceSymlink(filepath.Base(version), currentLink, options)
+	if err != nil {
+		os.RemoveAll(version)
+		return fmt.Errorf("failed to switch version link '%s' (cause: %w)", currentLink, err)
+	}
+	err = syncDir(set.dir)
+	if err != nil {
+		return err
+	}
+	return set.removeVersions(filepath.Base(version))
+}
+
+// linkFiles ensures that the given target files are symbolic links into the version link.
+// If requested, the links of files without data (and hence absent in the current version)
+// are removed.
+func (set *fileSet) linkFiles(files []*fileWrite, removeAbsent bool, options *writeOptions) error {
+	for _, file := range files {
+		target := filepath.Join(filepath.Base(set.currentLink()), filepath.Base(file.path))
+		current, err := os.Readlink(file.path)
+		if removeAbsent && file.data == nil {
+			if err == nil && current == target {
+				os.Remove(file.path)
+			}
+			continue
+		}
+		if err == nil && current == target {
+			continue
+		}
+		_, err = os.Lstat(file.path)
+		exists := err == nil
+		if !exists {
+			// linking fails if the target exists, hence we never overwrite a concurrently created file
+			err = os.Symlink(target, file.path)
+			if err == nil && (options.uid != -1 || options.gid != -1) {
+				err = os.Lchown(file.path, options.uid, options.gid)
+			}
+		} else if options.noOverwrite {
+			err = fs.ErrExist
+		} else {
+			err = replaceSymlink(target, file.path, options)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write file '%s' (cause: %w)", file.path, err)
+		}
+	}
+	return syncDir(set.dir)
+}
+
+// replaceSymlink atomically replaces the given link by a symbolic link to the given target.
+//
+// The new link is created under a unique temporary name first and then renamed to the
+// link's name.
+func replaceSymlink(target, link string, options *writeOptions) error {
+	var tempLink string
+	for {
+		tempLink = filepath.Join(filepath.Dir(link), "."+filepath.Base(link)+"."+strconv.FormatUint(uint64(rand.Uint32()), 10)+".link")
+		err := os.Symlink(target, tempLink)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+	}
+	var err error
+	if options.uid != -1 || options.gid != -1 {
+		err = os.Lchown(tempLink, options.uid, options.gid)
+	}
+	if err == nil {
+		err = rename(tempLink, link)
+	}
+	if err != nil {
+		os.Remove(tempLink)
+	}
+	return err
+}
+
+func writeFilesSequentially(files []*fileWrite, options *writeOptions) error {
+	for _, file := range files {
+		if file.data == nil {
+			err := os.Remove(file.path)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("failed to remove file '%s' (cause: %w)", file.path, err)
+			}
+			continue
+		}
+		err := writeFile(file, options)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeAndSync(osFile *os.File, file *fileWrite, options *writeOptions) error {
+	_, err := osFile.Write(file.data)
+	if err == nil {
+		err = osFile.Chmod(file.mode)
+	}
+	if err == nil && (options.uid != -1 || options.gid != -1) {
+		err = osFile.Chown(options.uid, options.gid)
+	}
+	if err == nil {
+		err = osFile.Sync()
+	}
+	return errors.Join(err, osFile.Close())
+}
+
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		// directories cannot be synced on Windows
+		return nil
+	}
+	dirFile, err := os.Open(dir)
+	if err == nil {
+		err = errors.Join(dirFile.Sync(), dirFile.Close())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sync directory '%s' (cause: %w)", dir, err)
+	}
+	return nil
+}
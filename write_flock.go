@@ -0,0 +1,39 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+//go:build unix && !aix && !solaris
+
+package tlsconf
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lockDir acquires an exclusive advisory lock on the given directory and returns the function
+// releasing it. The lock serializes concurrent writers of file sets within the directory
+// (regardless whether they are running in the same or in different processes).
+func lockDir(dir string) (func(), error) {
+	dirFile, err := os.Open(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open directory '%s' (cause: %w)", dir, err)
+	}
+	for {
+		err = syscall.Flock(int(dirFile.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		dirFile.Close()
+		return nil, fmt.Errorf("failed to lock directory '%s' (cause: %w)", dir, err)
+	}
+	return func() {
+		syscall.Flock(int(dirFile.Fd()), syscall.LOCK_UN)
+		dirFile.Close()
+	}, nil
+}
@@ -0,0 +1,14 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+//go:build !unix || aix || solaris
+
+package tlsconf
+
+// lockDir is not supported on this platform.
+func lockDir(dir string) (func(), error) {
+	return func() {}, nil
+}
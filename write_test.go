@@ -0,0 +1,201 @@
+//
+// Copyright (C) 2025-2026 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license. See the LICENSE file for details.
+
+package tlsconf_test
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tdrn-org/go-tlsconf"
+)
+
+func TestWriteCertificateModes(t *testing.T) {
+	certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	certFile, keyFile, err := tlsconf.WriteCertificate(certificate, dir, "default")
+	require.NoError(t, err)
+	requireFileMode(t, certFile, 0644)
+	requireFileMode(t, keyFile, 0600)
+	certFile, keyFile, err = tlsconf.WriteCertificate(certificate, dir, "custom", tlsconf.WithCertificateMode(0640), tlsconf.WithKeyMode(0640), tlsconf.WithOwner(os.Getuid(), os.Getgid()))
+	require.NoError(t, err)
+	requireFileMode(t, certFile, 0640)
+	requireFileMode(t, keyFile, 0640)
+}
+
+func TestWritePrivateKey(t *testing.T) {
+	certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "test.key")
+	err = os.WriteFile(keyFile, []byte{}, 0644)
+	require.NoError(t, err)
+	err = tlsconf.WritePrivateKey(certificate.PrivateKey, keyFile)
+	require.NoError(t, err)
+	requireFileMode(t, keyFile, 0600)
+	keyData, err := os.ReadFile(keyFile)
+	require.NoError(t, err)
+	keyBlock, _ := pem.Decode(keyData)
+	require.NotNil(t, keyBlock)
+	privateKey, err := tlsconf.DecodePrivateKey(keyBlock, nil)
+	require.NoError(t, err)
+	require.Equal(t, certificate.PrivateKey, privateKey)
+	err = tlsconf.WritePrivateKey(nil, keyFile)
+	require.ErrorIs(t, err, tlsconf.ErrNoPrivateKey)
+}
+
+func TestWriteCertificateOverwrite(t *testing.T) {
+	certificate1, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	certificate2, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	_, _, err = tlsconf.WriteCertificate(certificate1, dir, "test")
+	require.NoError(t, err)
+	certFile, keyFile, err := tlsconf.WriteCertificate(certificate2, dir, "test")
+	require.NoError(t, err)
+	reloadedCertificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	require.NoError(t, err)
+	require.Equal(t, certificate2, &reloadedCertificate)
+	requireFileSetEntries(t, dir, "test")
+}
+
+func TestWriteCertificateNoOverwrite(t *testing.T) {
+	certificate1, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	certificate2, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	certFile, keyFile, err := tlsconf.WriteCertificate(certificate1, dir, "test", tlsconf.WithNoOverwrite())
+	require.NoError(t, err)
+	_, _, err = tlsconf.WriteCertificate(certificate2, dir, "test", tlsconf.WithNoOverwrite())
+	require.ErrorIs(t, err, fs.ErrExist)
+	reloadedCertificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	require.NoError(t, err)
+	require.Equal(t, certificate1, &reloadedCertificate)
+	requireFileSetEntries(t, dir, "test")
+}
+
+func TestWriteCertificateRenameFailure(t *testing.T) {
+	certificate1, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	certificate2, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	for _, legacy := range []bool{false, true} {
+		for failingRename := 1; ; failingRename++ {
+			dir := t.TempDir()
+			certFile, keyFile, err := tlsconf.WriteCertificate(certificate1, dir, "test")
+			require.NoError(t, err)
+			if legacy {
+				// replace the links by regular files as written by former versions
+				for _, file := range []string{certFile, keyFile} {
+					data, err := os.ReadFile(file)
+					require.NoError(t, err)
+					require.NoError(t, os.Remove(file))
+					require.NoError(t, os.WriteFile(file, data, 0600))
+				}
+			}
+			renames := 0
+			restore := tlsconf.SetRename(func(oldpath, newpath string) error {
+				renames++
+				if renames == failingRename {
+					return errors.New("rename failure")
+				}
+				return os.Rename(oldpath, newpath)
+			})
+			_, _, err = tlsconf.WriteCertificate(certificate2, dir, "test")
+			restore()
+			reloadedCertificate, loadErr := tls.LoadX509KeyPair(certFile, keyFile)
+			require.NoError(t, loadErr)
+			if err == nil {
+				require.Equal(t, certificate2, &reloadedCertificate)
+				require.Less(t, renames, failingRename)
+				requireFileSetEntries(t, dir, "test")
+				break
+			}
+			require.Equal(t, certificate1, &reloadedCertificate)
+		}
+	}
+}
+
+func TestWriteCertificateConcurrentWriters(t *testing.T) {
+	certificates := make([]*tls.Certificate, 4)
+	for i := range certificates {
+		certificate, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+		require.NoError(t, err)
+		certificates[i] = certificate
+	}
+	dir := t.TempDir()
+	wait := sync.WaitGroup{}
+	errs := make(chan error, len(certificates)*8)
+	for _, certificate := range certificates {
+		wait.Go(func() {
+			for range 8 {
+				_, _, err := tlsconf.WriteCertificate(certificate, dir, "test")
+				errs <- err
+			}
+		})
+	}
+	wait.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	reloadedCertificate, err := tls.LoadX509KeyPair(filepath.Join(dir, "test.crt"), filepath.Join(dir, "test.key"))
+	require.NoError(t, err)
+	require.Contains(t, certificates, &reloadedCertificate)
+	requireFileSetEntries(t, dir, "test")
+}
+
+func TestWriteCertificateSimilarNames(t *testing.T) {
+	certificate1, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	certificate2, err := tlsconf.GenerateEphemeralCertificate("localhost", tlsconf.CertificateAlgorithmDefault, time.Hour)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	certFile, keyFile, err := tlsconf.WriteCertificate(certificate1, dir, "test.1")
+	require.NoError(t, err)
+	_, _, err = tlsconf.WriteCertificate(certificate2, dir, "test")
+	require.NoError(t, err)
+	_, _, err = tlsconf.WriteCertificate(certificate2, dir, "test")
+	require.NoError(t, err)
+	reloadedCertificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	require.NoError(t, err)
+	require.Equal(t, certificate1, &reloadedCertificate)
+	store := tlsconf.NewDirKeyStore(dir)
+	require.NoError(t, store.Delete("test"))
+	reloadedCertificate, err = tls.LoadX509KeyPair(certFile, keyFile)
+	require.NoError(t, err)
+	require.Equal(t, certificate1, &reloadedCertificate)
+	requireFileSetEntries(t, dir, "test.1")
+}
+
+func requireFileSetEntries(t *testing.T, dir, name string) {
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	require.Len(t, names, 4)
+	require.Contains(t, names, name+".crt")
+	require.Contains(t, names, name+".key")
+	require.Contains(t, names, "."+name+".current")
+}
+
+func requireFileMode(t *testing.T, file string, mode fs.FileMode) {
+	fileInfo, err := os.Stat(file)
+	require.NoError(t, err)
+	require.Equal(t, mode, fileInfo.Mode().Perm())
+}
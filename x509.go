@@ -18,6 +18,7 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math/big"
@@ -46,7 +47,24 @@ const (
 	CertificateAlgorithmED25519  CertificateAlgorithm = "ed25519"  // ED25519 cipher
 )
 
+// GenerateCertificateKey generates a new key pair for the algorithm.
+//
+// If a [KeyPool] has been enabled for the algorithm (see [EnableKeyPool]), the key pair is
+// taken from the pool (unless deterministic mode is enabled, see [EnableDeterministicMode]).
+// If a global [Policy] has been set (see [SetPolicy]), the algorithm must be approved by it.
 func (algorithm CertificateAlgorithm) GenerateCertificateKey() (crypto.PublicKey, crypto.PrivateKey, error) {
+	err := checkPolicyCertificateAlgorithm(algorithm)
+	if err != nil {
+		return nil, nil, err
+	}
+	pool := lookupKeyPool(algorithm)
+	if pool != nil && !isDeterministic() {
+		return pool.Get()
+	}
+	return algorithm.generateCertificateKey()
+}
+
+func (algorithm CertificateAlgorithm) generateCertificateKey() (crypto.PublicKey, crypto.PrivateKey, error) {
 	switch algorithm {
 	case CertificateAlgorithmRSA2048:
 		return generateRSAKey(2048)
@@ -98,14 +116,9 @@ func generateED25519Key() (crypto.PublicKey, crypto.PrivateKey, error) {
 // suitable for testing purposes.
 func GenerateEphemeralCertificate(address string, algorithm CertificateAlgorithm, lifetime time.Duration) (*tls.Certificate, error) {
 	slog.Info("generating ephemeral certificate", slog.String("address", address), slog.String("algorithm", string(algorithm)))
-	hostOnly := strings.LastIndex(address, ":") < 0
-	host := address
-	if !hostOnly {
-		host0, _, err := net.SplitHostPort(address)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decode address %q (cause %w)", address, err)
-		}
-		host = host0
+	host, err := addressHost(address)
+	if err != nil {
+		return nil, err
 	}
 	publicKey, privateKey, err := algorithm.GenerateCertificateKey()
 	if err != nil {
@@ -130,8 +143,33 @@ func GenerateEphemeralCertificate(address string, algorithm CertificateAlgorithm
 	return &certificate, nil
 }
 
+func addressHost(address string) (string, error) {
+	hostOnly := strings.LastIndex(address, ":") < 0
+	if hostOnly {
+		return address, nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode address %q (cause %w)", address, err)
+	}
+	return host, nil
+}
+
+func setCertificateHost(template *x509.Certificate, host string) {
+	hostIPAddress := net.ParseIP(host)
+	if hostIPAddress != nil {
+		template.IPAddresses = []net.IP{hostIPAddress}
+	} else {
+		template.DNSNames = []string{host}
+	}
+}
+
 func createEphemeralCertificateX509(host string, publicKey crypto.PublicKey, privateKey crypto.PrivateKey, lifetime time.Duration) (*pem.Block, error) {
-	now := time.Now().UTC()
+	err := checkPolicyPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	now := certificateNow()
 	template := &x509.Certificate{
 		SerialNumber: nextCertificateSerialNumber(),
 		Subject:      pkix.Name{CommonName: host},
@@ -141,16 +179,15 @@ func createEphemeralCertificateX509(host string, publicKey crypto.PublicKey, pri
 		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
 		IsCA:         true,
 	}
-	hostIPAddress := net.ParseIP(host)
-	if hostIPAddress != nil {
-		template.IPAddresses = []net.IP{hostIPAddress}
-	} else {
-		template.DNSNames = []string{host}
-	}
+	setCertificateHost(template, host)
 	x509Bytes, err := x509.CreateCertificate(rand.Reader, template, template, publicKey, privateKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create certificate (cause: %w)", err)
 	}
+	err = lintGeneratedCertificate(x509Bytes)
+	if err != nil {
+		return nil, err
+	}
 	x509Block := &pem.Block{
 		Type:  "CERTIFICATE",
 		Bytes: x509Bytes,
@@ -163,6 +200,12 @@ var certificateSerialNumberLock sync.Mutex = sync.Mutex{}
 func nextCertificateSerialNumber() *big.Int {
 	certificateSerialNumberLock.Lock()
 	defer certificateSerialNumberLock.Unlock()
+	if serialNumber := nextStrictSerialNumber(); serialNumber != nil {
+		return serialNumber
+	}
+	if serialNumber := nextDeterministicSerialNumber(); serialNumber != nil {
+		return serialNumber
+	}
 	// wait at least one update, to ensure this functions never returns the same result twice
 	current := time.Now().UnixMilli()
 	for {
@@ -173,12 +216,93 @@ func nextCertificateSerialNumber() *big.Int {
 	}
 }
 
+// ErrNoPrivateKey indicates an attempt to write a certificate without a private key.
+var ErrNoPrivateKey = errors.New("certificate has no private key")
+
 // WriteCertificate writes the given certificate to the given directory using the given name.
 //
 // A successfull write will create two files. The certificate file (<dir>/<name>.crt) containing
 // the full certificate chain. The key file (<dir>/<name>.key) containing the private key.
-func WriteCertificate(certificate *tls.Certificate, dir, name string) (string, string, error) {
-	certFile := filepath.Join(dir, name+".crt")
+// Both files are replaced together in a single atomic step (see [WriteOption] for the available
+// write options). To achieve this, the following layout is used within the given directory:
+//
+//	<name>.crt -> .<name>.current/<name>.crt
+//	<name>.key -> .<name>.current/<name>.key
+//	.<name>.current -> .<name>.<random>
+//	.<name>.<random>/<name>.crt
+//	.<name>.<random>/<name>.key
+//
+// A write creates a new version directory (.<name>.<random>) and switches the version link
+// (.<name>.current) to it. Concurrent writers are serialized via an advisory lock on the
+// directory (on Windows the files are replaced one after another instead).
+// If the private key is not exportable (see [IsExportableKey]), the key file is skipped
+// (respectively an existing key file is removed in the same atomic step) and an empty key file
+// name is returned.
+func WriteCertificate(certificate *tls.Certificate, dir, name string, options ...WriteOption) (string, string, error) {
+	if certificate.PrivateKey == nil {
+		return "", "", ErrNoPrivateKey
+	}
+	if !IsExportableKey(certificate.PrivateKey) {
+		return writeCertificateFiles(certificate, nil, dir, name, options)
+	}
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(certificate.PrivateKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal private key (cause: %w)", err)
+	}
+	keyBlock := &pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: keyBytes,
+	}
+	return writeCertificateFiles(certificate, keyBlock, dir, name, options)
+}
+
+// WriteEncryptedCertificate writes the given certificate to the given directory using the given name
+// and encrypts the private key using the given passphrase and [KeyEncryption] scheme.
+//
+// See [WriteCertificate] for the created files.
+func WriteEncryptedCertificate(certificate *tls.Certificate, dir, name string, passphrase PassphraseFunc, encryption KeyEncryption, options ...WriteOption) (string, string, error) {
+	if certificate.PrivateKey == nil {
+		return "", "", ErrNoPrivateKey
+	}
+	if !IsExportableKey(certificate.PrivateKey) {
+		return writeCertificateFiles(certificate, nil, dir, name, options)
+	}
+	passphraseBytes, err := passphrase()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to retrieve passphrase (cause: %w)", err)
+	}
+	keyBlock, err := EncryptPrivateKey(certificate.PrivateKey, passphraseBytes, encryption)
+	if err != nil {
+		return "", "", err
+	}
+	return writeCertificateFiles(certificate, keyBlock, dir, name, options)
+}
+
+// WritePrivateKey writes the given private key (PEM encoded PKCS#8) to the given file.
+//
+// The file is replaced atomically using the key file mode (see [WriteOption] for the available
+// write options). Non-exportable keys (see [IsExportableKey]) are rejected.
+func WritePrivateKey(privateKey crypto.PrivateKey, keyFile string, options ...WriteOption) error {
+	if privateKey == nil {
+		return ErrNoPrivateKey
+	}
+	if !IsExportableKey(privateKey) {
+		return fmt.Errorf("private key of type %T is not exportable", privateKey)
+	}
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return fmt.Errorf("failed to marshal private key (cause: %w)", err)
+	}
+	keyBlock := &pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: keyBytes,
+	}
+	writeOptions := newWriteOptions(options)
+	return writeFile(&fileWrite{path: keyFile, data: pem.EncodeToMemory(keyBlock), mode: writeOptions.keyMode}, writeOptions)
+}
+
+func writeCertificateFiles(certificate *tls.Certificate, keyBlock *pem.Block, dir, name string, options []WriteOption) (string, string, error) {
+	writeOptions := newWriteOptions(options)
 	encodedCerts := &bytes.Buffer{}
 	for _, cert := range certificate.Certificate {
 		certBlock := &pem.Block{
@@ -187,23 +311,120 @@ func WriteCertificate(certificate *tls.Certificate, dir, name string) (string, s
 		}
 		encodedCerts.Write(pem.EncodeToMemory(certBlock))
 	}
-	err := os.WriteFile(certFile, encodedCerts.Bytes(), 0666)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to write certificate file '%s' (cause: %w)", certFile, err)
+	certFile := filepath.Join(dir, name+".crt")
+	files := []*fileWrite{
+		{path: certFile, data: encodedCerts.Bytes(), mode: writeOptions.certMode},
 	}
 	keyFile := filepath.Join(dir, name+".key")
-	keyBytes, err := x509.MarshalPKCS8PrivateKey(certificate.PrivateKey)
+	if keyBlock != nil {
+		files = append(files, &fileWrite{path: keyFile, data: pem.EncodeToMemory(keyBlock), mode: writeOptions.keyMode})
+	} else {
+		// no key data causes a stale key file to be removed
+		files = append(files, &fileWrite{path: keyFile})
+		keyFile = ""
+	}
+	err := writeFileSet(dir, name, files, writeOptions)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to marshal private key (cause: %w)", err)
+		return "", "", err
 	}
-	keyBlock := &pem.Block{
-		Type:  "PRIVATE KEY",
-		Bytes: keyBytes,
+	return certFile, keyFile, nil
+}
+
+// LoadCertificate loads the certificate and private key from the given files.
+//
+// See [DecodeCertificate] for the supported file formats.
+func LoadCertificate(certFile, keyFile string, passphrase PassphraseFunc) (*tls.Certificate, error) {
+	certData, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate file '%s' (cause: %w)", certFile, err)
 	}
-	encodedKey := pem.EncodeToMemory(keyBlock)
-	err = os.WriteFile(keyFile, encodedKey, 0600)
+	keyData, err := os.ReadFile(keyFile)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to write key file '%s' (cause: %w)", keyFile, err)
+		return nil, fmt.Errorf("failed to read key file '%s' (cause: %w)", keyFile, err)
 	}
-	return certFile, keyFile, nil
+	certificate, err := DecodeCertificate(certData, keyData, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate from '%s' and '%s' (cause: %w)", certFile, keyFile, err)
+	}
+	return certificate, nil
+}
+
+// DecodeCertificate decodes the given certificate and private key data.
+//
+// The certificate data may be in any format supported by [DecodeCertificates]. The key data must
+// contain a PEM encoded private key block supported by [DecodePrivateKey]. Encrypted private keys are
+// decrypted transparently using the given passphrase function (which may be nil, if the private key
+// is not encrypted).
+func DecodeCertificate(certData, keyData []byte, passphrase PassphraseFunc) (*tls.Certificate, error) {
+	certificates, err := DecodeCertificates(certData, DecodeStrict)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode certificate (cause: %w)", err)
+	}
+	var keyBlock *pem.Block
+	rest := keyData
+	for {
+		keyBlock, rest = pem.Decode(rest)
+		if keyBlock == nil || strings.HasSuffix(keyBlock.Type, "PRIVATE KEY") {
+			break
+		}
+	}
+	if keyBlock == nil {
+		return nil, errors.New("no private key found")
+	}
+	privateKey, err := DecodePrivateKey(keyBlock, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode private key (cause: %w)", err)
+	}
+	encodedCerts, err := EncodeCertificates(certificates, CertificateFormatPEM)
+	if err != nil {
+		return nil, err
+	}
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key (cause: %w)", err)
+	}
+	certificate, err := tls.X509KeyPair(encodedCerts, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate (cause: %w)", err)
+	}
+	return &certificate, nil
+}
+
+// EncodeCertificates encodes the given certificates using the given [CertificateFormat].
+//
+// The [CertificateFormatDER] format concatenates the DER encoding of all certificates.
+func EncodeCertificates(certificates []*x509.Certificate, format CertificateFormat) ([]byte, error) {
+	switch format {
+	case CertificateFormatPEM:
+		encodedCerts := &bytes.Buffer{}
+		for _, certificate := range certificates {
+			certBlock := &pem.Block{
+				Type:  "CERTIFICATE",
+				Bytes: certificate.Raw,
+			}
+			encodedCerts.Write(pem.EncodeToMemory(certBlock))
+		}
+		return encodedCerts.Bytes(), nil
+	case CertificateFormatDER:
+		encodedCerts := &bytes.Buffer{}
+		for _, certificate := range certificates {
+			encodedCerts.Write(certificate.Raw)
+		}
+		return encodedCerts.Bytes(), nil
+	case CertificateFormatPKCS7:
+		return marshalPKCS7Certificates(certificates)
+	}
+	return nil, fmt.Errorf("unknown certificate format: %s", format)
+}
+
+// WriteCertificates writes the given certificates to the given file using the given [CertificateFormat].
+//
+// The file is replaced atomically (see [WriteOption] for the available write options).
+func WriteCertificates(certificates []*x509.Certificate, certFile string, format CertificateFormat, options ...WriteOption) error {
+	encodedCerts, err := EncodeCertificates(certificates, format)
+	if err != nil {
+		return err
+	}
+	writeOptions := newWriteOptions(options)
+	return writeFile(&fileWrite{path: certFile, data: encodedCerts, mode: writeOptions.certMode}, writeOptions)
 }